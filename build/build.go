@@ -34,6 +34,8 @@ import (
 	"github.com/gopherjs/gopherjs/compiler/errlist"
 	"github.com/gopherjs/gopherjs/compiler/incjs"
 	"github.com/gopherjs/gopherjs/compiler/sources"
+	"github.com/gopherjs/gopherjs/internal/experiments"
+	"github.com/gopherjs/gopherjs/internal/sizereport"
 	"github.com/gopherjs/gopherjs/internal/sourcemapx"
 	"github.com/gopherjs/gopherjs/internal/testmain"
 )
@@ -56,15 +58,19 @@ var DefaultGOROOT = func() string {
 //
 // Core GopherJS packages (i.e., "github.com/gopherjs/gopherjs/js", "github.com/gopherjs/gopherjs/nosync")
 // are loaded from gopherjspkg.FS virtual filesystem if not present in GOPATH or
-// go.mod.
+// go.mod. Standard library packages missing from the Go distribution, such as
+// "weak" and "unique", are loaded from their natives.FS implementations.
 func NewBuildContext(installSuffix string, buildTags []string) XContext {
 	e := DefaultEnv()
 	e.InstallSuffix = installSuffix
 	e.BuildTags = buildTags
 	realGOROOT := goCtx(e)
 	return &chainedCtx{
-		primary:   realGOROOT,
-		secondary: gopherjsCtx(e),
+		primary: &chainedCtx{
+			primary:   realGOROOT,
+			secondary: gopherjsCtx(e),
+		},
+		secondary: polyfillCtx(e),
 	}
 }
 
@@ -197,6 +203,11 @@ func parseAndAugment(xctx XContext, pkg *PackageData, isTest bool, fileSet *toke
 // parseOverlayFiles loads and parses overlay files
 // to augment the original files with.
 func parseOverlayFiles(xctx XContext, pkg *PackageData, isTest bool, fileSet *token.FileSet) ([]incjs.File, []*ast.File) {
+	if pkg.IsPolyfill {
+		// Polyfill sources already are the overlay files, nothing to augment.
+		return nil, nil
+	}
+
 	isXTest := strings.HasSuffix(pkg.ImportPath, "_test")
 	importPath := pkg.ImportPath
 	if isXTest {
@@ -603,11 +614,19 @@ type Options struct {
 	Watch          bool
 	CreateMapFile  bool
 	MapToLocalDisk bool
+	PositionTable  bool
 	Minify         bool
 	Color          bool
 	BuildTags      []string
 	TestedPackage  string
 	NoCache        bool
+	// Optimize enables the optimization passes run by the compiler before
+	// emitting code: inlining of small functions and dead branch elimination.
+	Optimize bool
+	// SizeReport is the path to write a report attributing the size of the
+	// written command package to its parts into, if not empty. The report is
+	// written as HTML if the path has the .html extension, and as JSON otherwise.
+	SizeReport string
 }
 
 // PrintError message to the terminal.
@@ -637,6 +656,9 @@ type PackageData struct {
 	UpToDate   bool
 	// If true, the package does not have a corresponding physical directory on disk.
 	IsVirtual bool
+	// If true, the package is a standard library package missing from the Go
+	// distribution, which is completely implemented by the natives overlays.
+	IsPolyfill bool
 
 	bctx *build.Context // The original build context this package came from.
 }
@@ -701,9 +723,10 @@ func (p *PackageData) TestPackage() *PackageData {
 			Imports:         append(p.Imports, p.TestImports...),
 			EmbedPatternPos: joinEmbedPatternPos(p.EmbedPatternPos, p.TestEmbedPatternPos),
 		},
-		IsTest:  true,
-		JSFiles: p.JSFiles,
-		bctx:    p.bctx,
+		IsTest:     true,
+		JSFiles:    p.JSFiles,
+		IsPolyfill: p.IsPolyfill,
+		bctx:       p.bctx,
 	}
 }
 
@@ -718,8 +741,9 @@ func (p *PackageData) XTestPackage() *PackageData {
 			Imports:         p.XTestImports,
 			EmbedPatternPos: p.XTestEmbedPatternPos,
 		},
-		IsTest: true,
-		bctx:   p.bctx,
+		IsTest:     true,
+		IsPolyfill: p.IsPolyfill,
+		bctx:       p.bctx,
 	}
 }
 
@@ -1193,6 +1217,13 @@ func (s *Session) prepareAndCompilePackages(rootSrcs *sources.Sources) (*compile
 		return nil, err
 	}
 
+	if experiments.Env.CallGraph || experiments.Env.MangleProps {
+		// Blocking information and property names now depend on all the
+		// packages of the program, so archives compiled for a previous root
+		// package may be out of date.
+		s.UpToDateArchives = map[string]*compiler.Archive{}
+	}
+
 	// Compile all the sources into archives.
 	for _, srcs := range allSources {
 		if _, err := s.compilePackage(srcs, tContext); err != nil {
@@ -1213,7 +1244,7 @@ func (s *Session) compilePackage(srcs *sources.Sources, tContext *types.Context)
 		return archive, nil
 	}
 
-	archive, err := compiler.Compile(srcs, tContext, s.options.Minify)
+	archive, err := compiler.Compile(srcs, tContext, s.options.Minify, s.options.Optimize)
 	if err != nil {
 		return nil, err
 	}
@@ -1297,6 +1328,16 @@ func (s *Session) EnableMapping(filter *sourcemapx.Filter, jsFileName string) {
 	filter.EnableMapping(jsFileName, s.xctx.Env().GOROOT, s.xctx.Env().GOPATH, s.options.MapToLocalDisk)
 }
 
+// EnablePositionTable makes the filter collect the Go position table, which is
+// embedded into the program for runtime.Callers() to report Go positions, if
+// requested by the build options.
+func (s *Session) EnablePositionTable(filter *sourcemapx.Filter) {
+	if !s.options.PositionTable {
+		return
+	}
+	filter.EnablePositionTable(s.xctx.Env().GOROOT, s.xctx.Env().GOPATH, s.options.MapToLocalDisk)
+}
+
 // WriteCommandPackage writes the final JavaScript output file at pkgObj path.
 func (s *Session) WriteCommandPackage(archive *compiler.Archive, pkgObj string) error {
 	if err := os.MkdirAll(filepath.Dir(pkgObj), 0o777); err != nil {
@@ -1309,6 +1350,7 @@ func (s *Session) WriteCommandPackage(archive *compiler.Archive, pkgObj string)
 	defer codeFile.Close()
 
 	sourceMapFilter := &sourcemapx.Filter{Writer: codeFile}
+	s.EnablePositionTable(sourceMapFilter)
 	if s.options.CreateMapFile {
 		s.EnableMapping(sourceMapFilter, filepath.Base(pkgObj))
 
@@ -1328,7 +1370,21 @@ func (s *Session) WriteCommandPackage(archive *compiler.Archive, pkgObj string)
 	if err != nil {
 		return err
 	}
-	return compiler.WriteProgramCode(deps, sourceMapFilter, s.GoRelease())
+	if s.options.SizeReport == "" {
+		return compiler.WriteProgramCode(deps, sourceMapFilter, s.GoRelease())
+	}
+
+	report := &sizereport.Report{}
+	if err := compiler.WriteProgramCodeWithReport(deps, sourceMapFilter, s.GoRelease(), report); err != nil {
+		return err
+	}
+	report.Sort()
+	reportFile, err := os.Create(s.options.SizeReport)
+	if err != nil {
+		return err
+	}
+	defer reportFile.Close()
+	return report.Write(reportFile, s.options.SizeReport)
 }
 
 // WaitForChange watches file system events and returns if either when one of
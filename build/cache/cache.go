@@ -15,6 +15,8 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
+
+	"github.com/gopherjs/gopherjs/internal/experiments"
 )
 
 // Cacheable defines methods to serialize and deserialize cachable objects.
@@ -248,22 +250,25 @@ func (bc *BuildCache) deserialize(c Cacheable, srcModTime time.Time, r io.Reader
 // under a given BuildCache configuration.
 func (bc *BuildCache) commonKey() string {
 	type commonKey struct {
-		GOOS      string
-		GOARCH    string
-		GOROOT    string
-		GOPATH    string
-		BuildTags []string
-		Version   string
+		GOOS        string
+		GOARCH      string
+		GOROOT      string
+		GOPATH      string
+		BuildTags   []string
+		Version     string
+		Experiments experiments.Flags
 	}
 	// These are the values that affect the files that are included into a
-	// package's source via build constraints.
+	// package's source via build constraints, and the experiments that affect
+	// the generated code.
 	ck := commonKey{
-		GOOS:      bc.GOOS,
-		GOARCH:    bc.GOARCH,
-		GOROOT:    bc.GOROOT,
-		GOPATH:    bc.GOPATH,
-		BuildTags: bc.BuildTags,
-		Version:   bc.Version,
+		GOOS:        bc.GOOS,
+		GOARCH:      bc.GOARCH,
+		GOROOT:      bc.GOROOT,
+		GOPATH:      bc.GOPATH,
+		BuildTags:   bc.BuildTags,
+		Version:     bc.Version,
+		Experiments: experiments.Env,
 	}
 	return fmt.Sprintf("%#v", ck)
 }
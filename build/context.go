@@ -341,6 +341,13 @@ func overlayCtx(e Env) *simpleCtx {
 	return embeddedCtx(&withPrefix{fs: http.FS(natives.FS), prefix: e.GOROOT}, e)
 }
 
+// polyfillCtx creates a context that imports standard library packages, which
+// are missing from the Go distribution GopherJS is compatible with, from their
+// complete implementations in the embedded standard library overlays.
+func polyfillCtx(e Env) *polyfilledCtx {
+	return &polyfilledCtx{overlay: overlayCtx(e)}
+}
+
 // gopherjsCtx creates a simpleCtx that imports from the embedded gopherjs
 // packages in case they are not present in the user's source tree.
 func gopherjsCtx(e Env) *simpleCtx {
@@ -452,6 +459,45 @@ func (cc chainedCtx) Match(patterns []string) ([]string, error) {
 	return matches, nil
 }
 
+// polyfilledPackages is a set of standard library packages introduced in Go
+// releases newer than the one GopherJS is compatible with, which the natives
+// overlays implement in their entirety.
+var polyfilledPackages = map[string]bool{
+	"unique": true,
+	"weak":   true,
+}
+
+// polyfilledCtx resolves polyfilled standard library packages from the natives
+// overlays and reports all other packages as not found.
+//
+// It is meant to be used as the last fallback of a chainedCtx, so that the real
+// standard library takes precedence whenever it provides the package.
+type polyfilledCtx struct {
+	overlay *simpleCtx
+}
+
+// Import implements XContext.Import().
+func (pc polyfilledCtx) Import(importPath string, srcDir string, mode build.ImportMode) (*PackageData, error) {
+	if !polyfilledPackages[importPath] {
+		return nil, fmt.Errorf("cannot find package %q in any of the polyfilled packages", importPath)
+	}
+	pkg, err := pc.overlay.Import(importPath, srcDir, mode)
+	if err != nil {
+		return nil, err
+	}
+	pkg.IsPolyfill = true
+	return pkg, nil
+}
+
+// Env implements XContext.Env().
+func (pc polyfilledCtx) Env() Env { return pc.overlay.Env() }
+
+// Match implements XContext.Match().
+//
+// Polyfilled packages are never matched by patterns, same as the standard
+// library packages they substitute are not matched by patterns such as "./...".
+func (pc polyfilledCtx) Match(patterns []string) ([]string, error) { return nil, nil }
+
 // IsPkgNotFound returns true if the error was caused by package not found.
 //
 // Unfortunately, go/build doesn't make use of typed errors, so we have to
@@ -459,7 +505,8 @@ func (cc chainedCtx) Match(patterns []string) ([]string, error) {
 func IsPkgNotFound(err error) bool {
 	return err != nil &&
 		(strings.Contains(err.Error(), "cannot find package") || // Modules off.
-			strings.Contains(err.Error(), "is not in GOROOT")) // Modules on.
+			strings.Contains(err.Error(), "is not in GOROOT") || // Modules on.
+			strings.Contains(err.Error(), "is not in std")) // Modules on, newer Go releases.
 }
 
 // updateImports package's list of import paths to only those present in sources
@@ -151,6 +151,34 @@ func TestChainedCtx(t *testing.T) {
 	}
 }
 
+func TestPolyfilledCtx(t *testing.T) {
+	pc := polyfillCtx(DefaultEnv())
+
+	t.Run("polyfilled", func(t *testing.T) {
+		for importPath := range polyfilledPackages {
+			pkg, err := pc.Import(importPath, "", 0)
+			if err != nil {
+				t.Fatalf("pc.Import(%q) returned error: %v. Want: no error.", importPath, err)
+			}
+			if !pkg.IsPolyfill || !pkg.IsVirtual {
+				t.Errorf("Got pc.Import(%q) with IsPolyfill=%t, IsVirtual=%t. Want: both true.", importPath, pkg.IsPolyfill, pkg.IsVirtual)
+			}
+			if len(pkg.GoFiles) == 0 {
+				t.Errorf("Got pc.Import(%q) with no Go files. Want: polyfill sources.", importPath)
+			}
+		}
+	})
+
+	t.Run("not polyfilled", func(t *testing.T) {
+		// Packages that have natives overlays, but don't need a polyfill, must be
+		// resolved from the real GOROOT.
+		_, err := pc.Import("fmt", "", 0)
+		if !IsPkgNotFound(err) {
+			t.Errorf("pc.Import(%q) returned error: %v. Want: package not found error.", "fmt", err)
+		}
+	})
+}
+
 func TestIsStd(t *testing.T) {
 	realGOROOT := goCtx(DefaultEnv())
 	overlayGOROOT := overlayCtx(DefaultEnv())
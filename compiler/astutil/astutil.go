@@ -178,6 +178,47 @@ func OverrideSignature(d *ast.FuncDecl) bool {
 	return hasDirective(d, `override-signature`)
 }
 
+// NoInline returns true if the go:noinline directive is present in the doc
+// comment of a function decl.
+//
+// `//go:noinline` is a standard Go compiler directive, which GopherJS honors
+// by never inlining calls of the function with optimizations enabled.
+func NoInline(d *ast.FuncDecl) bool {
+	if d.Doc == nil {
+		return false
+	}
+	for _, c := range d.Doc.List {
+		if c.Text == `//go:noinline` || strings.HasPrefix(c.Text, `//go:noinline `) {
+			return true
+		}
+	}
+	return false
+}
+
+// JSNaming returns the argument of the gopherjs:js-naming directive in the
+// file's package doc comment and its position, or an empty string if there is
+// no such directive.
+//
+// `//gopherjs:js-naming <strategy>` is a GopherJS-specific directive, which
+// sets the naming strategy for the JavaScript properties of exported struct
+// fields without an explicit name in the `js:"..."` tag. It applies to the
+// struct types declared in the package when their values are converted to or
+// from JavaScript objects.
+func JSNaming(file *ast.File) (string, token.Pos) {
+	if file.Doc == nil {
+		return "", token.NoPos
+	}
+	for _, c := range file.Doc.List {
+		m := directiveMatcher.FindStringSubmatch(c.Text)
+		if len(m) != 2 || m[1] != `js-naming` {
+			continue
+		}
+		arg := strings.TrimSuffix(c.Text[len(m[0]):], "*/")
+		return strings.TrimSpace(arg), c.Pos()
+	}
+	return "", token.NoPos
+}
+
 // directiveMatcher is a regex which matches a GopherJS directive
 // and finds the directive action.
 var directiveMatcher = regexp.MustCompile(`^\/(?:\/|\*)gopherjs:([\w-]+)`)
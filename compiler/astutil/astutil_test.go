@@ -404,6 +404,95 @@ func TestHasDirectiveOnFile(t *testing.T) {
 	}
 }
 
+func TestNoInline(t *testing.T) {
+	tests := []struct {
+		desc string
+		src  string
+		want bool
+	}{
+		{
+			desc: `no directive`,
+			src: `// foo does stuff.
+				func foo() {}`,
+			want: false,
+		}, {
+			desc: `directive`,
+			src: `//go:noinline
+				func foo() {}`,
+			want: true,
+		}, {
+			desc: `directive after doc`,
+			src: `// foo does stuff.
+				//
+				//go:noinline
+				func foo() {}`,
+			want: true,
+		}, {
+			desc: `directive in text`,
+			src: `// foo is marked with //go:noinline.
+				func foo() {}`,
+			want: false,
+		}, {
+			desc: `other directive`,
+			src: `//go:noinlinefoo
+				func foo() {}`,
+			want: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			src := "package testpackage\n" + test.src
+			fdecl := srctesting.ParseFuncDecl(t, src)
+			if got := NoInline(fdecl); got != test.want {
+				t.Errorf(`NoInline() returned %t, want %t`, got, test.want)
+			}
+		})
+	}
+}
+
+func TestJSNaming(t *testing.T) {
+	tests := []struct {
+		desc string
+		src  string
+		want string
+	}{
+		{
+			desc: `no directive`,
+			src: `// Package testpackage does stuff.
+				package testpackage`,
+			want: ``,
+		}, {
+			desc: `directive in package doc`,
+			src: `// Package testpackage does stuff.
+				//
+				//gopherjs:js-naming camelCase
+				package testpackage`,
+			want: `camelCase`,
+		}, {
+			desc: `block comment directive`,
+			src: `/*gopherjs:js-naming snake_case */
+				package testpackage`,
+			want: `snake_case`,
+		}, {
+			desc: `directive not in package doc`,
+			src: `package testpackage
+				//gopherjs:js-naming camelCase
+				type Foo int`,
+			want: ``,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			file := srctesting.New(t).Parse("test.go", test.src)
+			if got, _ := JSNaming(file); got != test.want {
+				t.Errorf(`JSNaming() returned %q, want %q`, got, test.want)
+			}
+		})
+	}
+}
+
 func TestHasDirectiveOnField(t *testing.T) {
 	tests := []struct {
 		desc string
@@ -14,8 +14,11 @@ import (
 
 	"github.com/gopherjs/gopherjs/compiler/incjs"
 	"github.com/gopherjs/gopherjs/compiler/internal/dce"
+	"github.com/gopherjs/gopherjs/compiler/internal/symbol"
 	"github.com/gopherjs/gopherjs/compiler/linkname"
 	"github.com/gopherjs/gopherjs/compiler/prelude"
+	"github.com/gopherjs/gopherjs/internal/experiments"
+	"github.com/gopherjs/gopherjs/internal/sizereport"
 	"github.com/gopherjs/gopherjs/internal/sourcemapx"
 )
 
@@ -129,39 +132,33 @@ func DefaultFilter(w io.Writer) *sourcemapx.Filter {
 }
 
 func WriteProgramCode(pkgs []*Archive, w *sourcemapx.Filter, goVersion string) error {
+	return WriteProgramCodeWithReport(pkgs, w, goVersion, nil)
+}
+
+// WriteProgramCodeWithReport is like WriteProgramCode, but additionally fills
+// the given report with the sizes of the emitted packages and declarations.
+// The report may be nil, in which case nothing is recorded.
+func WriteProgramCodeWithReport(pkgs []*Archive, w *sourcemapx.Filter, goVersion string, report *sizereport.Report) error {
 	mainPkg := pkgs[len(pkgs)-1]
 	minify := mainPkg.Minified
 
-	// Aggregate all go:linkname directives in the program together.
-	gls := linkname.GoLinknameSet{}
-	for _, pkg := range pkgs {
-		gls.Add(pkg.GoLinknames)
-	}
-
-	sel := &dce.Selector[*Decl]{}
-	for _, pkg := range pkgs {
-		for _, d := range pkg.Declarations {
-			implementsLink := false
-			if gls.IsImplementation(d.LinkingName) {
-				// If a decl is referenced by a go:linkname directive, we just assume
-				// it's not dead.
-				// TODO(nevkontakte): This is a safe, but imprecise assumption. We should
-				// try and trace whether the referencing functions are actually live.
-				implementsLink = true
-			}
-			sel.Include(d, implementsLink)
-		}
-	}
+	gls, sel := selectLiveDecls(pkgs)
 	dceSelection := sel.AliveDecls()
 
+	rec := newSizeRecorder(report, w, sel, mainPkg)
+	defer rec.finish()
+
 	if _, err := writeF(w, false, "\"use strict\";\n(function() {\n\n"); err != nil {
 		return err
 	}
 	if _, err := writeF(w, false, "var $goVersion = %q;\n", goVersion); err != nil {
 		return err
 	}
+	if _, err := writeF(w, false, "var $bigInt64 = %t;\n", experiments.Env.BigInt64); err != nil {
+		return err
+	}
 	for _, preludeFile := range prelude.PreludeFiles() {
-		if _, err := w.WriteJS(preludeFile.Source, preludeFile.Name, minify); err != nil {
+		if err := rec.writeJS(w, rec.prelude(), preludeFile.Source, preludeFile.Name, minify); err != nil {
 			return err
 		}
 	}
@@ -169,9 +166,26 @@ func WriteProgramCode(pkgs []*Archive, w *sourcemapx.Filter, goVersion string) e
 		return err
 	}
 
-	// write packages
+	// write packages, except for the ones eliminated as a whole
 	for _, pkg := range pkgs {
-		if err := WritePkgCode(pkg, dceSelection, gls, minify, w); err != nil {
+		if !sel.PackageAlive(pkg.ImportPath) {
+			continue
+		}
+		if err := writePkgCode(pkg, dceSelection, gls, minify, w, rec); err != nil {
+			return err
+		}
+	}
+
+	if w.HasPositionTable() {
+		// The table must be written after all packages, so that it covers their
+		// code, but before any Go code runs, so that it's available to the runtime.
+		if _, err := writeF(w, false, "$goPositions = "); err != nil {
+			return err
+		}
+		if _, err := w.WritePositionTable(); err != nil {
+			return err
+		}
+		if _, err := writeF(w, false, ";\n"); err != nil {
 			return err
 		}
 	}
@@ -188,11 +202,19 @@ func WriteProgramCode(pkgs []*Archive, w *sourcemapx.Filter, goVersion string) e
 	if _, err := writeF(w, false, "var $mainPkg = $packages[\"%s\"];\n", mainPkg.ImportPath); err != nil {
 		return err
 	}
-	if _, err := writeF(w, false, "$packages[\"runtime\"].$init();\n"); err != nil {
-		return err
-	}
-	if _, err := writeF(w, false, "$go($mainPkg.$init, []);\n"); err != nil {
-		return err
+	if experiments.Env.AsyncAwait {
+		// Async $init() functions return before the initialization completes, so
+		// runtime is initialized in the main goroutine, ahead of the main package.
+		if _, err := writeF(w, false, "$go(async () => { await $packages[\"runtime\"].$init(); await $mainPkg.$init(); }, []);\n"); err != nil {
+			return err
+		}
+	} else {
+		if _, err := writeF(w, false, "$packages[\"runtime\"].$init();\n"); err != nil {
+			return err
+		}
+		if _, err := writeF(w, false, "$go($mainPkg.$init, []);\n"); err != nil {
+			return err
+		}
 	}
 	if _, err := writeF(w, false, "$flushConsole();\n"); err != nil {
 		return err
@@ -203,7 +225,64 @@ func WriteProgramCode(pkgs []*Archive, w *sourcemapx.Filter, goVersion string) e
 	return nil
 }
 
+// selectLiveDecls aggregates all go:linkname directives in the program and
+// prepares dead-code elimination over the declarations of all its packages.
+func selectLiveDecls(pkgs []*Archive) (linkname.GoLinknameSet, *dce.Selector[*Decl]) {
+	gls := linkname.GoLinknameSet{}
+	for _, pkg := range pkgs {
+		gls.Add(pkg.GoLinknames)
+	}
+
+	sel := &dce.Selector[*Decl]{}
+	if experiments.Env.MethodDCE {
+		// The prelude calls these methods of panic values and time.Time by name.
+		sel.EliminateMethods("Error", "String", "UnixNano")
+	}
+	impls := map[symbol.Name][]*Decl{}
+	mainPkg := pkgs[len(pkgs)-1]
+	for _, pkg := range pkgs {
+		if pkg == mainPkg || pkg.ImportPath == "runtime" || len(pkg.IncJSCode) > 0 {
+			// The program starts by initializing runtime and main, and
+			// hand-written JavaScript may depend on anything in its package.
+			sel.KeepPackage(pkg.ImportPath)
+		}
+		for _, d := range pkg.Declarations {
+			implementsLink := gls.IsImplementation(d.LinkingName)
+			if implementsLink {
+				impls[d.LinkingName] = append(impls[d.LinkingName], d)
+			}
+			sel.IncludeInPackage(pkg.ImportPath, d, implementsLink)
+		}
+	}
+
+	// A go:linkname implementation is alive only if any of the functions
+	// referencing it is alive.
+	for _, pkg := range pkgs {
+		for _, d := range pkg.Declarations {
+			if impl, found := gls.FindImplementation(d.LinkingName); found {
+				for _, implDecl := range impls[impl] {
+					sel.IncludeLink(d, implDecl)
+				}
+			}
+		}
+	}
+	return gls, sel
+}
+
 func WritePkgCode(pkg *Archive, dceSelection map[*Decl]struct{}, gls linkname.GoLinknameSet, minify bool, w *sourcemapx.Filter) error {
+	return writePkgCode(pkg, dceSelection, gls, minify, w, nil)
+}
+
+func writePkgCode(pkg *Archive, dceSelection map[*Decl]struct{}, gls linkname.GoLinknameSet, minify bool, w *sourcemapx.Filter, rec *sizeRecorder) error {
+	var filteredDecls []*Decl
+	for _, d := range pkg.Declarations {
+		if _, ok := dceSelection[d]; ok {
+			filteredDecls = append(filteredDecls, d)
+		}
+	}
+	rec.startPackage(pkg, filteredDecls)
+	defer rec.endPackage()
+
 	if w.IsMapping() && pkg.FileSet != nil {
 		w.FileSet = pkg.FileSet
 	}
@@ -212,7 +291,7 @@ func WritePkgCode(pkg *Archive, dceSelection map[*Decl]struct{}, gls linkname.Go
 		if _, err := writeF(w, minify, "\t(function() {\n"); err != nil {
 			return err
 		}
-		if _, err := w.WriteJS(string(jsFile.Content), jsFile.Path, minify); err != nil {
+		if err := rec.writeJS(w, rec.jsFiles(), string(jsFile.Content), jsFile.Path, minify); err != nil {
 			return err
 		}
 		if _, err := writeF(w, minify, "\n\t}).call($global);\n"); err != nil {
@@ -224,12 +303,8 @@ func WritePkgCode(pkg *Archive, dceSelection map[*Decl]struct{}, gls linkname.Go
 		return err
 	}
 	vars := []string{"$pkg = {}", "$init"}
-	var filteredDecls []*Decl
-	for _, d := range pkg.Declarations {
-		if _, ok := dceSelection[d]; ok {
-			vars = append(vars, d.Vars...)
-			filteredDecls = append(filteredDecls, d)
-		}
+	for _, d := range filteredDecls {
+		vars = append(vars, d.Vars...)
 	}
 	// Write variable names
 	if _, err := writeF(w, minify, "\tvar %s;\n", strings.Join(vars, ", ")); err != nil {
@@ -237,19 +312,19 @@ func WritePkgCode(pkg *Archive, dceSelection map[*Decl]struct{}, gls linkname.Go
 	}
 	// Write imports
 	for _, d := range filteredDecls {
-		if _, err := w.Write(d.ImportCode); err != nil {
+		if err := rec.writeDecl(w, d, "ImportCode", d.ImportCode); err != nil {
 			return err
 		}
 	}
 	// Write named type declarations
 	for _, d := range filteredDecls {
-		if _, err := w.Write(d.TypeDeclCode); err != nil {
+		if err := rec.writeDecl(w, d, "TypeDeclCode", d.TypeDeclCode); err != nil {
 			return err
 		}
 	}
 	// Write exports for named type declarations
 	for _, d := range filteredDecls {
-		if _, err := w.Write(d.ExportTypeCode); err != nil {
+		if err := rec.writeDecl(w, d, "ExportTypeCode", d.ExportTypeCode); err != nil {
 			return err
 		}
 	}
@@ -263,31 +338,31 @@ func WritePkgCode(pkg *Archive, dceSelection map[*Decl]struct{}, gls linkname.Go
 
 	// Write anonymous type declarations
 	for _, d := range filteredDecls {
-		if _, err := w.Write(d.AnonTypeDeclCode); err != nil {
+		if err := rec.writeDecl(w, d, "AnonTypeDeclCode", d.AnonTypeDeclCode); err != nil {
 			return err
 		}
 	}
 	// Write function declarations
 	for _, d := range filteredDecls {
-		if _, err := w.Write(d.FuncDeclCode); err != nil {
+		if err := rec.writeDecl(w, d, "FuncDeclCode", d.FuncDeclCode); err != nil {
 			return err
 		}
 	}
 	// Write exports for function declarations
 	for _, d := range filteredDecls {
-		if _, err := w.Write(d.ExportFuncCode); err != nil {
+		if err := rec.writeDecl(w, d, "ExportFuncCode", d.ExportFuncCode); err != nil {
 			return err
 		}
 	}
 	// Write reflection metadata for types' methods
 	for _, d := range filteredDecls {
-		if _, err := w.Write(d.MethodListCode); err != nil {
+		if err := rec.writeDecl(w, d, "MethodListCode", d.MethodListCode); err != nil {
 			return err
 		}
 	}
 	// Write the calls to finish initialization of types
 	for _, d := range filteredDecls {
-		if _, err := w.Write(d.TypeInitCode); err != nil {
+		if err := rec.writeDecl(w, d, "TypeInitCode", d.TypeInitCode); err != nil {
 			return err
 		}
 	}
@@ -298,11 +373,13 @@ func WritePkgCode(pkg *Archive, dceSelection map[*Decl]struct{}, gls linkname.Go
 			// callers via $linkname object (declared in prelude). We are not using
 			// $pkg to avoid clashes with exported symbols.
 			if recv, method, ok := d.LinkingName.IsMethod(); ok {
-				if _, err := writeF(w, minify, "\t$linknames[%q] = $unsafeMethodToFunction(%v,%q,%t);\n", d.LinkingName.String(), d.NamedRecvType, method, strings.HasPrefix(recv, "*")); err != nil {
+				code := fmt.Sprintf("\t$linknames[%q] = $unsafeMethodToFunction(%v,%q,%t);\n", d.LinkingName.String(), d.NamedRecvType, method, strings.HasPrefix(recv, "*"))
+				if err := rec.writeDecl(w, d, "LinknameCode", removeWhitespace([]byte(code), minify)); err != nil {
 					return err
 				}
 			} else {
-				if _, err := writeF(w, minify, "\t$linknames[%q] = %s;\n", d.LinkingName.String(), d.RefExpr); err != nil {
+				code := fmt.Sprintf("\t$linknames[%q] = %s;\n", d.LinkingName.String(), d.RefExpr)
+				if err := rec.writeDecl(w, d, "LinknameCode", removeWhitespace([]byte(code), minify)); err != nil {
 					return err
 				}
 			}
@@ -338,22 +415,32 @@ func WritePkgCode(pkg *Archive, dceSelection map[*Decl]struct{}, gls linkname.Go
 
 	// Write the initialization function that will initialize this package
 	// (e.g. initialize package-level variable value).
-	if _, err := writeF(w, minify, "\t$init = function() {\n"); err != nil {
-		return err
+	if experiments.Env.AsyncAwait {
+		if _, err := writeF(w, minify, "\t$init = async function() {\n"); err != nil {
+			return err
+		}
+	} else {
+		if _, err := writeF(w, minify, "\t$init = function() {\n"); err != nil {
+			return err
+		}
 	}
 	if _, err := writeF(w, minify, "\t\t$pkg.$init = function() {};\n"); err != nil {
 		return err
 	}
-	if _, err := writeF(w, minify, "\t\t/* */ var $f, $c = false, $s = 0, $r; if (this !== undefined && this.$blk !== undefined) { $f = this; $c = true; $s = $f.$s; $r = $f.$r; } s: while (true) { switch ($s) { case 0:\n"); err != nil {
-		return err
+	if !experiments.Env.AsyncAwait {
+		if _, err := writeF(w, minify, "\t\t/* */ var $f, $c = false, $s = 0, $r; if (this !== undefined && this.$blk !== undefined) { $f = this; $c = true; $s = $f.$s; $r = $f.$r; } s: while (true) { switch ($s) { case 0:\n"); err != nil {
+			return err
+		}
 	}
 	for _, d := range filteredDecls {
-		if _, err := w.Write(d.InitCode); err != nil {
+		if err := rec.writeDecl(w, d, "InitCode", d.InitCode); err != nil {
 			return err
 		}
 	}
-	if _, err := writeF(w, minify, "\t\t/* */ } return; } if ($f === undefined) { $f = { $blk: $init }; } $f.$s = $s; $f.$r = $r; return $f;\n"); err != nil {
-		return err
+	if !experiments.Env.AsyncAwait {
+		if _, err := writeF(w, minify, "\t\t/* */ } return; } if ($f === undefined) { $f = { $blk: $init }; } $f.$s = $s; $f.$r = $r; return $f;\n"); err != nil {
+			return err
+		}
 	}
 	if _, err := writeF(w, minify, "\t};\n"); err != nil {
 		return err
@@ -2,7 +2,9 @@ package compiler
 
 import (
 	"bytes"
+	"go/token"
 	"go/types"
+	"path"
 	"regexp"
 	"sort"
 	"strings"
@@ -15,6 +17,8 @@ import (
 	"github.com/gopherjs/gopherjs/compiler/internal/dce"
 	"github.com/gopherjs/gopherjs/compiler/linkname"
 	"github.com/gopherjs/gopherjs/compiler/sources"
+	"github.com/gopherjs/gopherjs/internal/experiments"
+	"github.com/gopherjs/gopherjs/internal/sizereport"
 	"github.com/gopherjs/gopherjs/internal/srctesting"
 )
 
@@ -76,6 +80,27 @@ func TestDeclSelection_KeepUnusedExportedMethods(t *testing.T) {
 	sel.IsAlive(`func:command-line-arguments.Foo.Baz`)
 }
 
+func TestDeclSelection_RemoveUnusedJSONConverters(t *testing.T) {
+	src := `
+		package main
+		type Foo struct {
+			Name string ` + "`json:\"name\"`" + `
+		}
+		func main() {
+			println(Foo{Name: "foo"}.Name)
+		}`
+
+	srcFiles := []srctesting.Source{{Name: `main.go`, Contents: []byte(src)}}
+	sel := declSelection(t, srcFiles, nil)
+
+	sel.IsAlive(`type:command-line-arguments.Foo`)
+	sel.IsDead(`MarshalObject:command-line-arguments.Foo`)
+	sel.IsDead(`UnmarshalObject:command-line-arguments.Foo`)
+	if code := string(sel.FindDecl(`type:command-line-arguments.Foo`).TypeInitCode); strings.Contains(code, `jsonEncode`) {
+		t.Errorf("JSON converters are emitted with the type:\n%s", code)
+	}
+}
+
 func TestDeclSelection_RemoveUnusedUnexportedMethods(t *testing.T) {
 	src := `
 		package main
@@ -1109,6 +1134,503 @@ func collectDeclInstances(t *testing.T, pkg *Archive) []string {
 	return insts
 }
 
+func TestSizeReport(t *testing.T) {
+	src := `
+		package main
+
+		func used() int { return 42 }
+
+		func unused() int { return 0 }
+
+		func main() {
+			println(used())
+		}`
+
+	srcFiles := []srctesting.Source{{Name: `main.go`, Contents: []byte(src)}}
+	root := srctesting.ParseSources(t, srcFiles, nil)
+	archives := compileProject(t, root, false)
+	mainPkg := archives[root.PkgPath]
+
+	buf := &bytes.Buffer{}
+	report := &sizereport.Report{}
+	if err := WriteProgramCodeWithReport([]*Archive{mainPkg}, &sourcemapx.Filter{Writer: buf}, `go1.20`, report); err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Total.Bytes != buf.Len() {
+		t.Errorf(`report total %d != written bytes %d`, report.Total.Bytes, buf.Len())
+	}
+	if report.Total.MinifiedBytes >= report.Total.Bytes {
+		t.Errorf(`minified size %d is not smaller than size %d`, report.Total.MinifiedBytes, report.Total.Bytes)
+	}
+	if len(report.Packages) != 1 {
+		t.Fatalf(`got %d packages in report, want 1`, len(report.Packages))
+	}
+
+	decls := map[string]*sizereport.Decl{}
+	for _, d := range report.Packages[0].Decls {
+		decls[d.Name] = d
+	}
+	if _, ok := decls[`func:command-line-arguments.unused`]; ok {
+		t.Error(`dead function is present in the report`)
+	}
+	used, ok := decls[`func:command-line-arguments.used`]
+	if !ok {
+		t.Fatal(`live function is missing from the report`)
+	}
+	if used.Parts[`FuncDeclCode`].Bytes == 0 {
+		t.Error(`live function has no FuncDeclCode bytes attributed`)
+	}
+	if used.Retention.Root != `` || used.Retention.KeptBy == `` {
+		t.Errorf(`unexpected retention of a function kept by a dependency: %+v`, used.Retention)
+	}
+}
+
+func TestPackageDCE(t *testing.T) {
+	src1 := `
+		package main
+
+		import (
+			"github.com/gopherjs/gopherjs/compiler/ripley"
+			_ "github.com/gopherjs/gopherjs/compiler/bishop"
+			_ "github.com/gopherjs/gopherjs/compiler/vasquez"
+		)
+
+		func main() {
+			ripley.Quote()
+		}`
+	src2 := `package ripley
+		var quote string
+		func init() { quote = "Get away from her, you bitch!" }
+		func Quote() { println(quote) }`
+	src3 := `package bishop
+		var table [16]int
+		func init() {
+			for i := range table {
+				table[i] = i * i
+			}
+		}
+		func Square(i int) int { return table[i] }`
+	src4 := `package vasquez
+		func init() { println("Let's rock!") }`
+
+	root := srctesting.ParseSources(t,
+		[]srctesting.Source{
+			{Name: `main.go`, Contents: []byte(src1)},
+		},
+		[]srctesting.Source{
+			{Name: `ripley/ellen.go`, Contents: []byte(src2)},
+			{Name: `bishop/android.go`, Contents: []byte(src3)},
+			{Name: `vasquez/jenette.go`, Contents: []byte(src4)},
+		})
+	archives := compileProject(t, root, false)
+	pkgs := []*Archive{}
+	for _, path := range []string{`ripley`, `bishop`, `vasquez`} {
+		pkgs = append(pkgs, archives[`github.com/gopherjs/gopherjs/compiler/`+path])
+	}
+	pkgs = append(pkgs, archives[root.PkgPath])
+
+	buf := &bytes.Buffer{}
+	if err := WriteProgramCode(pkgs, &sourcemapx.Filter{Writer: buf}, `go1.20`); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+
+	for _, path := range []string{`ripley`, `vasquez`} {
+		if !strings.Contains(got, `$packages["github.com/gopherjs/gopherjs/compiler/`+path+`"] = `) {
+			t.Errorf(`package %s used by main or with side effects in init was eliminated`, path)
+		}
+	}
+	if strings.Contains(got, `compiler/bishop`) {
+		t.Error(`unused package bishop without side effects was not eliminated`)
+	}
+	if !strings.Contains(got, `Get away from her`) {
+		t.Error(`init function of the used package ripley was eliminated`)
+	}
+}
+
+func TestMethodDCE(t *testing.T) {
+	src := `
+		package main
+
+		type Speaker interface{ Speak() string }
+
+		type Dog struct{}
+
+		func (Dog) Speak() string { return "woof" }
+
+		func (Dog) Fetch() string { return "stick" }
+
+		func main() {
+			var s Speaker = Dog{}
+			println(s.Speak())
+		}`
+
+	srcFiles := []srctesting.Source{{Name: `main.go`, Contents: []byte(src)}}
+	root := srctesting.ParseSources(t, srcFiles, nil)
+	archives := compileProject(t, root, false)
+	pkgs := []*Archive{archives[root.PkgPath]}
+
+	write := func(methodDCE bool) string {
+		setExperiment(t, &experiments.Env.MethodDCE, methodDCE)
+		buf := &bytes.Buffer{}
+		if err := WriteProgramCode(pkgs, &sourcemapx.Filter{Writer: buf}, `go1.20`); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	if got := write(false); !strings.Contains(got, `"stick"`) {
+		t.Error(`exported method Fetch was eliminated without the methoddce experiment`)
+	}
+	got := write(true)
+	if strings.Contains(got, `"stick"`) {
+		t.Error(`uncalled exported method Fetch was not eliminated`)
+	}
+	if !strings.Contains(got, `"woof"`) {
+		t.Error(`exported method Speak called through an interface was eliminated`)
+	}
+}
+
+func TestAsyncAwait(t *testing.T) {
+	src := `
+		package main
+
+		func sum(c chan int) (total int) {
+			defer func() { recover() }()
+			for v := range c {
+				if v < 0 {
+					panic("negative")
+				}
+				total += v
+			}
+			return total
+		}
+
+		type producer interface{ produce(c chan int) }
+
+		type counter int
+
+		func (n counter) produce(c chan int) {
+			for i := 0; i < int(n); i++ {
+				c <- i
+			}
+			close(c)
+		}
+
+		func main() {
+			c := make(chan int)
+			go func() {
+				var p producer = counter(10)
+				p.produce(c)
+			}()
+			println(sum(c))
+		}`
+
+	setExperiment(t, &experiments.Env.AsyncAwait, false)
+	resumable := compileMain(t, src, nil, false)
+	setExperiment(t, &experiments.Env.AsyncAwait, true)
+	async := compileMain(t, src, nil, false)
+	for _, want := range []string{`async function sum`, `await sum(`, `_r = $recv(_2); if ($isAsyncCall($recv, _r)) { _r = await _r; }`, `_r = (_recv = p).produce(c[0]); if ($isAsyncCall(_recv.produce, _r))`, `.prototype.produce = async function(...$args) {`, `await $callDeferredAsync(`} {
+		if !strings.Contains(async, want) {
+			t.Errorf("async output doesn't contain %q:\n%s", want, async)
+		}
+	}
+	for _, unwanted := range []string{`$restore(`, `$blk`, `switch ($s)`} {
+		if strings.Contains(async, unwanted) {
+			t.Errorf("async output contains %q:\n%s", unwanted, async)
+		}
+	}
+	if len(async) >= len(resumable) {
+		t.Errorf("async output is %d bytes, want less than %d bytes of the resumable one", len(async), len(resumable))
+	}
+}
+
+func TestCallGraph(t *testing.T) {
+	src := `
+		package main
+
+		type Shape interface { Area() float64 }
+
+		type Square struct { Side float64 }
+		func (s Square) Area() float64 { return s.Side * s.Side }
+
+		type Circle struct { Radius float64 }
+		func (c Circle) Area() float64 { return 3 * c.Radius * c.Radius }
+
+		func total(shapes []Shape, scale func(float64) float64) (sum float64) {
+			for _, s := range shapes {
+				sum += scale(s.Area())
+			}
+			return sum
+		}
+
+		func double(x float64) float64 { return 2 * x }
+
+		func main() {
+			println(total([]Shape{Square{1}, Circle{2}}, double))
+		}`
+
+	const resumableTotal = `{$blk: total`
+	setExperiment(t, &experiments.Env.CallGraph, false)
+	conservative := compileMain(t, src, nil, false)
+	if !strings.Contains(conservative, resumableTotal) {
+		t.Errorf("total is not resumable without the experiment:\n%s", conservative)
+	}
+	setExperiment(t, &experiments.Env.CallGraph, true)
+	resolved := compileMain(t, src, nil, false)
+	if strings.Contains(resolved, resumableTotal) {
+		t.Errorf("total is resumable with the experiment:\n%s", resolved)
+	}
+}
+
+func TestOptimize(t *testing.T) {
+	src := `
+		package main
+
+		import "github.com/gopherjs/gopherjs/compiler/geometry"
+
+		const debug = false
+
+		type counter struct{ n int }
+		func (c *counter) value() int { return c.n }
+
+		func square(x int) int { return x * x }
+
+		//go:noinline
+		func twice(x int) int { return x + x }
+
+		func main() {
+			c := &counter{n: 3}
+			if debug && c.value() > 0 {
+				println("debugging")
+			}
+			println(square(c.value()), geometry.Area(geometry.Rect{W: 2, H: 3}))
+			println(twice(c.value()))
+		}`
+	geometry := `
+		package geometry
+
+		type Rect struct{ W, H int }
+		func Area(r Rect) int { return r.W * r.H }`
+
+	auxFiles := []srctesting.Source{{Name: `geometry/geometry.go`, Contents: []byte(geometry)}}
+	calls := []string{`square(`, `.value()`, `.Area(`, `"debugging"`}
+	kept := []string{`twice(`}
+	plain := compileMain(t, src, auxFiles, false)
+	for _, call := range calls {
+		if !strings.Contains(plain, call) {
+			t.Errorf("%s is missing without optimizations:\n%s", call, plain)
+		}
+	}
+	optimized := compileMain(t, src, auxFiles, true)
+	mainFunc := optimized[strings.Index(optimized, "main = function"):]
+	for _, call := range calls {
+		if strings.Contains(mainFunc, call) {
+			t.Errorf("%s is not optimized away:\n%s", call, mainFunc)
+		}
+	}
+	for _, call := range kept {
+		if !strings.Contains(mainFunc, call) {
+			t.Errorf("%s is inlined, but must not be:\n%s", call, mainFunc)
+		}
+	}
+}
+
+func TestIsCallerFunc(t *testing.T) {
+	function := func(pkgPath, name string) *types.Func {
+		pkg := types.NewPackage(pkgPath, path.Base(pkgPath))
+		return types.NewFunc(token.NoPos, pkg, name, types.NewSignatureType(nil, nil, nil, nil, nil, false))
+	}
+	method := func(pkgPath, typeName, name string) *types.Func {
+		pkg := types.NewPackage(pkgPath, path.Base(pkgPath))
+		named := types.NewNamed(types.NewTypeName(token.NoPos, pkg, typeName, nil), types.NewStruct(nil, nil), nil)
+		recv := types.NewVar(token.NoPos, pkg, "", types.NewPointer(named))
+		return types.NewFunc(token.NoPos, pkg, name, types.NewSignatureType(recv, nil, nil, nil, nil, false))
+	}
+
+	tests := []struct {
+		fun  *types.Func
+		want bool
+	}{
+		{fun: function("runtime", "Caller"), want: true},
+		{fun: function("runtime", "Callers"), want: true},
+		{fun: function("runtime", "GC"), want: false},
+		{fun: function("log", "Printf"), want: true},
+		{fun: method("log", "Logger", "Output"), want: true},
+		{fun: method("log/slog", "Logger", "Info"), want: true},
+		{fun: method("testing", "common", "Helper"), want: true},
+		{fun: method("testing", "common", "Errorf"), want: true},
+		{fun: function("testing", "Short"), want: false},
+		{fun: function("fmt", "Println"), want: false},
+	}
+	for _, test := range tests {
+		if got := isCallerFunc(test.fun); got != test.want {
+			t.Errorf("isCallerFunc(%s) returned %t, want %t", test.fun.FullName(), got, test.want)
+		}
+	}
+}
+
+func TestEscapeAnalysis(t *testing.T) {
+	src := `
+		package main
+
+		type celsius float64
+		func (c *celsius) fahrenheit() float64 { return float64(*c)*9/5 + 32 }
+		func (c *celsius) warm(by float64) { *c += celsius(by) }
+
+		func main() {
+			for i := 0; i < 3; i++ {
+				t := celsius(i)
+				(&t).warm(10)
+				println((&t).fahrenheit())
+			}
+		}`
+
+	compile := func(optimize bool) string {
+		code := compileMain(t, src, nil, optimize)
+		return code[strings.Index(code, "main = function"):]
+	}
+
+	// Without optimizations, t gets a new binding in each iteration, along with
+	// a new pointer to it.
+	plain := compile(false)
+	if !strings.Contains(plain, "t = [t]") || !strings.Contains(plain, "t.$ptr") {
+		t.Errorf("t is not boxed in each iteration without optimizations:\n%s", plain)
+	}
+
+	// The pointers to t don't outlive the calls, so t doesn't need a new binding
+	// in each iteration, and the pointer allocated for it once is reused.
+	optimized := compile(true)
+	if strings.Contains(optimized, "t = [t]") {
+		t.Errorf("t is boxed in each iteration:\n%s", optimized)
+	}
+	if !strings.Contains(optimized, "(t$24ptr || (t$24ptr = new ptrType(") {
+		t.Errorf("the pointer to t is not cached:\n%s", optimized)
+	}
+
+	// Inlined fahrenheit only dereferences the pointer, which is replaced with t.
+	if strings.Contains(optimized, "fahrenheit(") {
+		t.Errorf("fahrenheit is not inlined:\n%s", optimized)
+	}
+	if !strings.Contains(optimized, "console.log((t) * 9 / 5 + 32)") {
+		t.Errorf("the pointer to t is not replaced with t:\n%s", optimized)
+	}
+}
+
+func TestMangleProps(t *testing.T) {
+	src := `
+		package main
+
+		import "github.com/gopherjs/gopherjs/compiler/inventory"
+
+		type stock struct{ Quantity int }
+
+		func main() {
+			s := stock{Quantity: 2}
+			item := inventory.NewItem(s.Quantity)
+			println(item.Total(), struct{ Quantity int }(s).Quantity)
+		}`
+	inventory := `
+		package inventory
+
+		type Item struct{ Quantity, UnitPrice int }
+
+		func NewItem(quantity int) *Item { return &Item{Quantity: quantity, UnitPrice: 3} }
+
+		func (i *Item) Total() int { return i.subtotal() }
+		func (i *Item) subtotal() int { return i.Quantity * i.UnitPrice }`
+
+	setExperiment(t, &experiments.Env.MangleProps, true)
+	st := declSelection(t,
+		[]srctesting.Source{{Name: `main.go`, Contents: []byte(src)}},
+		[]srctesting.Source{{Name: `inventory/inventory.go`, Contents: []byte(inventory)}})
+	render := func(archive *Archive) string {
+		buf := &bytes.Buffer{}
+		if err := WritePkgCode(archive, st.dceSelection, linkname.GoLinknameSet{}, false, &sourcemapx.Filter{Writer: buf}); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+	mainCode := render(st.mainPkg)
+	inventoryCode := render(st.archives[`github.com/gopherjs/gopherjs/compiler/inventory`])
+
+	// Quantity is the most used name, so it gets the shortest property in both
+	// packages, and reflection still sees the original name.
+	for _, code := range []string{mainCode, inventoryCode} {
+		if !strings.Contains(code, `{prop: "a", name: "Quantity"`) {
+			t.Errorf("Quantity is not mangled to a:\n%s", code)
+		}
+		if strings.Contains(code, `.Quantity`) || strings.Contains(code, `.UnitPrice`) {
+			t.Errorf("Fields are accessed by their Go names:\n%s", code)
+		}
+	}
+	if strings.Contains(inventoryCode, `.subtotal`) || !strings.Contains(inventoryCode, `name: "subtotal"`) {
+		t.Errorf("Unexported method is not mangled:\n%s", inventoryCode)
+	}
+	if !strings.Contains(mainCode, `.Total()`) {
+		t.Errorf("Exported method is mangled:\n%s", mainCode)
+	}
+}
+
+func TestExplainLiveness(t *testing.T) {
+	src := `
+		package main
+
+		type T struct{}
+
+		func (*T) method() int { return helper() }
+
+		func helper() int { return 42 }
+
+		func unused() int { return 0 }
+
+		func main() {
+			println((&T{}).method())
+		}`
+
+	srcFiles := []srctesting.Source{{Name: `main.go`, Contents: []byte(src)}}
+	root := srctesting.ParseSources(t, srcFiles, nil)
+	archives := compileProject(t, root, false)
+	pkgs := []*Archive{archives[root.PkgPath]}
+
+	steps, err := ExplainLiveness(pkgs, root.PkgPath, `helper`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, step := range steps {
+		got = append(got, step.Decl.FullName)
+	}
+	want := []string{
+		`func:command-line-arguments.main`,
+		`func:command-line-arguments.(*T).method`,
+		`func:command-line-arguments.helper`,
+	}
+	if len(got) < len(want) {
+		t.Fatalf(`liveness chain %v is shorter than expected`, got)
+	}
+	if diff := cmp.Diff(want, got[len(got)-len(want):]); diff != `` {
+		t.Errorf(`unexpected liveness chain (-want,+got):\n%s`, diff)
+	}
+	if steps[0].Root == `` {
+		t.Errorf(`the chain doesn't start with a root: %+v`, steps[0])
+	}
+
+	if _, err := ExplainLiveness(pkgs, root.PkgPath, `T.method`); err != nil {
+		t.Errorf(`method named without the pointer indicator not found: %v`, err)
+	}
+	if _, err := ExplainLiveness(pkgs, root.PkgPath, `unused`); err == nil || !strings.Contains(err.Error(), `dead code`) {
+		t.Errorf(`got error %v for a dead function, want a dead code error`, err)
+	}
+	if _, err := ExplainLiveness(pkgs, root.PkgPath, `missing`); err == nil || !strings.Contains(err.Error(), `no declaration`) {
+		t.Errorf(`got error %v for a missing symbol, want a missing declaration error`, err)
+	}
+	if _, err := ExplainLiveness(pkgs, `fmt`, `Sprintf`); err == nil {
+		t.Error(`got no error for a package not in the program`)
+	}
+}
+
 func compareOrder(t *testing.T, sourceFiles []srctesting.Source, minify bool) {
 	t.Helper()
 	outputNormal := compile(t, sourceFiles, minify)
@@ -1142,6 +1664,31 @@ func compile(t *testing.T, sourceFiles []srctesting.Source, minify bool) string
 // compileProject compiles the given root package and all packages imported by the root.
 // This returns the compiled archives of all packages keyed by their import path.
 func compileProject(t *testing.T, root *packages.Package, minify bool) map[string]*Archive {
+	t.Helper()
+	return compileProjectOptimized(t, root, minify, false)
+}
+
+// setExperiment sets the experiment flag to enabled until the test finishes.
+func setExperiment(t *testing.T, flag *bool, enabled bool) {
+	t.Helper()
+	prev := *flag
+	*flag = enabled
+	t.Cleanup(func() { *flag = prev })
+}
+
+// compileMain compiles the main package source, along with the auxiliary
+// packages, with the experiments currently set, and returns the code of the
+// main package.
+func compileMain(t *testing.T, src string, auxFiles []srctesting.Source, optimize bool) string {
+	t.Helper()
+	root := srctesting.ParseSources(t, []srctesting.Source{{Name: `main.go`, Contents: []byte(src)}}, auxFiles)
+	archives := compileProjectOptimized(t, root, false, optimize)
+	return renderPackage(t, archives[root.PkgPath], false)
+}
+
+// compileProjectOptimized is like compileProject, with the optimization passes
+// enabled if optimize is true.
+func compileProjectOptimized(t *testing.T, root *packages.Package, minify, optimize bool) map[string]*Archive {
 	t.Helper()
 	pkgMap := map[string]*packages.Package{}
 	packages.Visit([]*packages.Package{root}, nil, func(pkg *packages.Package) {
@@ -1178,7 +1725,7 @@ func compileProject(t *testing.T, root *packages.Package, minify bool) map[strin
 
 	archives := map[string]*Archive{}
 	for _, srcs := range allSrcs {
-		a, err := Compile(srcs, tContext, minify)
+		a, err := Compile(srcs, tContext, minify, optimize)
 		if err != nil {
 			t.Fatal(`failed to compile:`, err)
 		}
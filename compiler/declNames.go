@@ -64,9 +64,23 @@ func typeDeclFullName(inst typeparams.Instance) string {
 	return `type:` + inst.String()
 }
 
+// jsonConverterDeclFullName returns a unique name for the declaration of the
+// converter between instances of a struct type and plain JavaScript objects
+// used by the given js package function, e.g. `MarshalObject:pkg.T`.
+func jsonConverterDeclFullName(inst typeparams.Instance, funcName string) string {
+	return funcName + `:` + inst.String()
+}
+
 // anonTypeDeclFullName returns a unique name for a package-level type
 // declaration for an anonymous type. Names are only unique per package.
 // These names are generated for types that are not named in the source code.
 func anonTypeDeclFullName(o types.Object) string {
 	return `anonType:` + symbol.New(o).String()
 }
+
+// jsNamingDeclFullName returns the name for the declaration setting the
+// package's JavaScript field naming strategy. There should only be one decl
+// with this name per package.
+func jsNamingDeclFullName() string {
+	return `jsNaming`
+}
@@ -9,9 +9,11 @@ import (
 	"go/constant"
 	"go/token"
 	"go/types"
+	"reflect"
 	"sort"
 	"strings"
 
+	"github.com/gopherjs/gopherjs/compiler/astutil"
 	"github.com/gopherjs/gopherjs/compiler/internal/analysis"
 	"github.com/gopherjs/gopherjs/compiler/internal/dce"
 	"github.com/gopherjs/gopherjs/compiler/internal/symbol"
@@ -190,7 +192,7 @@ func (fc *funcContext) newImportDecl(importedPkg *types.Package) *Decl {
 		ImportCode: []byte(fmt.Sprintf("\t%s = $packages[\"%s\"];\n", pkgVar, importedPkg.Path())),
 		InitCode:   fc.CatchOutput(1, func() { fc.translateStmt(fc.importInitializer(importedPkg.Path()), nil) }),
 	}
-	d.Dce().SetAsAlive()
+	d.Dce().SetAsImport(importedPkg.Path())
 	return d
 }
 
@@ -201,11 +203,49 @@ func (fc *funcContext) importInitializer(impPath string) ast.Stmt {
 	id := fc.newIdent(fmt.Sprintf(`%s.$init`, pkgVar), types.NewSignatureType(nil, nil, nil, nil, nil, false))
 	call := &ast.CallExpr{Fun: id}
 	fc.Blocking[call] = true
-	fc.Flattened[call] = true
+	fc.flattenBlocking(call)
 
 	return &ast.ExprStmt{X: call}
 }
 
+// jsNamingStrategies lists the supported arguments of the gopherjs:js-naming
+// directive, see $jsFieldNaming in the prelude.
+var jsNamingStrategies = map[string]bool{"camelCase": true, "snake_case": true}
+
+// jsNamingDecl returns a Decl that sets the package's JavaScript field naming
+// strategy declared by the gopherjs:js-naming directive, or nil if the package
+// doesn't declare one.
+func (fc *funcContext) jsNamingDecl(files []*ast.File) *Decl {
+	if !fc.isRoot() {
+		panic(bailout(fmt.Errorf("functionContext.jsNamingDecl() must be only called on the package-level context")))
+	}
+
+	strategy := ""
+	for _, file := range files {
+		s, pos := astutil.JSNaming(file)
+		switch {
+		case s == "":
+			continue
+		case !jsNamingStrategies[s]:
+			fc.pkgCtx.errList = append(fc.pkgCtx.errList, types.Error{Fset: fc.pkgCtx.fileSet, Pos: pos, Msg: fmt.Sprintf("unknown js-naming strategy %q, must be camelCase or snake_case", s)})
+		case strategy != "" && s != strategy:
+			fc.pkgCtx.errList = append(fc.pkgCtx.errList, types.Error{Fset: fc.pkgCtx.fileSet, Pos: pos, Msg: fmt.Sprintf("js-naming strategy %q conflicts with %q declared in another file", s, strategy)})
+		default:
+			strategy = s
+		}
+	}
+	if strategy == "" {
+		return nil
+	}
+
+	d := &Decl{
+		FullName:     jsNamingDeclFullName(),
+		TypeDeclCode: []byte(fmt.Sprintf("\t$pkg.$jsNaming = %q;\n", strategy)),
+	}
+	d.Dce().SetAsPackageInit()
+	return d
+}
+
 // varDecls translates all package-level variables.
 //
 // `vars` argument must contain all package-level variables found in the package.
@@ -377,7 +417,12 @@ func (fc *funcContext) newFuncDecl(fun *ast.FuncDecl, inst typeparams.Instance)
 			}
 		case "init":
 			d.InitCode = fc.CatchOutput(1, func() { fc.translateStmt(fc.callInitFunc(o), nil) })
-			d.Dce().SetAsAlive() // init() function is always reachable.
+			if analysis.HasExternalEffect(fun.Body, fc.pkgCtx.Pkg, fc.pkgCtx.Info.Info) {
+				d.Dce().SetAsAlive() // init() function is always reachable.
+			} else {
+				// Only needed if anything else in the package is used.
+				d.Dce().SetAsPackageInit()
+			}
 		}
 	}
 
@@ -418,7 +463,7 @@ func (fc *funcContext) callMainFunc(main *types.Func) ast.Stmt {
 	}
 	if fc.pkgCtx.IsBlocking(typeparams.Instance{Object: main}) {
 		fc.Blocking[call] = true
-		fc.Flattened[ifStmt] = true
+		fc.flattenBlocking(ifStmt)
 	}
 
 	return ifStmt
@@ -442,11 +487,11 @@ func (fc *funcContext) namedTypeDecls(typeNames typesutil.TypeNames) ([]*Decl, e
 		typeDecls = append(typeDecls, fc.newNamedTypeVarDecl(o))
 
 		for _, inst := range fc.knownInstances(o) {
-			d, err := fc.newNamedTypeInstDecl(inst)
+			decls, err := fc.newNamedTypeInstDecl(inst)
 			if err != nil {
 				return nil, err
 			}
-			typeDecls = append(typeDecls, d)
+			typeDecls = append(typeDecls, decls...)
 		}
 	}
 
@@ -480,8 +525,9 @@ func (fc *funcContext) newNamedTypeVarDecl(obj *types.TypeName) *Decl {
 }
 
 // newNamedTypeInstDecl returns a Decl that represents an instantiation of a
-// named Go type.
-func (fc *funcContext) newNamedTypeInstDecl(inst typeparams.Instance) (*Decl, error) {
+// named Go type, followed by the Decls of its converters to plain JavaScript
+// objects if the type is a struct, see jsonConverterDecls.
+func (fc *funcContext) newNamedTypeInstDecl(inst typeparams.Instance) ([]*Decl, error) {
 	originType := inst.Object.Type().(*types.Named)
 
 	fc.typeResolver = typeparams.NewResolver(fc.pkgCtx.typesCtx, inst)
@@ -559,7 +605,10 @@ func (fc *funcContext) newNamedTypeInstDecl(inst typeparams.Instance) (*Decl, er
 			})
 		}
 	})
-	return d, nil
+	if st, ok := underlying.(*types.Struct); ok {
+		return append([]*Decl{d}, fc.jsonConverterDecls(inst, st)...), nil
+	}
+	return []*Decl{d}, nil
 }
 
 // structConstructor returns JS constructor function for a struct type.
@@ -572,7 +621,7 @@ func (fc *funcContext) structConstructor(t *types.Struct) string {
 
 	ctrArgs := make([]string, t.NumFields())
 	for i := 0; i < t.NumFields(); i++ {
-		ctrArgs[i] = fieldName(t, i) + "_"
+		ctrArgs[i] = fc.fieldName(t, i) + "_"
 	}
 
 	fmt.Fprintf(constructor, "function(%s) {\n", strings.Join(ctrArgs, ", "))
@@ -582,24 +631,146 @@ func (fc *funcContext) structConstructor(t *types.Struct) string {
 	fmt.Fprintf(constructor, "\t\tif (arguments.length === 0) {\n")
 	for i := 0; i < t.NumFields(); i++ {
 		zeroValue := fc.zeroValue(fc.fieldType(t, i))
-		fmt.Fprintf(constructor, "\t\t\tthis.%s = %s;\n", fieldName(t, i), fc.translateExpr(zeroValue).String())
+		fmt.Fprintf(constructor, "\t\t\tthis.%s = %s;\n", fc.fieldName(t, i), fc.translateExpr(zeroValue).String())
 	}
 	fmt.Fprintf(constructor, "\t\t\treturn;\n")
 	fmt.Fprintf(constructor, "\t\t}\n")
 
 	// Otherwise initialize fields with the provided values.
 	for i := 0; i < t.NumFields(); i++ {
-		fmt.Fprintf(constructor, "\t\tthis.%[1]s = %[1]s_;\n", fieldName(t, i))
+		fmt.Fprintf(constructor, "\t\tthis.%[1]s = %[1]s_;\n", fc.fieldName(t, i))
 	}
 	fmt.Fprintf(constructor, "\t}")
 	return constructor.String()
 }
 
+// jsonConverterDecls returns the Decls of the type-specialized converters
+// between values of the struct type t, the underlying type of inst, and plain
+// JavaScript objects, used by js.MarshalObject and js.UnmarshalObject. Each of
+// them is only kept if both the type and the js function using it are alive.
+// The prelude falls back to walking the reflection metadata for struct types
+// without converters, and both must agree on how fields are named and inlined
+// (see $jsonFields). Inlined structs are converted first, so that the fields
+// of t take precedence over the promoted ones.
+func (fc *funcContext) jsonConverterDecls(inst typeparams.Instance, t *types.Struct) []*Decl {
+	typeName := fc.instName(inst)
+	var inlineEncode, inlineDecode, encode, decode []string
+	for i := 0; i < t.NumFields(); i++ {
+		field := t.Field(i)
+		tag := reflect.StructTag(t.Tag(i)).Get("json")
+		if tag == "-" || field.Embedded() && typesutil.IsJsObject(field.Type()) {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		prop := fc.fieldName(t, i)
+		ft := fc.fieldType(t, i)
+
+		if field.Embedded() && name == "" {
+			if ptr, ok := ft.Underlying().(*types.Pointer); ok {
+				if _, ok := ptr.Elem().Underlying().(*types.Struct); ok {
+					elem := fc.typeName(ptr.Elem())
+					inlineEncode = append(inlineEncode, fmt.Sprintf("if (v.%s !== %s.nil) { $jsonEncodeStruct(v.%[1]s, %s, o); }", prop, fc.typeName(ft), elem))
+					inlineDecode = append(inlineDecode, fmt.Sprintf("if (v.%s === %s.nil) { v.%[1]s = new %s.ptr(); } $jsonDecodeStruct(o, %[3]s, v.%[1]s);", prop, fc.typeName(ft), elem))
+					continue
+				}
+			}
+			if _, ok := ft.Underlying().(*types.Struct); ok {
+				inlineEncode = append(inlineEncode, fmt.Sprintf("$jsonEncodeStruct(v.%s, %s, o);", prop, fc.typeName(ft)))
+				inlineDecode = append(inlineDecode, fmt.Sprintf("$jsonDecodeStruct(o, %s, v.%s);", fc.typeName(ft), prop))
+				continue
+			}
+		}
+		if !field.Exported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name()
+		}
+		key := encodeString(name)
+
+		value := fmt.Sprintf("$jsonEncode(v.%s, %s)", prop, fc.typeName(ft))
+		empty := fmt.Sprintf("$isEmptyValue(v.%s, %s)", prop, fc.typeName(ft))
+		if basic, ok := ft.Underlying().(*types.Basic); ok {
+			switch {
+			case isBoolean(basic):
+				value = "v." + prop
+				empty = "!v." + prop
+			case is64Bit(basic):
+				value = fmt.Sprintf("$flatten64(v.%s)", prop)
+			case isNumeric(basic) && !isComplex(basic):
+				value = "v." + prop
+				empty = fmt.Sprintf("v.%s === 0", prop)
+			case isString(basic):
+				value = fmt.Sprintf("$externalize(v.%s, $String)", prop)
+				empty = fmt.Sprintf("v.%s === \"\"", prop)
+			}
+		}
+		assign := fmt.Sprintf("o[%s] = %s;", key, value)
+		if hasOption(opts, "omitempty") {
+			assign = fmt.Sprintf("if (!(%s)) { %s }", empty, assign)
+		}
+		encode = append(encode, assign)
+		decode = append(decode, fmt.Sprintf("x = o[%s]; if (x !== undefined) { v.%s = $jsonDecode(x, %s, v.%[2]s); }", key, prop, fc.typeName(ft)))
+	}
+	encode = append(inlineEncode, encode...)
+	decode = append(inlineDecode, decode...)
+	if len(encode) == 0 {
+		return nil
+	}
+
+	converterDecl := func(funcName string, code func()) *Decl {
+		d := &Decl{
+			FullName: jsonConverterDeclFullName(inst, funcName),
+		}
+		d.Dce().SetName(inst.Object, inst.TNest, inst.TArgs)
+		d.Dce().SetCondition("github.com/gopherjs/gopherjs/js", funcName)
+		fc.pkgCtx.CollectDCEDeps(d, func() {
+			d.TypeInitCode = fc.CatchOutput(1, code)
+		})
+		return d
+	}
+	encodeDecl := converterDecl("MarshalObject", func() {
+		fc.Printf("%s.jsonEncode = function(v, o) {", typeName)
+		fc.Indented(func() {
+			for _, line := range encode {
+				fc.Printf("%s", line)
+			}
+			fc.Printf("return o;")
+		})
+		fc.Printf("};")
+	})
+	decodeDecl := converterDecl("UnmarshalObject", func() {
+		fc.Printf("%s.jsonDecode = function(o, v) {", typeName)
+		fc.Indented(func() {
+			fc.Printf("var x;")
+			for _, line := range decode {
+				fc.Printf("%s", line)
+			}
+			fc.Printf("return v;")
+		})
+		fc.Printf("};")
+	})
+	return []*Decl{encodeDecl, decodeDecl}
+}
+
+// hasOption reports whether the comma-separated struct tag options contain
+// the given option.
+func hasOption(opts, option string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
 // methodListEntry returns a JS code fragment that describes the given method
 // function for runtime reflection. It returns isPtr=true if the method belongs
 // to the pointer-receiver method list.
 func (fc *funcContext) methodListEntry(method *types.Func) (entry string, isPtr bool) {
-	name := sanitizeName(method.Name())
+	name := fc.methodName(method)
 	pkgPath := ""
 	if !method.Exported() {
 		pkgPath = method.Pkg().Path()
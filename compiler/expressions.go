@@ -15,6 +15,7 @@ import (
 	"github.com/gopherjs/gopherjs/compiler/internal/analysis"
 	"github.com/gopherjs/gopherjs/compiler/internal/typeparams"
 	"github.com/gopherjs/gopherjs/compiler/typesutil"
+	"github.com/gopherjs/gopherjs/internal/experiments"
 )
 
 type expression struct {
@@ -41,6 +42,23 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 		case isBoolean(basic):
 			return fc.formatExpr("%s", strconv.FormatBool(constant.BoolVal(value)))
 		case isInteger(basic):
+			if isBigInt(basic) {
+				if basic.Kind() == types.Int64 {
+					d, ok := constant.Int64Val(constant.ToInt(value))
+					if !ok {
+						panic("could not get exact int")
+					}
+					if d < 0 {
+						return fc.formatParenExpr("%sn", strconv.FormatInt(d, 10))
+					}
+					return fc.formatExpr("%sn", strconv.FormatInt(d, 10))
+				}
+				d, ok := constant.Uint64Val(constant.ToInt(value))
+				if !ok {
+					panic("could not get exact uint")
+				}
+				return fc.formatExpr("%sn", strconv.FormatUint(d, 10))
+			}
 			if is64Bit(basic) {
 				if basic.Kind() == types.Int64 {
 					d, ok := constant.Int64Val(constant.ToInt(value))
@@ -77,6 +95,13 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 		}
 	}
 
+	if fc.pkgCtx.optimize {
+		// Fold logical expressions short-circuited by a constant operand.
+		if b, ok := analysis.BoolValue(expr, fc.pkgCtx.Info.Info); ok {
+			return fc.formatExpr("%s", strconv.FormatBool(b))
+		}
+	}
+
 	var inst typeparams.Instance
 	switch e := expr.(type) {
 	case *ast.SelectorExpr:
@@ -291,6 +316,8 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 			return fc.translateExpr(e.X)
 		case token.SUB:
 			switch {
+			case isBigInt(basic):
+				return fc.fixBigInt(fc.formatExpr("-%e", e.X), basic)
 			case is64Bit(basic):
 				return fc.formatExpr("new %1s(-%2h, -%2l)", fc.typeName(t), e.X)
 			case isComplex(basic):
@@ -301,6 +328,9 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 				return fc.formatExpr("-%e", e.X)
 			}
 		case token.XOR:
+			if isBigInt(basic) {
+				return fc.fixBigInt(fc.formatExpr("~%e", e.X), basic)
+			}
 			if is64Bit(basic) {
 				return fc.formatExpr("new %1s(~%2h, ~%2l >>> 0)", fc.typeName(t), e.X)
 			}
@@ -328,6 +358,30 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 		}
 
 		if basic, isBasic := t.Underlying().(*types.Basic); isBasic && isNumeric(basic) {
+			if isBigInt(basic) {
+				switch e.Op {
+				case token.ADD, token.SUB, token.MUL:
+					return fc.fixBigInt(fc.formatExpr("%e %t %e", e.X, e.Op, e.Y), basic)
+				case token.QUO:
+					return fc.fixBigInt(fc.formatExpr("$divBigInt(%e, %e)", e.X, e.Y), basic)
+				case token.REM:
+					return fc.formatExpr("$remBigInt(%e, %e)", e.X, e.Y)
+				case token.SHL:
+					return fc.fixBigInt(fc.formatExpr("%e << %s", e.X, fc.bigIntShiftCount(e.Y)), basic)
+				case token.SHR:
+					return fc.formatParenExpr("%e >> %s", e.X, fc.bigIntShiftCount(e.Y))
+				case token.EQL:
+					return fc.formatParenExpr("%e === %e", e.X, e.Y)
+				case token.LSS, token.LEQ, token.GTR, token.GEQ:
+					return fc.formatExpr("%e %t %e", e.X, e.Op, e.Y)
+				case token.AND, token.OR, token.XOR:
+					return fc.formatParenExpr("%e %t %e", e.X, e.Op, e.Y)
+				case token.AND_NOT:
+					return fc.formatParenExpr("%e & ~%e", e.X, e.Y)
+				default:
+					panic(e.Op)
+				}
+			}
 			if is64Bit(basic) {
 				switch e.Op {
 				case token.MUL:
@@ -441,6 +495,14 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 		case token.ADD, token.LSS, token.LEQ, token.GTR, token.GEQ:
 			return fc.formatExpr("%e %t %e", e.X, e.Op, e.Y)
 		case token.LAND:
+			if fc.Blocking[e.Y] && experiments.Env.AsyncAwait {
+				resultVar := fc.newLocalVariable("_v")
+				fc.Printf("%s = %s;", resultVar, fc.translateExpr(e.X))
+				fc.Printf("if (%s) {", resultVar)
+				fc.Indented(func() { fc.Printf("%s = %s;", resultVar, fc.translateExpr(e.Y)) })
+				fc.Printf("}")
+				return fc.formatExpr("%s", resultVar)
+			}
 			if fc.Blocking[e.Y] {
 				skipCase := fc.caseCounter
 				fc.caseCounter++
@@ -451,6 +513,14 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 			}
 			return fc.formatExpr("%e && %e", e.X, e.Y)
 		case token.LOR:
+			if fc.Blocking[e.Y] && experiments.Env.AsyncAwait {
+				resultVar := fc.newLocalVariable("_v")
+				fc.Printf("%s = %s;", resultVar, fc.translateExpr(e.X))
+				fc.Printf("if (!%s) {", resultVar)
+				fc.Indented(func() { fc.Printf("%s = %s;", resultVar, fc.translateExpr(e.Y)) })
+				fc.Printf("}")
+				return fc.formatExpr("%s", resultVar)
+			}
 			if fc.Blocking[e.Y] {
 				skipCase := fc.caseCounter
 				fc.caseCounter++
@@ -603,13 +673,13 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 			}
 			return fc.formatExpr("%e.%s", e.X, strings.Join(fields, "."))
 		case types.MethodVal:
-			return fc.formatExpr(`$methodVal(%s, "%s")`, fc.makeReceiver(e), sel.Obj().(*types.Func).Name())
+			return fc.formatExpr(`$methodVal(%s, "%s")`, fc.makeReceiver(e), fc.methodName(sel.Obj().(*types.Func)))
 		case types.MethodExpr:
 			fc.pkgCtx.DeclareDCEDep(sel.Obj(), inst.TNest, inst.TArgs)
 			if _, ok := sel.Recv().Underlying().(*types.Interface); ok {
-				return fc.formatExpr(`$ifaceMethodExpr("%s")`, sel.Obj().(*types.Func).Name())
+				return fc.formatExpr(`$ifaceMethodExpr("%s")`, fc.methodName(sel.Obj().(*types.Func)))
 			}
-			return fc.formatExpr(`$methodExpr(%s, "%s")`, fc.typeName(sel.Recv()), sel.Obj().(*types.Func).Name())
+			return fc.formatExpr(`$methodExpr(%s, "%s")`, fc.typeName(sel.Recv()), fc.methodName(sel.Obj().(*types.Func)))
 		default:
 			panic(fmt.Sprintf("unexpected sel.Kind(): %T", sel.Kind()))
 		}
@@ -632,7 +702,12 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 			if typesutil.IsJsPackage(obj.Pkg()) && obj.Name() == "InternalObject" {
 				return fc.translateExpr(e.Args[0])
 			}
-			return fc.translateCall(e, sig, fc.translateExpr(f))
+			if o, ok := obj.(*types.Func); ok {
+				if inlined := fc.inlineCall(e, o, nil); inlined != nil {
+					return inlined
+				}
+			}
+			return fc.translateCall(e, sig, fc.translateExpr(f), nil)
 
 		case *ast.SelectorExpr:
 			sel, ok := fc.selectionOf(f)
@@ -650,7 +725,12 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 						return fc.translateExpr(e.Args[0])
 					}
 				}
-				return fc.translateCall(e, sig, fc.translateExpr(f))
+				if o, ok := obj.(*types.Func); ok {
+					if inlined := fc.inlineCall(e, o, nil); inlined != nil {
+						return inlined
+					}
+				}
+				return fc.translateCall(e, sig, fc.translateExpr(f), nil)
 			}
 
 			externalizeExpr := func(e ast.Expr) string {
@@ -670,6 +750,9 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 
 			switch sel.Kind() {
 			case types.MethodVal:
+				if inlined := fc.inlineCall(e, sel.Obj().(*types.Func), f); inlined != nil {
+					return inlined
+				}
 				recv := fc.makeReceiver(f)
 				declaredFuncRecv := sel.Obj().(*types.Func).Type().(*types.Signature).Recv().Type()
 				if typesutil.IsJsObject(declaredFuncRecv) {
@@ -743,7 +826,13 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 				}
 
 				methodName := fc.methodName(sel.Obj().(*types.Func))
-				return fc.translateCall(e, sig, fc.formatExpr("%s.%s", recv, methodName))
+				if fc.Blocking[e] && experiments.Env.AsyncAwait && !fc.callsAsyncFunc(e) {
+					// The method is looked up again to find out whether to await
+					// its result, so the receiver must only be evaluated once.
+					recvVar := fc.newLocalVariable("_recv")
+					return fc.translateCall(e, sig, fc.formatExpr("(%s = %s).%s", recvVar, recv, methodName), fc.formatExpr("%s.%s", recvVar, methodName))
+				}
+				return fc.translateCall(e, sig, fc.formatExpr("%s.%s", recv, methodName), nil)
 
 			case types.FieldVal:
 				fields, jsTag := fc.translateSelection(sel, f.Pos())
@@ -758,19 +847,24 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 						fc.pkgCtx.errList = append(fc.pkgCtx.errList, types.Error{Fset: fc.pkgCtx.fileSet, Pos: f.Pos(), Msg: "field with js tag can not have func type with multiple results"})
 					}
 				}
-				return fc.translateCall(e, sig, fc.formatExpr("%e.%s", f.X, strings.Join(fields, ".")))
+				return fc.translateCall(e, sig, fc.formatExpr("%e.%s", f.X, strings.Join(fields, ".")), nil)
 
 			case types.MethodExpr:
-				return fc.translateCall(e, sig, fc.translateExpr(f))
+				return fc.translateCall(e, sig, fc.translateExpr(f), nil)
 
 			default:
 				panic(fmt.Sprintf("unexpected sel.Kind(): %T", sel.Kind()))
 			}
 		default:
-			return fc.translateCall(e, sig, fc.translateExpr(plainFun))
+			return fc.translateCall(e, sig, fc.translateExpr(plainFun), nil)
 		}
 
 	case *ast.StarExpr:
+		if id, ok := astutil.RemoveParens(e.X).(*ast.Ident); ok {
+			if alias, ok := fc.pkgCtx.derefAliases[fc.pkgCtx.Uses[id]]; ok {
+				return fc.translateExpr(alias)
+			}
+		}
 		if typesutil.IsJsObject(fc.typeOf(e.X)) {
 			return fc.formatExpr("new $jsObjectPtr(%e)", e.X)
 		}
@@ -844,8 +938,41 @@ func (fc *funcContext) translateExpr(expr ast.Expr) *expression {
 	}
 }
 
-func (fc *funcContext) translateCall(e *ast.CallExpr, sig *types.Signature, fun *expression) *expression {
+// translateCall translates the call e of the function fun.
+//
+// With the asyncawait experiment, blocking calls of functions only known at run
+// time are awaited if the callee turns out to be async. To find out, callee is
+// evaluated after the call, so it must not have side effects. If it's nil, fun
+// is stored in a temporary variable instead.
+func (fc *funcContext) translateCall(e *ast.CallExpr, sig *types.Signature, fun, callee *expression) *expression {
 	args := fc.translateArgs(sig, e.Args, e.Ellipsis.IsValid())
+	if fc.Blocking[e] && experiments.Env.AsyncAwait {
+		if fc.callsAsyncFunc(e) {
+			if sig.Results().Len() == 0 {
+				fc.Printf("await %s(%s);", fun, strings.Join(args, ", "))
+				return fc.formatExpr("")
+			}
+			returnVar := fc.newLocalVariable("_r")
+			fc.Printf("%s = await %s(%s);", returnVar, fun, strings.Join(args, ", "))
+			return fc.formatExpr("%s", returnVar)
+		}
+		// The callee may or may not be an async function. Functions that don't
+		// block may return a JavaScript promise too, so whether to await the
+		// result is decided from the callee, see $isAsyncCall.
+		if _, ok := astutil.RemoveParens(e.Fun).(*ast.Ident); ok && callee == nil {
+			callee = fun
+		}
+		if callee == nil {
+			callee = fc.formatExpr("%s", fc.newLocalVariable("_f"))
+			fun = fc.formatExpr("(%s = %s)", callee, fun)
+		}
+		returnVar := fc.newLocalVariable("_r")
+		fc.Printf("%[1]s = %[2]s(%[3]s); if ($isAsyncCall(%[4]s, %[1]s)) { %[1]s = await %[1]s; }", returnVar, fun, strings.Join(args, ", "), callee)
+		if sig.Results().Len() != 0 {
+			return fc.formatExpr("%s", returnVar)
+		}
+		return fc.formatExpr("")
+	}
 	if fc.Blocking[e] {
 		resumeCase := fc.caseCounter
 		fc.caseCounter++
@@ -862,6 +989,38 @@ func (fc *funcContext) translateCall(e *ast.CallExpr, sig *types.Signature, fun
 	return fc.formatExpr("%s(%s)", fun, strings.Join(args, ", "))
 }
 
+// callsAsyncFunc reports whether the blocking call e statically refers to a Go
+// function or concrete method, which is compiled into an async function and
+// always returns a promise with the asyncawait experiment. Calls of interface
+// methods, function values and prelude primitives such as $recv may return
+// their result directly.
+func (fc *funcContext) callsAsyncFunc(e *ast.CallExpr) bool {
+	f := astutil.RemoveParens(e.Fun)
+	switch g := f.(type) {
+	case *ast.IndexExpr:
+		f = g.X // Generic function instantiation.
+	case *ast.IndexListExpr:
+		f = g.X
+	}
+	switch f := f.(type) {
+	case *ast.Ident:
+		_, ok := fc.pkgCtx.Uses[f].(*types.Func)
+		return ok
+	case *ast.SelectorExpr:
+		if sel, ok := fc.selectionOf(f); ok {
+			if sel.Kind() != types.MethodVal {
+				return false
+			}
+			// The method may be promoted from an embedded interface.
+			recv := sel.Obj().Type().(*types.Signature).Recv()
+			return recv != nil && !types.IsInterface(recv.Type())
+		}
+		_, ok := fc.pkgCtx.Uses[f.Sel].(*types.Func)
+		return ok
+	}
+	return false
+}
+
 // delegatedCall returns a pair of JS expressions representing a callable function
 // and its arguments to be invoked elsewhere.
 //
@@ -921,8 +1080,23 @@ func (fc *funcContext) makeReceiver(e *ast.SelectorExpr) *expression {
 	sel, _ := fc.selectionOf(e)
 	if !sel.Obj().Exported() {
 		fc.pkgCtx.DeclareDCEDep(sel.Obj(), nil, nil)
+	} else {
+		fc.pkgCtx.DeclareDCEMethodUse(sel.Obj().(*types.Func))
 	}
 
+	recv, recvType := fc.translateReceiver(e)
+	if isWrapped(recvType) {
+		// Wrap JS-native value to have access to the Go type's methods.
+		recv = fc.formatExpr("new %s(%s)", fc.typeName(sel.Obj().Type().(*types.Signature).Recv().Type()), recv)
+	}
+	return recv
+}
+
+// translateReceiver returns the receiver value the method selected by e is
+// called with, along with its type. Unlike makeReceiver, JS-native values are
+// not wrapped, which is the form the method body refers to its receiver in.
+func (fc *funcContext) translateReceiver(e *ast.SelectorExpr) (*expression, types.Type) {
+	sel, _ := fc.selectionOf(e)
 	x := e.X
 	recvType := sel.Recv()
 	if len(sel.Index()) > 1 {
@@ -950,12 +1124,7 @@ func (fc *funcContext) makeReceiver(e *ast.SelectorExpr) *expression {
 		x = fc.setType(x, methodsRecvType)
 	}
 
-	recv := fc.translateImplicitConversionWithCloning(x, methodsRecvType)
-	if isWrapped(recvType) {
-		// Wrap JS-native value to have access to the Go type's methods.
-		recv = fc.formatExpr("new %s(%s)", fc.typeName(methodsRecvType), recv)
-	}
-	return recv
+	return fc.translateImplicitConversionWithCloning(x, methodsRecvType), recvType
 }
 
 func (fc *funcContext) translateBuiltin(name string, sig *types.Signature, args []ast.Expr, ellipsis bool) *expression {
@@ -1131,6 +1300,24 @@ func (fc *funcContext) translateConversion(expr ast.Expr, desiredType types.Type
 		case isInteger(t):
 			basicExprType := exprType.Underlying().(*types.Basic)
 			switch {
+			case isBigInt(t):
+				switch {
+				case isBigInt(basicExprType):
+					if isUnsigned(t) == isUnsigned(basicExprType) {
+						return fc.translateExpr(expr)
+					}
+					return fc.fixBigInt(fc.translateExpr(expr), t)
+				case isFloat(basicExprType):
+					return fc.fixBigInt(fc.formatExpr("$bigIntFromFloat(%e)", expr), t)
+				case basicExprType.Kind() == types.Uintptr: // this might be an Object returned from reflect.Value.Pointer()
+					return fc.formatExpr("BigInt(%1e.constructor === Number ? %1e : 1)", expr)
+				case isUnsigned(t) && !isUnsigned(basicExprType):
+					return fc.fixBigInt(fc.formatExpr("BigInt(%e)", expr), t)
+				default:
+					return fc.formatExpr("BigInt(%e)", expr)
+				}
+			case isBigInt(basicExprType):
+				return fc.fixNumber(fc.formatExpr("Number(BigInt.asIntN(32, %e))", expr), t)
 			case is64Bit(t):
 				if !is64Bit(basicExprType) {
 					if basicExprType.Kind() == types.Uintptr { // this might be an Object returned from reflect.Value.Pointer()
@@ -1160,7 +1347,9 @@ func (fc *funcContext) translateConversion(expr ast.Expr, desiredType types.Type
 			value := fc.translateExpr(expr)
 			switch et := exprType.Underlying().(type) {
 			case *types.Basic:
-				if is64Bit(et) {
+				if isBigInt(et) {
+					value = fc.formatExpr("Number(%s)", value)
+				} else if is64Bit(et) {
 					value = fc.formatExpr("%s.$low", value)
 				}
 				if isNumeric(et) {
@@ -1331,13 +1520,13 @@ func (fc *funcContext) loadStruct(array, target string, s *types.Struct) string
 	var collectFields func(s *types.Struct, path string)
 	collectFields = func(s *types.Struct, path string) {
 		for i := 0; i < s.NumFields(); i++ {
-			fieldName := path + "." + fieldName(s, i)
+			fieldPath := path + "." + fc.fieldName(s, i)
 			fieldType := fc.fieldType(s, i)
 			if fs, isStruct := fieldType.Underlying().(*types.Struct); isStruct {
-				collectFields(fs, fieldName)
+				collectFields(fs, fieldPath)
 				continue
 			}
-			fields = append(fields, types.NewVar(0, nil, fieldName, fieldType))
+			fields = append(fields, types.NewVar(0, nil, fieldPath, fieldType))
 		}
 	}
 	collectFields(s, target)
@@ -1346,6 +1535,14 @@ func (fc *funcContext) loadStruct(array, target string, s *types.Struct) string
 		switch t := field.Type().Underlying().(type) {
 		case *types.Basic:
 			if isNumeric(t) {
+				if isBigInt(t) {
+					getter := "getBigInt64"
+					if isUnsigned(t) {
+						getter = "getBigUint64"
+					}
+					code += fmt.Sprintf(", %s = %s.%s(%d, true)", field.Name(), view, getter, offsets[i])
+					break
+				}
 				if is64Bit(t) {
 					code += fmt.Sprintf(", %s = new %s(%s.getUint32(%d, true), %s.getUint32(%d, true))", field.Name(), fc.typeName(field.Type()), view, offsets[i]+4, view, offsets[i])
 					break
@@ -1383,6 +1580,29 @@ func (fc *funcContext) fixNumber(value *expression, basic *types.Basic) *express
 	}
 }
 
+// fixBigInt wraps the BigInt value around to the range of the 64-bit integer
+// type basic, like fixNumber does for the other integer types.
+func (fc *funcContext) fixBigInt(value *expression, basic *types.Basic) *expression {
+	if isUnsigned(basic) {
+		return fc.formatExpr("BigInt.asUintN(64, %s)", value)
+	}
+	return fc.formatExpr("BigInt.asIntN(64, %s)", value)
+}
+
+// bigIntShiftCount returns the BigInt shift count for shifting a BigInt by the
+// integer y. Counts of 64 and more have the same effect in Go, so they are
+// capped to avoid creating huge intermediate values.
+func (fc *funcContext) bigIntShiftCount(y ast.Expr) string {
+	if v := fc.pkgCtx.Types[y].Value; v != nil {
+		i, _ := constant.Uint64Val(constant.ToInt(v))
+		if i > 64 {
+			i = 64
+		}
+		return strconv.FormatUint(i, 10) + "n"
+	}
+	return fc.formatExpr("BigInt($min(%f, 64))", y).String()
+}
+
 func (fc *funcContext) internalize(s *expression, t types.Type) *expression {
 	if typesutil.IsJsObject(t) {
 		return s
@@ -1496,6 +1716,12 @@ func (fc *funcContext) formatExprInternal(format string, a []any, parens bool) *
 				out.WriteString(strconv.FormatInt(d, 10))
 				return
 			}
+			if isBigInt(fc.typeOf(e).Underlying().(*types.Basic)) {
+				out.WriteString("Number(")
+				writeExpr("")
+				out.WriteString(")")
+				return
+			}
 			if is64Bit(fc.typeOf(e).Underlying().(*types.Basic)) {
 				out.WriteString("$flatten64(")
 				writeExpr("")
@@ -16,6 +16,7 @@ import (
 	"github.com/gopherjs/gopherjs/compiler/internal/analysis"
 	"github.com/gopherjs/gopherjs/compiler/internal/typeparams"
 	"github.com/gopherjs/gopherjs/compiler/typesutil"
+	"github.com/gopherjs/gopherjs/internal/experiments"
 	"github.com/gopherjs/gopherjs/internal/sourcemapx"
 )
 
@@ -175,7 +176,13 @@ func (fc *funcContext) translateMethod(fun *ast.FuncDecl) []byte {
 	// and define a proxy function on the other, which converts the receiver type
 	// and forwards the call to the primary implementation.
 	proxyFunction := func(lvalue, receiver string) []byte {
-		fun := fmt.Sprintf("function(...$args) { return %s.%s(...$args); }", receiver, funName)
+		keyword := "function"
+		if fc.IsBlocking() && experiments.Env.AsyncAwait {
+			// Callers find out whether to await the result from the callee,
+			// see $isAsyncCall.
+			keyword = "async function"
+		}
+		fun := fmt.Sprintf("%s(...$args) { return %s.%s(...$args); }", keyword, receiver, funName)
 		return []byte(fmt.Sprintf("\t\t%s = %s;\n", lvalue, fun))
 	}
 
@@ -272,6 +279,12 @@ func (fc *funcContext) translateFunctionBody(typ *ast.FuncType, recv *ast.Ident,
 
 	var prefix, suffix string
 
+	// Blocking functions are either compiled into async functions, which await
+	// blocking calls, or into state machines, which can be resumed after the
+	// call unblocks.
+	async := fc.IsBlocking() && experiments.Env.AsyncAwait
+	resumable := fc.IsBlocking() && !async
+
 	if len(fc.Flattened) != 0 {
 		// $s contains an index of the switch case a blocking function reached
 		// before getting blocked. When execution resumes, it will allow to continue
@@ -283,14 +296,14 @@ func (fc *funcContext) translateFunctionBody(typ *ast.FuncType, recv *ast.Ident,
 	if fc.HasDefer {
 		fc.localVars = append(fc.localVars, "$deferred")
 		suffix = " }" + suffix
-		if fc.IsBlocking() {
+		if resumable {
 			suffix = " }" + suffix
 		}
 	}
 
 	localVarDefs := "" // Function-local var declaration at the top.
 
-	if fc.IsBlocking() {
+	if resumable {
 		localVars := append([]string{}, fc.localVars...)
 		// There are several special variables involved in handling blocking functions:
 		// $r is sometimes used as a temporary variable to store blocking call result.
@@ -314,17 +327,21 @@ func (fc *funcContext) translateFunctionBody(typ *ast.FuncType, recv *ast.Ident,
 	if fc.HasDefer {
 		prefix = prefix + " var $err = null; try {"
 		deferSuffix := " } catch(err) { $err = err;"
-		if fc.IsBlocking() {
+		if resumable {
 			deferSuffix += " $s = -1;"
 		}
 		if fc.resultNames == nil && fc.sig.HasResults() {
 			deferSuffix += fmt.Sprintf(" return%s;", fc.translateResults(nil))
 		}
-		deferSuffix += " } finally { $callDeferred($deferred, $err);"
+		if async {
+			deferSuffix += " } finally { await $callDeferredAsync($deferred, $err);"
+		} else {
+			deferSuffix += " } finally { $callDeferred($deferred, $err);"
+		}
 		if fc.resultNames != nil {
 			deferSuffix += fmt.Sprintf(" if (!$curGoroutine.asleep) { return %s; }", fc.translateResults(fc.resultNames))
 		}
-		if fc.IsBlocking() {
+		if resumable {
 			deferSuffix += " if($curGoroutine.asleep) {"
 		}
 		suffix = deferSuffix + suffix
@@ -336,7 +353,12 @@ func (fc *funcContext) translateFunctionBody(typ *ast.FuncType, recv *ast.Ident,
 	}
 
 	if fc.HasDefer {
-		prefix = prefix + " $deferred = []; $curGoroutine.deferStack.push($deferred);"
+		prefix = prefix + " $deferred = [];"
+		if !experiments.Env.AsyncAwait {
+			// With the asyncawait experiment each function runs its own deferred
+			// calls while a panic unwinds, so the goroutine doesn't track them.
+			prefix = prefix + " $curGoroutine.deferStack.push($deferred);"
+		}
 	}
 
 	if prefix != "" {
@@ -351,5 +373,9 @@ func (fc *funcContext) translateFunctionBody(typ *ast.FuncType, recv *ast.Ident,
 
 	fc.pkgCtx.escapingVars = prevEV
 
-	return fmt.Sprintf("%sfunction %s(%s) {\n%s%s}", fc.funcRef.EncodeHint(), fc.funcRef, strings.Join(args, ", "), bodyOutput, fc.Indentation(1))
+	keyword := "function"
+	if async {
+		keyword = "async function"
+	}
+	return fmt.Sprintf("%s%s %s(%s) {\n%s%s}%s", fc.funcRef.EncodeHint(), keyword, fc.funcRef, strings.Join(args, ", "), bodyOutput, fc.Indentation(1), fc.funcRef.EncodeEndHint())
 }
@@ -54,6 +54,7 @@ type deferStmt struct {
 	obj      types.Object
 	lit      *ast.FuncLit
 	typeArgs typesutil.TypeList
+	dynamic  *dynamicCall
 }
 
 // newBlockingDefer creates a new defer statement that is blocking.
@@ -77,6 +78,13 @@ func newLitDefer(lit *ast.FuncLit, typeArgs typesutil.TypeList) *deferStmt {
 	return &deferStmt{lit: lit, typeArgs: typeArgs}
 }
 
+// newDynamicDefer creates a new defer statement for a call through an
+// interface method or a function value. The call is used to look up the
+// blocking information once it is resolved.
+func newDynamicDefer(call *dynamicCall) *deferStmt {
+	return &deferStmt{dynamic: call}
+}
+
 // IsBlocking determines if the defer statement is blocking or not.
 func (d *deferStmt) IsBlocking(info *Info) bool {
 	// If the object or the literal is set then we can look up the blocking,
@@ -88,6 +96,9 @@ func (d *deferStmt) IsBlocking(info *Info) bool {
 	if d.lit != nil {
 		return info.FuncLitInfo(d.lit, d.typeArgs).IsBlocking()
 	}
+	if d.dynamic != nil {
+		return d.dynamic.IsBlocking()
+	}
 	return true
 }
 
@@ -0,0 +1,330 @@
+package analysis
+
+import (
+	"go/ast"
+	"go/types"
+
+	"github.com/gopherjs/gopherjs/compiler/internal/typeparams"
+	"github.com/gopherjs/gopherjs/compiler/typesutil"
+)
+
+// dynamicCall is a call through an interface method or a function value.
+//
+// Such calls can't be resolved to a single callee while analyzing a package,
+// so with the callgraph experiment enabled PropagateAnalysis resolves them to
+// the set of functions across the whole program that they may possibly call.
+// Without it, dynamic calls are conservatively assumed to be blocking.
+type dynamicCall struct {
+	// method is the interface method being called, or nil for calls of
+	// function values.
+	method *types.Func
+	// sig is the signature of the called function with the type parameters
+	// of the caller substituted.
+	sig *types.Signature
+	// targets is the set of functions the call may call, or nil if the call
+	// hasn't been resolved yet.
+	targets *calleeSet
+}
+
+// IsBlocking returns true if any of the possible targets of the call may
+// block, or if the call hasn't been resolved.
+func (c *dynamicCall) IsBlocking() bool {
+	return c.targets == nil || c.targets.IsBlocking()
+}
+
+// funcValue is a function used as a value rather than called directly,
+// which may be called through a function value elsewhere in the program.
+type funcValue struct {
+	// sig is the type of the function value with the type parameters of the
+	// enclosing function substituted.
+	sig types.Type
+	// inst is the named function or method instance used as a value.
+	inst typeparams.Instance
+	// funcInfo is the function literal used as a value if inst is not set.
+	// It is nil if the function can't be determined, such as an interface
+	// method value, which is then assumed to be blocking.
+	funcInfo *FuncInfo
+}
+
+// calleeSet is a set of functions a dynamic call may call.
+type calleeSet struct {
+	// targets is the list of possible callees. A nil target stands for a
+	// function we know nothing about, which may be blocking.
+	targets []*FuncInfo
+	// blocking caches the blocking status once any of the targets is
+	// known to be blocking. Blocking status never reverts.
+	blocking bool
+}
+
+// IsBlocking returns true if any of the targets in the set may block.
+func (s *calleeSet) IsBlocking() bool {
+	if !s.blocking {
+		for _, fi := range s.targets {
+			if fi.IsBlocking() {
+				s.blocking = true
+				break
+			}
+		}
+	}
+	return s.blocking
+}
+
+// methodImpl is a concrete method instance which may implement an interface
+// method with the same name.
+type methodImpl struct {
+	sig      types.Type
+	funcInfo *FuncInfo
+}
+
+// dynamicCallResolver resolves the possible targets of dynamic calls, given
+// all the functions of the whole program.
+//
+// This is a class hierarchy analysis of sorts: an interface method call may
+// call any method of the program with the same name and signature, and a
+// function value call may call any function with the same signature that is
+// used as a value. Since exported methods can be turned into function values
+// via reflection, those are assumed to be used as values too.
+type dynamicCallResolver struct {
+	infos map[*types.Package]*Info
+	// methods are the concrete methods of the program by methodKey.
+	methods map[string][]methodImpl
+	// values are the functions used as values by their signature.
+	values typesutil.Map[[]*FuncInfo]
+	// genericValues are the functions used as values whose signatures
+	// couldn't be resolved to concrete types, which match any signature.
+	genericValues []*FuncInfo
+
+	methodSets map[string]*typesutil.Map[*calleeSet]
+	valueSets  typesutil.Map[*calleeSet]
+}
+
+// methodKey returns the key used to match interface methods with concrete
+// methods. Unexported methods only match methods of the same package.
+func methodKey(method *types.Func) string {
+	if method.Exported() {
+		return method.Name()
+	}
+	return method.Pkg().Path() + "." + method.Name()
+}
+
+// resolveDynamicCalls resolves the targets of all dynamic calls recorded while
+// analyzing the given packages, which must be the whole program.
+func resolveDynamicCalls(allInfo []*Info) {
+	r := &dynamicCallResolver{
+		infos:      make(map[*types.Package]*Info, len(allInfo)),
+		methods:    make(map[string][]methodImpl),
+		methodSets: make(map[string]*typesutil.Map[*calleeSet]),
+	}
+	for _, info := range allInfo {
+		r.infos[info.Pkg] = info
+	}
+
+	for _, info := range allInfo {
+		info.funcInstInfos.Iterate(func(inst typeparams.Instance, fi *FuncInfo) {
+			method, ok := inst.Object.(*types.Func)
+			if !ok {
+				return
+			}
+			sig := method.Type().(*types.Signature)
+			if sig.Recv() == nil || types.IsInterface(sig.Recv().Type()) {
+				return
+			}
+			impl := methodImpl{sig: fi.resolver.Substitute(sig), funcInfo: fi}
+			key := methodKey(method)
+			r.methods[key] = append(r.methods[key], impl)
+			if method.Exported() {
+				r.addValue(impl.sig, fi)
+			}
+		})
+	}
+
+	for _, info := range allInfo {
+		for _, v := range info.funcValues {
+			fi := v.funcInfo
+			if v.inst.Object != nil {
+				fi = r.funcInfo(v.inst)
+			}
+			r.addValue(v.sig, fi)
+		}
+	}
+
+	for _, info := range allInfo {
+		for _, fi := range info.allInfos {
+			for call := range fi.dynamicCallees {
+				r.resolve(call)
+			}
+			for _, d := range fi.deferStmts {
+				if d.dynamic != nil {
+					r.resolve(d.dynamic)
+				}
+			}
+		}
+	}
+}
+
+// funcInfo returns the information about the given function instance from
+// any package of the program, or nil if it isn't known.
+func (r *dynamicCallResolver) funcInfo(inst typeparams.Instance) *FuncInfo {
+	info, ok := r.infos[inst.Object.Pkg()]
+	if !ok {
+		return nil
+	}
+	return info.FuncInfo(inst)
+}
+
+func (r *dynamicCallResolver) addValue(sig types.Type, fi *FuncInfo) {
+	if typeparams.IsGeneric(sig) {
+		r.genericValues = append(r.genericValues, fi)
+		return
+	}
+	sig = sig.Underlying()
+	r.values.Set(sig, append(r.values.At(sig), fi))
+}
+
+func (r *dynamicCallResolver) resolve(call *dynamicCall) {
+	if call.targets != nil {
+		return
+	}
+	if call.method != nil {
+		call.targets = r.methodSet(call.method, call.sig)
+	} else {
+		call.targets = r.valueSet(call.sig)
+	}
+}
+
+// methodSet returns the set of concrete methods an interface method with the
+// given signature may call.
+func (r *dynamicCallResolver) methodSet(method *types.Func, sig *types.Signature) *calleeSet {
+	key := methodKey(method)
+	sets, ok := r.methodSets[key]
+	if !ok {
+		sets = new(typesutil.Map[*calleeSet])
+		r.methodSets[key] = sets
+	}
+	if set := sets.At(sig); set != nil {
+		return set
+	}
+
+	set := &calleeSet{}
+	for _, impl := range r.methods[key] {
+		if typeparams.IsGeneric(impl.sig) || types.Identical(impl.sig, sig) {
+			set.targets = append(set.targets, impl.funcInfo)
+		}
+	}
+	sets.Set(sig, set)
+	return set
+}
+
+// valueSet returns the set of functions used as values a function value with
+// the given signature may call.
+func (r *dynamicCallResolver) valueSet(sig *types.Signature) *calleeSet {
+	if set := r.valueSets.At(sig); set != nil {
+		return set
+	}
+	set := &calleeSet{}
+	set.targets = append(set.targets, r.values.At(sig)...)
+	set.targets = append(set.targets, r.genericValues...)
+	r.valueSets.Set(sig, set)
+	return set
+}
+
+// isCallee returns true if the node on top of the visitor stack is the function
+// called by the enclosing call expression or go statement, as opposed to being
+// used as a function value.
+func (fi *FuncInfo) isCallee() bool {
+	fun := fi.visitorStack[len(fi.visitorStack)-1]
+	for i := len(fi.visitorStack) - 2; i >= 0; i-- {
+		switch parent := fi.visitorStack[i].(type) {
+		case *ast.ParenExpr:
+		case *ast.SelectorExpr:
+			if parent.Sel != fun {
+				return false
+			}
+		case *ast.IndexExpr:
+			if parent.X != fun {
+				return false
+			}
+		case *ast.IndexListExpr:
+			if parent.X != fun {
+				return false
+			}
+		case *ast.CallExpr:
+			return parent.Fun == fun
+		case *ast.GoStmt:
+			return parent.Call.Fun == fun
+		default:
+			return false
+		}
+		fun = fi.visitorStack[i]
+	}
+	return false
+}
+
+// funcValueForIdent records a named function used as a value.
+func (fi *FuncInfo) funcValueForIdent(id *ast.Ident) {
+	o, ok := fi.pkgInfo.Uses[id].(*types.Func)
+	if !ok || o.Type().(*types.Signature).Recv() != nil || fi.isCallee() {
+		// Methods are recorded by funcValueForSelector.
+		return
+	}
+	fi.pkgInfo.funcValues = append(fi.pkgInfo.funcValues, funcValue{
+		sig:  fi.resolver.Substitute(fi.pkgInfo.TypeOf(id)),
+		inst: fi.instanceForIdent(id),
+	})
+}
+
+// funcValueForSelector records a method value or a method expression.
+func (fi *FuncInfo) funcValueForSelector(e *ast.SelectorExpr) {
+	sel := fi.pkgInfo.Selections[e]
+	if sel == nil || sel.Kind() == types.FieldVal || fi.isCallee() {
+		return
+	}
+	v := funcValue{sig: fi.resolver.Substitute(fi.pkgInfo.TypeOf(e))}
+	if inst := fi.instanceForSelection(sel); !types.IsInterface(recvType(inst.Object)) {
+		// Interface method values are left without a target, any method of
+		// the interface may be called through them.
+		v.inst = inst
+	}
+	fi.pkgInfo.funcValues = append(fi.pkgInfo.funcValues, v)
+}
+
+// funcValueForLit records a function literal used as a value.
+func (fi *FuncInfo) funcValueForLit(lit *ast.FuncLit, litInfo *FuncInfo) {
+	if fi.isCallee() {
+		return
+	}
+	fi.pkgInfo.funcValues = append(fi.pkgInfo.funcValues, funcValue{
+		sig:      fi.resolver.Substitute(fi.pkgInfo.TypeOf(lit)),
+		funcInfo: litInfo,
+	})
+}
+
+// methodInstanceType returns the type of the given method instance. The
+// methods of generic types are instantiated with the type arguments of the
+// instance, which are the ones of the receiver type.
+func (fi *FuncInfo) methodInstanceType(inst typeparams.Instance) types.Type {
+	o := inst.Object.(*types.Func)
+	if len(inst.TArgs) == 0 {
+		return o.Type()
+	}
+	recv, ok := recvType(o).(*types.Named)
+	if !ok {
+		return o.Type()
+	}
+	named, err := types.Instantiate(fi.pkgInfo.typeCtx, recv.Origin(), inst.TArgs, false)
+	if err != nil {
+		return o.Type()
+	}
+	if m, _, _ := types.LookupFieldOrMethod(named, true, o.Pkg(), o.Name()); m != nil {
+		return m.Type()
+	}
+	return o.Type()
+}
+
+// recvType returns the receiver type of a method, or nil for a function.
+func recvType(o types.Object) types.Type {
+	if recv := o.Type().(*types.Signature).Recv(); recv != nil {
+		return recv.Type()
+	}
+	return nil
+}
@@ -4,11 +4,21 @@ import (
 	"go/ast"
 	"go/token"
 	"go/types"
+
+	"github.com/gopherjs/gopherjs/compiler/astutil"
 )
 
-func EscapingObjects(n ast.Node, info *types.Info) []*types.Var {
+// EscapingObjects returns the variables declared in the given node which need
+// a new JavaScript binding each time they're declared, because they're captured
+// by a function literal or a pointer to them is taken.
+//
+// If addrEscapes is not nil, taking the address of a variable only makes it
+// escaping if addrEscapes returns true for the address-of expression, see
+// Info.AddrEscapes.
+func EscapingObjects(n ast.Node, info *types.Info, addrEscapes func(*ast.UnaryExpr) bool) []*types.Var {
 	v := escapeAnalysis{
 		info:         info,
+		addrEscapes:  addrEscapes,
 		escaping:     make(map[*types.Var]bool),
 		topScope:     info.Scopes[n],
 		bottomScopes: make(map[*types.Scope]bool),
@@ -19,6 +29,7 @@ func EscapingObjects(n ast.Node, info *types.Info) []*types.Var {
 
 type escapeAnalysis struct {
 	info         *types.Info
+	addrEscapes  func(*ast.UnaryExpr) bool
 	escaping     map[*types.Var]bool
 	ordered      []*types.Var
 	topScope     *types.Scope
@@ -30,7 +41,7 @@ func (v *escapeAnalysis) Visit(node ast.Node) (w ast.Visitor) {
 	switch n := node.(type) {
 	case *ast.UnaryExpr:
 		if n.Op == token.AND {
-			if _, ok := n.X.(*ast.Ident); ok {
+			if _, ok := n.X.(*ast.Ident); ok && (v.addrEscapes == nil || v.addrEscapes(n)) {
 				return &escapingObjectCollector{v}
 			}
 		}
@@ -68,3 +79,306 @@ func (v *escapingObjectCollector) Visit(node ast.Node) (w ast.Visitor) {
 	}
 	return v
 }
+
+// analyzePointers collects the information about where the pointers passed to
+// functions of the package as arguments, and pointers to local variables, may
+// flow to. Whether they escape is decided once the information about all
+// packages is available, see propagateParamEscapes.
+//
+// The analysis is intraprocedural and conservative: a pointer doesn't escape
+// only if it is dereferenced, compared or passed on to a parameter of a
+// statically known function which itself doesn't let it escape. Storing the
+// pointer anywhere, returning it, converting it to an interface or capturing
+// it in a function literal makes it escape.
+//
+// The result doesn't remove the pointer objects themselves: with optimizations
+// enabled, the compiler uses it to avoid boxing a variable each time it's
+// declared when the pointers to it don't escape, so they're allocated once per
+// call of the function, see AddrEscapes. Pointers are only eliminated for calls
+// inlined by the optimizer.
+func (info *Info) analyzePointers(files []*ast.File) {
+	for _, file := range files {
+		for _, decl := range file.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Body == nil {
+				continue
+			}
+
+			params := map[*types.Var]bool{}
+			fields := fd.Type.Params.List
+			if fd.Recv != nil {
+				fields = append(fd.Recv.List[:len(fd.Recv.List):len(fd.Recv.List)], fields...)
+			}
+			for _, field := range fields {
+				for _, name := range field.Names {
+					param, ok := info.Defs[name].(*types.Var)
+					if !ok || !isPointer(param.Type()) {
+						continue
+					}
+					params[param] = true
+					info.pointerParams[param] = nil
+				}
+			}
+
+			var stack astPath
+			ast.Inspect(fd.Body, func(n ast.Node) bool {
+				if n == nil {
+					stack = stack[:len(stack)-1]
+					return true
+				}
+				stack = append(stack, n)
+				switch n := n.(type) {
+				case *ast.Ident:
+					param, ok := info.Uses[n].(*types.Var)
+					if !ok || !params[param] || info.escapingParams[param] {
+						break
+					}
+					if flows, ok := info.pointerUse(stack); ok && !inFuncLit(stack) {
+						info.pointerParams[param] = append(info.pointerParams[param], flows...)
+					} else {
+						info.escapingParams[param] = true
+					}
+				case *ast.UnaryExpr:
+					if n.Op != token.AND {
+						break
+					}
+					if _, ok := astutil.RemoveParens(n.X).(*ast.Ident); !ok {
+						break
+					}
+					if flows, ok := info.pointerUse(stack); ok {
+						info.localAddrs[n] = flows
+					}
+				}
+				return true
+			})
+		}
+	}
+}
+
+// pointerUse returns the parameters of the functions the pointer value on top
+// of the stack is passed to. The second result is false if the pointer may
+// escape otherwise.
+func (info *Info) pointerUse(stack astPath) ([]*types.Var, bool) {
+	parent, i := stack.parent(len(stack) - 1)
+	child := stack[i+1]
+	switch p := parent.(type) {
+	case *ast.StarExpr:
+		return nil, info.pointeeUse(stack, i)
+	case *ast.IndexExpr:
+		// Indexing a pointer to an array.
+		return nil, p.X == child && info.pointeeUse(stack, i)
+	case *ast.SelectorExpr:
+		sel := info.Selections[p]
+		if sel == nil {
+			return nil, false
+		}
+		switch sel.Kind() {
+		case types.FieldVal:
+			return nil, info.pointeeUse(stack, i)
+		case types.MethodVal:
+			call, j := stack.parent(i)
+			if c, ok := call.(*ast.CallExpr); !ok || c.Fun != stack[j+1] || isDelegated(stack, j) {
+				return nil, false
+			}
+			recv := sel.Obj().(*types.Func).Origin().Type().(*types.Signature).Recv()
+			if types.IsInterface(recv.Type()) {
+				return nil, false
+			}
+			if !isPointer(recv.Type()) {
+				// The method is called with a copy of the pointed to value.
+				return nil, true
+			}
+			return []*types.Var{recv}, true
+		}
+		return nil, false
+	case *ast.CallExpr:
+		if p.Fun == child || isDelegated(stack, i) {
+			return nil, false
+		}
+		return info.argumentUse(p, child)
+	case *ast.BinaryExpr:
+		return nil, p.Op == token.EQL || p.Op == token.NEQ
+	case *ast.AssignStmt:
+		// Assigning to a variable holding a pointer doesn't let the previous
+		// value escape.
+		if p.Tok != token.ASSIGN {
+			return nil, false
+		}
+		for _, lhs := range p.Lhs {
+			if lhs == child {
+				return nil, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// pointeeUse returns true if the value pointed to, which is on the stack at
+// the given index, is only read or written, as opposed to the address of it
+// or a part of it being taken.
+func (info *Info) pointeeUse(stack astPath, i int) bool {
+	for {
+		parent, j := stack.parent(i)
+		child := stack[j+1]
+		switch p := parent.(type) {
+		case *ast.SelectorExpr:
+			sel := info.Selections[p]
+			if sel == nil {
+				return false
+			}
+			if sel.Kind() == types.MethodVal {
+				// Calling a pointer method takes the address of the value.
+				recv := sel.Obj().Type().(*types.Signature).Recv().Type()
+				return !isPointer(recv) || isPointer(sel.Recv())
+			}
+			i = j
+			continue
+		case *ast.IndexExpr:
+			if p.X == child {
+				i = j
+				continue
+			}
+		case *ast.UnaryExpr:
+			return p.Op != token.AND
+		case *ast.SliceExpr:
+			return p.X != child
+		}
+		return true
+	}
+}
+
+// argumentUse returns the parameter of the statically known function the
+// pointer argument of the call is passed to.
+func (info *Info) argumentUse(call *ast.CallExpr, arg ast.Node) ([]*types.Var, bool) {
+	fun := astutil.RemoveParens(call.Fun)
+	if astutil.IsTypeExpr(fun, info.Info) {
+		return nil, false
+	}
+	switch f := fun.(type) {
+	case *ast.IndexExpr:
+		fun = f.X
+	case *ast.IndexListExpr:
+		fun = f.X
+	}
+
+	var callee types.Object
+	switch f := fun.(type) {
+	case *ast.Ident:
+		callee = info.Uses[f]
+	case *ast.SelectorExpr:
+		if sel := info.Selections[f]; sel == nil {
+			callee = info.Uses[f.Sel]
+		} else if sel.Kind() == types.MethodVal && !types.IsInterface(sel.Recv()) {
+			callee = sel.Obj()
+		}
+	}
+
+	switch callee := callee.(type) {
+	case *types.Builtin:
+		switch callee.Name() {
+		case "len", "cap", "print", "println":
+			return nil, true
+		}
+	case *types.Func:
+		sig := callee.Origin().Type().(*types.Signature)
+		for idx, a := range call.Args {
+			if a != arg {
+				continue
+			}
+			if sig.Variadic() && idx >= sig.Params().Len()-1 {
+				return nil, false
+			}
+			param := sig.Params().At(idx)
+			if !isPointer(param.Type()) {
+				// Converted to an interface.
+				return nil, false
+			}
+			return []*types.Var{param}, true
+		}
+	}
+	return nil, false
+}
+
+// propagateParamEscapes marks the pointer parameters passing their value on
+// to escaping parameters as escaping, until no more escaping parameters are
+// found across all the given packages.
+func propagateParamEscapes(allInfo []*Info) {
+	for done := false; !done; {
+		done = true
+		for _, info := range allInfo {
+			for param, flows := range info.pointerParams {
+				if info.escapingParams[param] {
+					continue
+				}
+				for _, to := range flows {
+					if info.paramEscapes(to) {
+						info.escapingParams[param] = true
+						done = false
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+// paramEscapes returns true if the pointer passed as the given parameter may
+// outlive the call. If the parameter is from a different package, this will
+// use the InfoImporter to lookup the information from the other package.
+func (info *Info) paramEscapes(param *types.Var) bool {
+	if param.Pkg() != info.Pkg {
+		if param.Pkg() == nil || info.infoImporter == nil {
+			return true
+		}
+		otherInfo, err := info.infoImporter(param.Pkg().Path())
+		if err != nil {
+			return true
+		}
+		return otherInfo.paramEscapes(param)
+	}
+	_, analyzed := info.pointerParams[param]
+	return !analyzed || info.escapingParams[param]
+}
+
+// AddrEscapes returns true if the pointer taken by the given address-of
+// expression may outlive the expression it is used in, such as the call it is
+// passed to. If it doesn't, the variable needn't be boxed for the pointer,
+// which may then be reused for every binding of the variable.
+func (info *Info) AddrEscapes(e *ast.UnaryExpr) bool {
+	flows, ok := info.localAddrs[e]
+	if !ok {
+		return true
+	}
+	for _, to := range flows {
+		if info.paramEscapes(to) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDelegated returns true if the call expression on the stack at the given
+// index is deferred or run in a goroutine, outliving the current statement.
+func isDelegated(stack astPath, i int) bool {
+	parent, _ := stack.parent(i)
+	switch parent.(type) {
+	case *ast.GoStmt, *ast.DeferStmt:
+		return true
+	}
+	return false
+}
+
+// inFuncLit returns true if there is a function literal on the stack.
+func inFuncLit(stack astPath) bool {
+	for _, n := range stack {
+		if _, ok := n.(*ast.FuncLit); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func isPointer(t types.Type) bool {
+	_, ok := t.Underlying().(*types.Pointer)
+	return ok
+}
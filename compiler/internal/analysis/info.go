@@ -10,6 +10,7 @@ import (
 	"github.com/gopherjs/gopherjs/compiler/astutil"
 	"github.com/gopherjs/gopherjs/compiler/internal/typeparams"
 	"github.com/gopherjs/gopherjs/compiler/typesutil"
+	"github.com/gopherjs/gopherjs/internal/experiments"
 )
 
 type continueStmt struct {
@@ -35,6 +36,18 @@ func (src astPath) copy() astPath {
 	return dst
 }
 
+// parent returns the closest ancestor of the node at the given index which
+// isn't a parenthesized expression, along with its index, or nil and -1 if
+// there is none.
+func (ap astPath) parent(i int) (ast.Node, int) {
+	for i--; i >= 0; i-- {
+		if _, ok := ap[i].(*ast.ParenExpr); !ok {
+			return ap[i], i
+		}
+	}
+	return nil, -1
+}
+
 func (ap astPath) String() string {
 	s := &strings.Builder{}
 	s.WriteString("[")
@@ -56,10 +69,21 @@ type Info struct {
 	HasPointer    map[*types.Var]bool
 	funcInstInfos *typeparams.InstanceMap[*FuncInfo]
 	funcLitInfos  map[*ast.FuncLit][]*FuncInfo
+	funcDecls     map[*types.Func]*ast.FuncDecl
 	InitFuncInfo  *FuncInfo // Context for package variable initialization.
 
 	infoImporter InfoImporter // To get `Info` for other packages.
 	allInfos     []*FuncInfo
+	funcValues   []funcValue // Functions used as values, see dynamicCall.
+
+	// Escape analysis of pointers, see analyzePointers.
+	pointerParams  map[*types.Var][]*types.Var // Parameters they flow to.
+	escapingParams map[*types.Var]bool
+	localAddrs     map[*ast.UnaryExpr][]*types.Var // Parameters they flow to.
+
+	// PropNames maps field and method names to their JS property names across
+	// the whole program, see ManglePropNames. Nil unless the names are mangled.
+	PropNames map[string]string
 }
 
 // InfoImporter is used to get the `Info` for another package.
@@ -75,6 +99,7 @@ func (info *Info) newFuncInfo(n ast.Node, obj types.Object, typeArgs typesutil.T
 		loopReturnIndex:    -1,
 		instCallees:        new(typeparams.InstanceMap[[]astPath]),
 		literalFuncCallees: make(map[*ast.FuncLit]astPath),
+		dynamicCallees:     make(map[*dynamicCall]astPath),
 		typeArgs:           typeArgs,
 		resolver:           resolver,
 	}
@@ -97,6 +122,9 @@ func (info *Info) newFuncInfo(n ast.Node, obj types.Object, typeArgs typesutil.T
 		}
 		inst := typeparams.Instance{Object: obj, TArgs: typeArgs}
 		info.funcInstInfos.Set(inst, funcInfo)
+		if fn, ok := obj.(*types.Func); ok {
+			info.funcDecls[fn.Origin()] = n
+		}
 
 	case *ast.FuncLit:
 		info.funcLitInfos[n] = append(info.funcLitInfos[n], funcInfo)
@@ -154,6 +182,31 @@ func (info *Info) FuncInfo(inst typeparams.Instance) *FuncInfo {
 	return info.funcInstInfos.Get(inst)
 }
 
+// FuncDecl returns the declaration of the given function or method and the
+// information about the package it is declared in. If fn is from a different
+// package, the InfoImporter is used to lookup the declaration from the other
+// package. Returns nil if the declaration isn't found.
+func (info *Info) FuncDecl(fn *types.Func) (*ast.FuncDecl, *Info) {
+	if fn.Pkg() == nil {
+		return nil, nil
+	}
+	if fn.Pkg() != info.Pkg {
+		if info.infoImporter == nil {
+			return nil, nil
+		}
+		otherInfo, err := info.infoImporter(fn.Pkg().Path())
+		if err != nil {
+			return nil, nil
+		}
+		return otherInfo.FuncDecl(fn)
+	}
+	decl, ok := info.funcDecls[fn.Origin()]
+	if !ok {
+		return nil, nil
+	}
+	return decl, info
+}
+
 // FuncLitInfo returns information about the given function literal, or nil if not found.
 // The given type arguments are used to identify the correct instance of the
 // function literal in the case the literal was defined inside a generic function.
@@ -194,6 +247,11 @@ func AnalyzePkg(files []*ast.File, fileSet *token.FileSet, typesInfo *types.Info
 		infoImporter:  infoImporter,
 		funcInstInfos: new(typeparams.InstanceMap[*FuncInfo]),
 		funcLitInfos:  make(map[*ast.FuncLit][]*FuncInfo),
+		funcDecls:     make(map[*types.Func]*ast.FuncDecl),
+
+		pointerParams:  make(map[*types.Var][]*types.Var),
+		escapingParams: make(map[*types.Var]bool),
+		localAddrs:     make(map[*ast.UnaryExpr][]*types.Var),
 	}
 	info.InitFuncInfo = info.newFuncInfo(nil, nil, nil, nil)
 
@@ -202,6 +260,7 @@ func AnalyzePkg(files []*ast.File, fileSet *token.FileSet, typesInfo *types.Info
 	for _, file := range files {
 		ast.Walk(info.InitFuncInfo, file)
 	}
+	info.analyzePointers(files)
 
 	return info
 }
@@ -209,6 +268,10 @@ func AnalyzePkg(files []*ast.File, fileSet *token.FileSet, typesInfo *types.Info
 // PropagateAnalysis will propagate analysis information across package
 // boundaries to finish the analysis of a whole project.
 func PropagateAnalysis(allInfo []*Info) {
+	if experiments.Env.CallGraph {
+		resolveDynamicCalls(allInfo)
+	}
+
 	done := false
 	for !done {
 		done = true
@@ -222,6 +285,8 @@ func PropagateAnalysis(allInfo []*Info) {
 	for _, info := range allInfo {
 		info.propagateControlStatementBlocking()
 	}
+
+	propagateParamEscapes(allInfo)
 }
 
 // propagateFunctionBlocking propagates information about blocking calls
@@ -253,6 +318,15 @@ func (info *Info) propagateFunctionBlocking() bool {
 				done = false
 			}
 		}
+
+		// Check calls through interface methods and function values.
+		for call, callSite := range caller.dynamicCallees {
+			if call.IsBlocking() {
+				caller.markBlocking(callSite)
+				delete(caller.dynamicCallees, call)
+				done = false
+			}
+		}
 	}
 	return done
 }
@@ -272,7 +346,8 @@ type FuncInfo struct {
 	HasDefer bool
 	// Nodes are "flattened" into a switch-case statement when we need to be able
 	// to jump into an arbitrary position in the code with a GOTO statement, or
-	// resume a goroutine after a blocking call unblocks.
+	// resume a goroutine after a blocking call unblocks. The latter isn't needed
+	// with the asyncawait experiment, which awaits blocking calls in place.
 	Flattened map[ast.Node]bool
 	// Blocking indicates that either the AST node itself or its descendant may
 	// block goroutine execution (for example, a channel operation).
@@ -305,6 +380,10 @@ type FuncInfo struct {
 	// doStuff()`), which are handled by localInstCallees. If any of them are
 	// identified as blocking, this function will become blocking too.
 	literalFuncCallees map[*ast.FuncLit]astPath
+	// List of calls through interface methods and function values. Unless
+	// the callgraph experiment is enabled, such calls are marked blocking
+	// right away instead. See dynamicCall.
+	dynamicCallees map[*dynamicCall]astPath
 	// typeArgs are the type arguments for the function instance.
 	typeArgs typesutil.TypeList
 	// resolver is used by this function instance to resolve any type arguments
@@ -395,7 +474,21 @@ func (fi *FuncInfo) Visit(node ast.Node) ast.Visitor {
 		return nil
 	case *ast.FuncLit:
 		// Analyze the function literal in its own context.
-		return fi.pkgInfo.newFuncInfo(n, nil, fi.typeArgs, fi.resolver)
+		litInfo := fi.pkgInfo.newFuncInfo(n, nil, fi.typeArgs, fi.resolver)
+		if experiments.Env.CallGraph {
+			fi.funcValueForLit(n, litInfo)
+		}
+		return litInfo
+	case *ast.Ident:
+		if experiments.Env.CallGraph {
+			fi.funcValueForIdent(n)
+		}
+		return fi
+	case *ast.SelectorExpr:
+		if experiments.Env.CallGraph {
+			fi.funcValueForSelector(n)
+		}
+		return fi
 	case *ast.BranchStmt:
 		switch n.Tok {
 		case token.GOTO:
@@ -589,12 +682,9 @@ func (fi *FuncInfo) visitCallExpr(n *ast.CallExpr, deferredCall bool) ast.Visito
 		}
 		// The called function is gotten with an index or key from a map, array, or slice.
 		// e.g. `m := map[string]func(){}; m["key"]()`, `s := []func(); s[0]()`.
-		// Since we can't predict if the returned function will be blocking
-		// or not, we have to be conservative and assume that function might be blocking.
-		fi.markBlocking(fi.visitorStack)
-		if deferredCall {
-			fi.deferStmts = append(fi.deferStmts, newBlockingDefer())
-		}
+		// Since we can't predict which function will be returned, it might be
+		// any function value of the same type.
+		fi.callToDynamicFunc(nil, fi.pkgInfo.TypeOf(f), deferredCall)
 		return fi
 	case *ast.IndexListExpr:
 		// Collect info about the instantiated type or function.
@@ -628,12 +718,9 @@ func (fi *FuncInfo) visitCallExpr(n *ast.CallExpr, deferredCall bool) ast.Visito
 			// blocking, but we will visit the input expression.
 			return fi
 		}
-		// The function is returned by a non-trivial expression. We have to be
-		// conservative and assume that function might be blocking.
-		fi.markBlocking(fi.visitorStack)
-		if deferredCall {
-			fi.deferStmts = append(fi.deferStmts, newBlockingDefer())
-		}
+		// The function is returned by a non-trivial expression, it might be
+		// any function value of the same type.
+		fi.callToDynamicFunc(nil, fi.pkgInfo.TypeOf(f), deferredCall)
 		return fi
 	}
 }
@@ -680,14 +767,10 @@ func (fi *FuncInfo) instanceForSelection(sel *types.Selection) typeparams.Instan
 func (fi *FuncInfo) callToNamedFunc(callee typeparams.Instance, deferredCall bool) {
 	switch o := callee.Object.(type) {
 	case *types.Func:
-		o = o.Origin()
-		if recv := o.Type().(*types.Signature).Recv(); recv != nil {
+		if recv := o.Origin().Type().(*types.Signature).Recv(); recv != nil {
 			if _, ok := recv.Type().Underlying().(*types.Interface); ok {
-				// Conservatively assume that an interface implementation may be blocking.
-				fi.markBlocking(fi.visitorStack)
-				if deferredCall {
-					fi.deferStmts = append(fi.deferStmts, newBlockingDefer())
-				}
+				// Any implementation of the interface method might be called.
+				fi.callToDynamicFunc(o, fi.methodInstanceType(callee), deferredCall)
 				return
 			}
 		}
@@ -701,22 +784,46 @@ func (fi *FuncInfo) callToNamedFunc(callee typeparams.Instance, deferredCall boo
 			fi.deferStmts = append(fi.deferStmts, newInstDefer(callee))
 		}
 	case *types.Var:
-		// Conservatively assume that a function in a variable might be blocking.
+		// A function in a variable might be any function value of its type.
+		fi.callToDynamicFunc(nil, o.Type(), deferredCall)
+	default:
+		// No need to add defers for other call types, such as *types.Builtin,
+		// since those are considered non-blocking.
+		return
+	}
+}
+
+// callToDynamicFunc records a call through the given interface method or
+// through a function value if method is nil. typ is the type of the called
+// function.
+//
+// Unless the callgraph experiment is enabled, or if the signature of the call
+// can't be resolved, the call is conservatively assumed to be blocking. So are
+// the calls in the js package, whose function parameters may hold prelude
+// functions calling blocking Go code, e.g. in callAsync.
+func (fi *FuncInfo) callToDynamicFunc(method *types.Func, typ types.Type, deferredCall bool) {
+	sig, ok := fi.resolver.Substitute(typ).Underlying().(*types.Signature)
+	if !experiments.Env.CallGraph || !ok || typeparams.IsGeneric(sig) || typesutil.IsJsPackage(fi.pkgInfo.Pkg) {
 		fi.markBlocking(fi.visitorStack)
 		if deferredCall {
 			fi.deferStmts = append(fi.deferStmts, newBlockingDefer())
 		}
-	default:
-		// No need to add defers for other call types, such as *types.Builtin,
-		// since those are considered non-blocking.
 		return
 	}
+
+	call := &dynamicCall{method: method, sig: sig}
+	fi.dynamicCallees[call] = fi.visitorStack.copy()
+	if deferredCall {
+		fi.deferStmts = append(fi.deferStmts, newDynamicDefer(call))
+	}
 }
 
 func (fi *FuncInfo) markBlocking(stack astPath) {
 	for _, n := range stack {
 		fi.Blocking[n] = true
-		fi.Flattened[n] = true
+		if !experiments.Env.AsyncAwait {
+			fi.Flattened[n] = true
+		}
 	}
 }
 
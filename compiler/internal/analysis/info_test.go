@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/gopherjs/gopherjs/compiler/internal/typeparams"
+	"github.com/gopherjs/gopherjs/internal/experiments"
 	"github.com/gopherjs/gopherjs/internal/srctesting"
 )
 
@@ -1621,6 +1622,245 @@ func TestBlocking_IsImportBlocking_BackwardInstances(t *testing.T) {
 	bt.assertNotBlocking(`notBlocking`)
 }
 
+func TestBlocking_CallGraph_InterfaceCall(t *testing.T) {
+	enableCallGraph(t)
+	bt := newBlockingTest(t,
+		`package test
+
+		type Waiter interface { Wait() }
+		type Printer interface { Print() }
+
+		type chanWaiter chan bool
+		func (c chanWaiter) Wait() { <-c }
+		func (c chanWaiter) Print() { println("chan") }
+
+		type noWaiter struct{}
+		func (noWaiter) Wait() {}
+
+		type stringPrinter string
+		func (s stringPrinter) Print() { println(s) }
+
+		type Closer interface { Close() error }
+
+		func wait(w Waiter) {
+			w.Wait()
+		}
+
+		func print(p Printer) {
+			p.Print()
+		}
+
+		func deferPrint(p Printer) {
+			defer p.Print()
+		}
+
+		func close(c Closer) {
+			c.Close()
+		}
+
+		func embedded(s struct{ Waiter }) {
+			s.Wait()
+		}`)
+	bt.assertBlocking(`wait`)
+	bt.assertNotBlocking(`print`)
+	bt.assertNotBlocking(`deferPrint`)
+	bt.assertNotBlocking(`close`)
+	bt.assertBlocking(`embedded`)
+}
+
+func TestBlocking_CallGraph_UnexportedMethod(t *testing.T) {
+	enableCallGraph(t)
+	otherSrc := `package other
+
+		type Blocker chan bool
+		func (b Blocker) wait() { <-b }`
+
+	testSrc := `package test
+
+		import "pkg/other"
+
+		var _ = other.Blocker(nil)
+
+		type waiter interface { wait() }
+
+		type noWaiter struct{}
+		func (noWaiter) wait() {}
+
+		func wait(w waiter) {
+			w.wait()
+		}`
+
+	bt := newBlockingTestWithOtherPackage(t, testSrc, otherSrc)
+	bt.assertNotBlocking(`wait`)
+}
+
+func TestBlocking_CallGraph_FuncValueCall(t *testing.T) {
+	enableCallGraph(t)
+	bt := newBlockingTest(t,
+		`package test
+
+		type T chan string
+		func (t T) Recv() string { return <-t }
+
+		func blocking(c chan int) int { return <-c }
+		func notBlocking(c chan int) int { return len(c) }
+
+		var (
+			byName = blocking
+			byLit = func() { println("hi") }
+		)
+
+		func callInts(f func(chan int) int) {
+			f(nil)
+		}
+
+		func callVoid(f func()) {
+			f()
+		}
+
+		func callStrings(f func() string) {
+			f()
+		}
+
+		func deferVoid(f func()) {
+			defer f()
+		}
+
+		func callFloats(f func(float64)) {
+			f(1)
+		}
+
+		func main() {
+			callInts(notBlocking)
+		}`)
+	bt.assertBlocking(`callInts`)
+	bt.assertNotBlocking(`callVoid`)
+	bt.assertNotBlocking(`deferVoid`)
+	// Exported methods may be called through values obtained via reflection.
+	bt.assertBlocking(`callStrings`)
+	bt.assertNotBlocking(`callFloats`)
+}
+
+func TestBlocking_CallGraph_JsPackage(t *testing.T) {
+	enableCallGraph(t)
+	bt := newBlockingTestInPackage(t, `github.com/gopherjs/gopherjs/js`,
+		`package js
+
+		type Object struct{}
+
+		// The prelude passes functions which may block to callAsync.
+		func callAsync(fn func() *Object) *Object {
+			return fn()
+		}
+
+		func notBlocking() *Object { return nil }
+
+		var byName = notBlocking`)
+	bt.assertBlocking(`callAsync`)
+}
+
+func TestBlocking_CallGraph_Generic(t *testing.T) {
+	enableCallGraph(t)
+	bt := newBlockingTest(t,
+		`package test
+
+		type Getter[T any] interface { Get() T }
+
+		type chanGetter[T any] chan T
+		func (c chanGetter[T]) Get() T { return <-c }
+
+		type valueGetter[T any] struct { v T }
+		func (g valueGetter[T]) Get() T { return g.v }
+
+		func get[T any](g Getter[T]) T {
+			return g.Get()
+		}
+
+		func main() {
+			get[int](chanGetter[int](nil))
+			get[string](valueGetter[string]{})
+		}`)
+	bt.assertBlockingInst(`pkg/test.get<int>`)
+	bt.assertNotBlockingInst(`pkg/test.get<string>`)
+}
+
+func TestEscape_PointerParams(t *testing.T) {
+	bt := newBlockingTest(t,
+		`package test
+
+		type counter int
+		func (c *counter) inc() { *c++ }
+		func (c *counter) get() int { return int(*c) }
+
+		var saved *counter
+		func (c *counter) save() { saved = c }
+
+		func incTwice(c *counter) { c.inc(); c.inc() }
+		func compare(c, d *counter) bool { return c == d || c == nil }
+		func recursive(c *counter, n int) { if n > 0 { recursive(c, n-1) } }
+
+		func saveIt(c *counter) { c.save() }
+		func returned(c *counter) *counter { return c }
+		func captured(c *counter) func() int { return func() int { return c.get() } }
+		func deferred(c *counter) { defer c.inc() }
+		func boxed(c *counter) any { return c }
+		func printed(c *counter) { println(*c, c) }
+
+		type pair struct{ a, b int }
+		func (p *pair) sum() int { return p.a + p.b }
+		func fieldPtr(p *pair) *int { return &p.a }
+		func fieldSum(p *pair) int { return p.sum() }`)
+
+	for _, name := range []string{`counter.inc`, `counter.get`, `incTwice`, `compare`, `recursive`, `printed`, `pair.sum`, `fieldSum`} {
+		bt.assertParamNotEscaping(name)
+	}
+	for _, name := range []string{`counter.save`, `saveIt`, `returned`, `captured`, `deferred`, `boxed`, `fieldPtr`} {
+		bt.assertParamEscaping(name)
+	}
+}
+
+func TestEscape_LocalAddrs(t *testing.T) {
+	bt := newBlockingTestWithOtherPackage(t,
+		`package test
+
+		import "pkg/other"
+
+		var kept *int
+		func keep(p *int) { kept = p }
+
+		func loop() {
+			for i := 0; i < 3; i++ {
+				var a, b, c, d int
+				other.Use(&a)
+				keep(&b)
+				other.Keep(&c)
+				go other.Use(&d)
+			}
+		}`,
+		`package other
+
+		var kept *int
+		func Use(p *int) int { return *p }
+		func Keep(p *int) { kept = p }`)
+
+	loop := bt.funcDecl(`loop`).Body.List[0].(*ast.ForStmt).Body
+	got := []string{}
+	for _, v := range EscapingObjects(loop, bt.pkgInfo.Info, bt.pkgInfo.AddrEscapes) {
+		got = append(got, v.Name())
+	}
+	if want := `[b c d]`; fmt.Sprint(got) != want {
+		t.Errorf(`Got escaping variables %v, want %v.`, got, want)
+	}
+
+	got = got[:0]
+	for _, v := range EscapingObjects(loop, bt.pkgInfo.Info, nil) {
+		got = append(got, v.Name())
+	}
+	if want := `[a b c d]`; fmt.Sprint(got) != want {
+		t.Errorf(`Got escaping variables %v without escape analysis, want %v.`, got, want)
+	}
+}
+
 type blockingTest struct {
 	f       *srctesting.Fixture
 	file    *ast.File
@@ -1628,6 +1868,10 @@ type blockingTest struct {
 }
 
 func newBlockingTest(t *testing.T, src string) *blockingTest {
+	return newBlockingTestInPackage(t, `pkg/test`, src)
+}
+
+func newBlockingTestInPackage(t *testing.T, importPath string, src string) *blockingTest {
 	f := srctesting.New(t)
 	tContext := types.NewContext()
 	tc := typeparams.Collector{
@@ -1636,7 +1880,7 @@ func newBlockingTest(t *testing.T, src string) *blockingTest {
 	}
 
 	file := f.Parse(`test.go`, src)
-	testInfo, testPkg := f.Check(`pkg/test`, file)
+	testInfo, testPkg := f.Check(importPath, file)
 	tc.Scan(testInfo, testPkg, file)
 	tc.Finish()
 
@@ -1653,6 +1897,12 @@ func newBlockingTest(t *testing.T, src string) *blockingTest {
 	}
 }
 
+func enableCallGraph(t *testing.T) {
+	prev := experiments.Env.CallGraph
+	experiments.Env.CallGraph = true
+	t.Cleanup(func() { experiments.Env.CallGraph = prev })
+}
+
 func newBlockingTestWithOtherPackage(t *testing.T, testSrc string, otherSrc string) *blockingTest {
 	f := srctesting.New(t)
 	tContext := types.NewContext()
@@ -1754,6 +2004,49 @@ func getFuncDeclName(fd *ast.FuncDecl) string {
 	return name
 }
 
+func (bt *blockingTest) funcDecl(funcName string) *ast.FuncDecl {
+	bt.f.T.Helper()
+	var decl *ast.FuncDecl
+	ast.Inspect(bt.file, func(n ast.Node) bool {
+		if f, ok := n.(*ast.FuncDecl); ok && getFuncDeclName(f) == funcName {
+			decl = f
+			return false
+		}
+		return decl == nil
+	})
+	if decl == nil {
+		bt.f.T.Fatalf(`Declaration of %q is not found in the AST.`, funcName)
+	}
+	return decl
+}
+
+// firstParamEscapes returns true if the receiver of the named method, or the
+// first parameter of the named function, escapes.
+func (bt *blockingTest) firstParamEscapes(funcName string) bool {
+	bt.f.T.Helper()
+	fn := bt.pkgInfo.Defs[bt.funcDecl(funcName).Name].(*types.Func)
+	sig := fn.Type().(*types.Signature)
+	param := sig.Recv()
+	if param == nil {
+		param = sig.Params().At(0)
+	}
+	return bt.pkgInfo.paramEscapes(param)
+}
+
+func (bt *blockingTest) assertParamEscaping(funcName string) {
+	bt.f.T.Helper()
+	if !bt.firstParamEscapes(funcName) {
+		bt.f.T.Errorf(`Got the pointer passed to %q as not escaping but expected it to escape.`, funcName)
+	}
+}
+
+func (bt *blockingTest) assertParamNotEscaping(funcName string) {
+	bt.f.T.Helper()
+	if bt.firstParamEscapes(funcName) {
+		bt.f.T.Errorf(`Got the pointer passed to %q as escaping but expected it not to escape.`, funcName)
+	}
+}
+
 func (bt *blockingTest) isTypesFuncBlocking(funcName string) bool {
 	bt.f.T.Helper()
 	var decl *ast.FuncDecl
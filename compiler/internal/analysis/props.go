@@ -0,0 +1,118 @@
+package analysis
+
+import (
+	"go/types"
+	"sort"
+
+	"github.com/gopherjs/gopherjs/compiler/typesutil"
+)
+
+// ManglePropNames assigns short JavaScript property names to struct fields and
+// unexported methods of all the given packages. The new names are stored in the
+// PropNames of each Info.
+//
+// Since struct types from different packages may be identical and interfaces
+// may be implemented by types from other packages, the names are assigned per
+// Go name across the whole program, so all the fields and methods named alike
+// get the same property name. The most used names get the shortest properties.
+//
+// Packages which import the js package or have hand-written JavaScript files,
+// given as jsPkgs, may refer to the properties by their original names in
+// JavaScript, so names declared there are kept everywhere, as are the exported
+// method names, which JavaScript may call. Short names never collide with the
+// kept names or the reserved ones.
+func ManglePropNames(allInfo []*Info, jsPkgs map[*types.Package]bool, reserved map[string]bool) {
+	uses := map[string]int{}
+	kept := map[string]bool{"_": true}
+	for _, info := range allInfo {
+		usesJS := jsPkgs[info.Pkg] || usesJSPackage(info.Pkg)
+		for _, obj := range info.Defs {
+			if !isProp(obj) {
+				continue
+			}
+			uses[obj.Name()]++
+			if usesJS || obj.Exported() && !isField(obj) {
+				kept[obj.Name()] = true
+			}
+		}
+		for _, obj := range info.Uses {
+			if isProp(obj) {
+				uses[obj.Name()]++
+			}
+		}
+	}
+
+	names := make([]string, 0, len(uses))
+	for name := range uses {
+		if !kept[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if uses[names[i]] != uses[names[j]] {
+			return uses[names[i]] > uses[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	propNames := make(map[string]string, len(names))
+	n := 0
+	for _, name := range names {
+		prop := shortPropName(n)
+		for kept[prop] || reserved[prop] {
+			n++
+			prop = shortPropName(n)
+		}
+		n++
+		propNames[name] = prop
+	}
+	for _, info := range allInfo {
+		info.PropNames = propNames
+	}
+}
+
+const (
+	propStartChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	propChars      = propStartChars + "0123456789_"
+)
+
+// shortPropName returns the n-th shortest JavaScript identifier.
+func shortPropName(n int) string {
+	name := []byte{propStartChars[n%len(propStartChars)]}
+	n /= len(propStartChars)
+	for n > 0 {
+		n--
+		name = append(name, propChars[n%len(propChars)])
+		n /= len(propChars)
+	}
+	return string(name)
+}
+
+func usesJSPackage(pkg *types.Package) bool {
+	if typesutil.IsJsPackage(pkg) {
+		return true
+	}
+	for _, imp := range pkg.Imports() {
+		if typesutil.IsJsPackage(imp) {
+			return true
+		}
+	}
+	return false
+}
+
+// isProp returns true if the object is a struct field or a method, which are
+// properties of the JavaScript values.
+func isProp(obj types.Object) bool {
+	switch obj := obj.(type) {
+	case *types.Var:
+		return obj.IsField()
+	case *types.Func:
+		return obj.Type().(*types.Signature).Recv() != nil
+	}
+	return false
+}
+
+func isField(obj types.Object) bool {
+	v, ok := obj.(*types.Var)
+	return ok && v.IsField()
+}
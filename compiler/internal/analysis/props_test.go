@@ -0,0 +1,47 @@
+package analysis
+
+import (
+	"go/types"
+	"testing"
+
+	"github.com/gopherjs/gopherjs/internal/srctesting"
+)
+
+func TestManglePropNames(t *testing.T) {
+	f := srctesting.New(t)
+	shapesInfo, shapes := f.Check(`example.com/shapes`, f.Parse(`shapes.go`, `
+		package shapes
+
+		type Circle struct{ radius float64 }
+
+		func (c Circle) Area() float64 { return c.radius * c.radius * 3 }
+		func (c Circle) scaled(by float64) Circle { return Circle{c.radius * by} }`))
+	f.Info = nil
+	canvasInfo, canvas := f.Check(`example.com/canvas`, f.Parse(`canvas.go`, `
+		package canvas
+
+		type Point struct{ x, y float64 }
+
+		func (p Point) moved(dx float64) Point { return Point{p.x + dx, p.y} }`))
+
+	allInfo := []*Info{{Info: shapesInfo, Pkg: shapes}, {Info: canvasInfo, Pkg: canvas}}
+	ManglePropNames(allInfo, map[*types.Package]bool{canvas: true}, map[string]bool{})
+
+	propNames := allInfo[0].PropNames
+	if _, mangled := propNames["radius"]; !mangled {
+		t.Errorf("radius is not mangled: %v", propNames)
+	}
+	if _, mangled := propNames["scaled"]; !mangled {
+		t.Errorf("scaled is not mangled: %v", propNames)
+	}
+	// Exported methods and the names declared in packages with hand-written
+	// JavaScript are kept.
+	for _, name := range []string{"Area", "x", "y", "moved"} {
+		if prop, mangled := propNames[name]; mangled {
+			t.Errorf("%s is mangled to %s: %v", name, prop, propNames)
+		}
+	}
+	if allInfo[1].PropNames == nil {
+		t.Errorf("PropNames of the canvas package are not set")
+	}
+}
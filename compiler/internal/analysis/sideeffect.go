@@ -35,3 +35,36 @@ func (v *hasSideEffectVisitor) Visit(node ast.Node) (w ast.Visitor) {
 	}
 	return v
 }
+
+// HasExternalEffect returns true if the code may have effects observable
+// outside of the given package: in addition to the side effects detected by
+// HasSideEffect, these are channel sends, ranging over a channel and any access
+// to a package-level variable of another package.
+//
+// It is used to decide whether a package's init function has to run even if
+// nothing else in the package is used.
+func HasExternalEffect(n ast.Node, pkg *types.Package, info *types.Info) bool {
+	if HasSideEffect(n, info) {
+		return true
+	}
+	hasEffect := false
+	ast.Inspect(n, func(n ast.Node) bool {
+		if hasEffect {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.SendStmt:
+			hasEffect = true
+		case *ast.RangeStmt:
+			if t := info.TypeOf(n.X); t != nil {
+				_, hasEffect = t.Underlying().(*types.Chan)
+			}
+		case *ast.Ident:
+			if o, isVar := info.Uses[n].(*types.Var); isVar && !o.IsField() && o.Pkg() != pkg {
+				hasEffect = true
+			}
+		}
+		return !hasEffect
+	})
+	return hasEffect
+}
@@ -44,3 +44,12 @@ func (c *Collector) DeclareDCEDep(o types.Object, tNest, tArgs []types.Type) {
 		c.dce.addDep(o, tNest, tArgs)
 	}
 }
+
+// DeclareDCEMethodUse records that the code that is currently being transpiled
+// may call the given method by name, e.g. through an interface, without
+// depending on the method's receiver type.
+func (c *Collector) DeclareDCEMethodUse(m *types.Func) {
+	if c.dce != nil {
+		c.dce.addMethodUse(m)
+	}
+}
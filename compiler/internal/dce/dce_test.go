@@ -1178,6 +1178,407 @@ func Test_Selector_SpecificMethods(t *testing.T) {
 	}
 }
 
+func Test_Selector_EliminateMethods(t *testing.T) {
+	objects := parseObjects(t,
+		`package pratchett
+
+		type rincewind struct{}
+		func (r rincewind) Run() {}
+		func (r rincewind) hide() {}
+
+		type Vimes struct{}
+		func (v Vimes) Run() {}
+		func (v Vimes) Read() {}
+
+		type Runner interface{ Run() }`)
+
+	var (
+		rincewind     = quickTestDecl(objects[0])
+		rincewindRun  = quickTestDecl(objects[2])
+		rincewindHide = quickTestDecl(objects[4])
+		vimes         = quickTestDecl(objects[5])
+		vimesRun      = quickTestDecl(objects[7])
+		vimesRead     = quickTestDecl(objects[9])
+		runnerRun     = objects[len(objects)-1].(*types.Func)
+		vetinari      = quickTestDecl(quickVar(testPackage(`pratchett`), `Vetinari`))
+	)
+	allDecls := []*testDecl{rincewind, rincewindRun, rincewindHide, vimes, vimesRun, vimesRead, vetinari}
+	vetinari.Dce().SetAsAlive()
+
+	c := Collector{}
+	for _, method := range []*testDecl{rincewindRun, rincewindHide} {
+		c.CollectDCEDeps(method, func() {
+			c.DeclareDCEDep(rincewind.obj, nil, nil)
+		})
+	}
+	for _, method := range []*testDecl{vimesRun, vimesRead} {
+		c.CollectDCEDeps(method, func() {
+			c.DeclareDCEDep(vimes.obj, nil, nil)
+		})
+	}
+
+	reflectPkg := types.NewPackage(`reflect`, `reflect`)
+	value := types.NewNamed(types.NewTypeName(token.NoPos, reflectPkg, `Value`, nil), types.NewStruct(nil, nil), nil)
+	methodByName := types.NewFunc(token.NoPos, reflectPkg, `MethodByName`,
+		types.NewSignatureType(types.NewVar(token.NoPos, reflectPkg, `v`, value), nil, nil,
+			types.NewTuple(types.NewVar(token.NoPos, reflectPkg, `name`, types.Typ[types.String])),
+			types.NewTuple(types.NewVar(token.NoPos, reflectPkg, ``, value)), false))
+
+	// The js wrapper constructors only reach the methods through the helpers.
+	jsPkg := types.NewPackage(`github.com/gopherjs/gopherjs/js`, `js`)
+	jsFunc := func(name string) *testDecl {
+		return quickTestDecl(types.NewFunc(token.NoPos, jsPkg, name, types.NewSignatureType(nil, nil, nil, nil, nil, false)))
+	}
+	var (
+		makeWrapper           = jsFunc(`makeWrapper`)
+		makeAsyncWrapper      = jsFunc(`MakeAsyncWrapper`)
+		makeFullWrapper       = jsFunc(`makeFullWrapper`)
+		cachedWrapper         = jsFunc(`cachedWrapper`)
+		makeCachedFullWrapper = jsFunc(`MakeCachedFullWrapper`)
+	)
+	allDecls = append(allDecls, makeWrapper, makeAsyncWrapper, makeFullWrapper, cachedWrapper, makeCachedFullWrapper)
+	c.CollectDCEDeps(makeAsyncWrapper, func() {
+		c.DeclareDCEDep(makeWrapper.obj, nil, nil)
+	})
+	c.CollectDCEDeps(makeCachedFullWrapper, func() {
+		c.DeclareDCEDep(cachedWrapper.obj, nil, nil)
+		c.DeclareDCEDep(makeFullWrapper.obj, nil, nil)
+	})
+
+	tests := []struct {
+		name  string
+		uses  []*types.Func // which methods vetinari may call
+		calls []*testDecl   // which functions vetinari may call
+		keep  []string      // which method names are kept regardless
+		want  []*testDecl   // which decls should be determined as alive
+	}{
+		{
+			name: `no calls`,
+			want: []*testDecl{rincewind, vimes, vetinari},
+		},
+		{
+			name: `interface call`,
+			uses: []*types.Func{runnerRun},
+			want: []*testDecl{rincewind, rincewindRun, vimes, vimesRun, vetinari},
+		},
+		{
+			name: `direct call`,
+			uses: []*types.Func{vimesRead.obj.(*types.Func)},
+			want: []*testDecl{rincewind, vimes, vimesRead, vetinari},
+		},
+		{
+			name: `kept method`,
+			keep: []string{`Read`},
+			want: []*testDecl{rincewind, vimes, vimesRead, vetinari},
+		},
+		{
+			name: `unexported method`,
+			uses: []*types.Func{rincewindHide.obj.(*types.Func)},
+			want: []*testDecl{rincewind, rincewindHide, vimes, vetinari},
+		},
+		{
+			name: `reflection`,
+			uses: []*types.Func{methodByName},
+			want: []*testDecl{rincewind, rincewindRun, vimes, vimesRun, vimesRead, vetinari},
+		},
+		{
+			name:  `async wrapper`,
+			calls: []*testDecl{makeAsyncWrapper},
+			want:  []*testDecl{rincewind, rincewindRun, vimes, vimesRun, vimesRead, vetinari, makeAsyncWrapper, makeWrapper},
+		},
+		{
+			name:  `cached full wrapper`,
+			calls: []*testDecl{makeCachedFullWrapper},
+			want:  []*testDecl{rincewind, rincewindRun, vimes, vimesRun, vimesRead, vetinari, makeCachedFullWrapper, cachedWrapper, makeFullWrapper},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			*vetinari.Dce() = Info{}
+			vetinari.Dce().SetName(vetinari.obj, nil, nil)
+			vetinari.Dce().SetAsAlive()
+			c.CollectDCEDeps(vetinari, func() {
+				for _, m := range tt.uses {
+					if m.Exported() {
+						c.DeclareDCEMethodUse(m)
+					} else {
+						c.DeclareDCEDep(m, nil, nil)
+					}
+				}
+				for _, f := range tt.calls {
+					c.DeclareDCEDep(f.obj, nil, nil)
+				}
+				c.DeclareDCEDep(rincewind.obj, nil, nil)
+				c.DeclareDCEDep(vimes.obj, nil, nil)
+			})
+
+			s := Selector[*testDecl]{}
+			s.EliminateMethods(tt.keep...)
+			for _, decl := range allDecls {
+				s.Include(decl, false)
+			}
+			selected := s.AliveDecls()
+			for _, decl := range tt.want {
+				if _, ok := selected[decl]; !ok {
+					t.Errorf(`expected %q to be alive`, decl.obj.String())
+				}
+				delete(selected, decl)
+			}
+			for decl := range selected {
+				t.Errorf(`expected %q to be dead`, decl.obj.String())
+			}
+		})
+	}
+}
+
+func Test_Selector_Retention(t *testing.T) {
+	pkg := testPackage(`adams`)
+	arthur := quickTestDecl(quickVar(pkg, `Arthur`))
+	ford := quickTestDecl(quickVar(pkg, `Ford`))
+	zaphod := quickTestDecl(quickVar(pkg, `Zaphod`))
+	marvin := quickTestDecl(quickVar(pkg, `Marvin`))
+	trillian := quickTestDecl(quickVar(pkg, `Trillian`))
+
+	c := Collector{}
+	c.CollectDCEDeps(arthur, func() {
+		c.DeclareDCEDep(ford.obj, nil, nil)
+	})
+	c.CollectDCEDeps(ford, func() {
+		c.DeclareDCEDep(zaphod.obj, nil, nil)
+	})
+	arthur.Dce().SetAsAlive()
+
+	s := Selector[*testDecl]{}
+	for _, decl := range []*testDecl{arthur, ford, zaphod, trillian} {
+		s.Include(decl, false)
+	}
+	s.Include(marvin, true)
+	s.AliveDecls()
+
+	r, ok := s.Retention(arthur)
+	equal(t, ok, true)
+	equal(t, r.Root, RootAlive)
+
+	r, ok = s.Retention(ford)
+	equal(t, ok, true)
+	equal(t, r.Root, ``)
+	equal(t, r.KeptBy, arthur)
+	equal(t, r.Dep, ford.Dce().objectFilter)
+
+	r, ok = s.Retention(zaphod)
+	equal(t, ok, true)
+	equal(t, r.KeptBy, ford)
+
+	r, ok = s.Retention(marvin)
+	equal(t, ok, true)
+	equal(t, r.Root, RootLinkname)
+
+	_, ok = s.Retention(trillian)
+	equal(t, ok, false)
+}
+
+func Test_Selector_Chain(t *testing.T) {
+	pkg := testPackage(`herbert`)
+	paul := quickTestDecl(quickVar(pkg, `Paul`))
+	jessica := quickTestDecl(quickVar(pkg, `Jessica`))
+	stilgar := quickTestDecl(quickVar(pkg, `Stilgar`))
+	chani := quickTestDecl(quickVar(pkg, `Chani`))
+	leto := quickTestDecl(quickVar(pkg, `Leto`))
+
+	c := Collector{}
+	c.CollectDCEDeps(paul, func() {
+		c.DeclareDCEDep(jessica.obj, nil, nil)
+		c.DeclareDCEDep(chani.obj, nil, nil)
+	})
+	c.CollectDCEDeps(jessica, func() {
+		c.DeclareDCEDep(stilgar.obj, nil, nil)
+	})
+	c.CollectDCEDeps(stilgar, func() {
+		c.DeclareDCEDep(chani.obj, nil, nil)
+	})
+	paul.Dce().SetAsAlive()
+
+	s := Selector[*testDecl]{}
+	for _, decl := range []*testDecl{stilgar, jessica, chani, leto, paul} {
+		s.Include(decl, false)
+	}
+	s.AliveDecls()
+
+	equalSlices(t, s.Chain(paul), []*testDecl{paul})
+	equalSlices(t, s.Chain(stilgar), []*testDecl{paul, jessica, stilgar})
+	// Chani is reachable both directly and via Jessica and Stilgar.
+	equalSlices(t, s.Chain(chani), []*testDecl{paul, chani})
+	equalSlices(t, s.Chain(leto), nil)
+}
+
+func Test_Selector_Packages(t *testing.T) {
+	pkgMain := testPackage(`main`)
+	pkgA := testPackage(`arrakis`)
+	pkgB := testPackage(`bene`)
+	pkgC := testPackage(`caladan`)
+	pkgD := testPackage(`dune`)
+	pkgE := testPackage(`ecaz`)
+
+	mainFn := quickTestDecl(quickVar(pkgMain, `main`))
+	mainFn.Dce().SetAsAlive()
+	importDecl := func(pkg *types.Package) *testDecl {
+		d := &testDecl{}
+		d.Dce().SetAsImport(pkg.Path())
+		return d
+	}
+	initDecl := func(pkg *types.Package) *testDecl {
+		d := quickTestDecl(quickVar(pkg, `init`))
+		d.Dce().SetAsPackageInit()
+		return d
+	}
+	importA, importB, importC, importE := importDecl(pkgA), importDecl(pkgB), importDecl(pkgC), importDecl(pkgE)
+
+	// Arrakis is used by main and imports Dune, which is not used at all.
+	spice := quickTestDecl(quickVar(pkgA, `Spice`))
+	initA := initDecl(pkgA)
+	importD := importDecl(pkgD)
+	sand := quickTestDecl(quickVar(pkgD, `Sand`))
+	// Bene is not used and its init has no side effects.
+	witch := quickTestDecl(quickVar(pkgB, `Witch`))
+	initB := initDecl(pkgB)
+	// Caladan is not used, but has a variable with side effects.
+	sea := quickTestDecl(quickVar(pkgC, `Sea`))
+	sea.Dce().SetAsAlive()
+	// Ecaz is not used, but kept explicitly.
+	initE := initDecl(pkgE)
+
+	c := Collector{}
+	c.CollectDCEDeps(mainFn, func() {
+		c.DeclareDCEDep(spice.obj, nil, nil)
+	})
+
+	s := Selector[*testDecl]{}
+	for pkg, decls := range map[*types.Package][]*testDecl{
+		pkgMain: {mainFn, importA, importB, importC, importE},
+		pkgA:    {spice, initA, importD},
+		pkgB:    {witch, initB},
+		pkgC:    {sea},
+		pkgD:    {sand},
+		pkgE:    {initE},
+	} {
+		for _, decl := range decls {
+			s.IncludeInPackage(pkg.Path(), decl, false)
+		}
+	}
+	s.KeepPackage(pkgE.Path())
+	selection := s.AliveDecls()
+
+	for _, pkg := range []*types.Package{pkgMain, pkgA, pkgC, pkgE} {
+		equal(t, s.PackageAlive(pkg.Path()), true)
+	}
+	for _, pkg := range []*types.Package{pkgB, pkgD} {
+		equal(t, s.PackageAlive(pkg.Path()), false)
+	}
+
+	got := []*testDecl{}
+	for _, decl := range []*testDecl{mainFn, importA, importB, importC, importE, spice, initA, importD, sand, witch, initB, sea, initE} {
+		if _, ok := selection[decl]; ok {
+			got = append(got, decl)
+		}
+	}
+	equalSlices(t, got, []*testDecl{mainFn, importA, importC, importE, spice, initA, sea, initE})
+
+	equalSlices(t, s.Chain(initA), []*testDecl{mainFn, spice, initA})
+	retention, _ := s.Retention(initA)
+	equal(t, retention.Dep, `package path/to/arrakis`)
+	retention, _ = s.Retention(initE)
+	equal(t, retention.Root, RootPackage)
+}
+
+func Test_Selector_Links(t *testing.T) {
+	pkgMain := testPackage(`main`)
+	pkgRef := testPackage(`giedi`)
+	pkgImpl := testPackage(`ix`)
+
+	mainFn := quickTestDecl(quickVar(pkgMain, `main`))
+	mainFn.Dce().SetAsAlive()
+	feyd := quickTestDecl(quickVar(pkgRef, `Feyd`))
+	rabban := quickTestDecl(quickVar(pkgRef, `Rabban`))
+	navigator := quickTestDecl(quickVar(pkgImpl, `navigator`))
+	heighliner := quickTestDecl(quickVar(pkgImpl, `heighliner`))
+
+	c := Collector{}
+	c.CollectDCEDeps(mainFn, func() {
+		c.DeclareDCEDep(feyd.obj, nil, nil)
+	})
+
+	s := Selector[*testDecl]{}
+	s.IncludeInPackage(pkgMain.Path(), mainFn, false)
+	s.IncludeInPackage(pkgRef.Path(), feyd, false)
+	s.IncludeInPackage(pkgRef.Path(), rabban, false)
+	s.IncludeInPackage(pkgImpl.Path(), navigator, true)
+	s.IncludeInPackage(pkgImpl.Path(), heighliner, true)
+	s.IncludeLink(feyd, navigator)
+	s.IncludeLink(rabban, heighliner)
+	selection := s.AliveDecls()
+
+	_, navigatorAlive := selection[navigator]
+	equal(t, navigatorAlive, true)
+	_, heighlinerAlive := selection[heighliner]
+	equal(t, heighlinerAlive, false)
+	equal(t, s.PackageAlive(pkgImpl.Path()), true)
+	equalSlices(t, s.Chain(navigator), []*testDecl{mainFn, feyd, navigator})
+	retention, _ := s.Retention(navigator)
+	equal(t, retention.Dep, LinknameDep)
+}
+
+func Test_Selector_Condition(t *testing.T) {
+	pkgMain := testPackage(`main`)
+	pkgPalantir := testPackage(`palantir`)
+
+	mainFn := quickTestDecl(quickVar(pkgMain, `main`))
+	mainFn.Dce().SetAsAlive()
+	orthanc := quickTestDecl(types.NewTypeName(token.NoPos, pkgMain, `Orthanc`, nil))
+	minasTirith := quickTestDecl(types.NewTypeName(token.NoPos, pkgMain, `MinasTirith`, nil))
+	gaze := quickTestDecl(types.NewFunc(token.NoPos, pkgPalantir, `Gaze`, types.NewSignatureType(nil, nil, nil, nil, nil, false)))
+
+	// The visions of each type are only needed when the type is alive and
+	// palantir.Gaze is called.
+	vision := func(tower *testDecl) *testDecl {
+		d := quickTestDecl(tower.obj)
+		d.Dce().SetCondition(pkgPalantir.Path(), `Gaze`)
+		return d
+	}
+	orthancVision := vision(orthanc)
+	minasTirithVision := vision(minasTirith)
+	errorMatches(t, capturePanic(t, func() {
+		orthancVision.Dce().SetCondition(pkgPalantir.Path(), `Gaze`)
+	}), `^may only set a condition once for a named declaration without a method name.*`)
+
+	c := Collector{}
+	c.CollectDCEDeps(mainFn, func() {
+		c.DeclareDCEDep(orthanc.obj, nil, nil)
+		c.DeclareDCEDep(minasTirith.obj, nil, nil)
+	})
+
+	s := Selector[*testDecl]{}
+	for _, decl := range []*testDecl{mainFn, orthanc, minasTirith, gaze, orthancVision, minasTirithVision} {
+		s.Include(decl, false)
+	}
+	selection := s.AliveDecls()
+	_, orthancVisionAlive := selection[orthancVision]
+	equal(t, orthancVisionAlive, false)
+
+	c.CollectDCEDeps(orthanc, func() {
+		c.DeclareDCEDep(gaze.obj, nil, nil)
+	})
+	s = Selector[*testDecl]{}
+	for _, decl := range []*testDecl{mainFn, orthanc, minasTirith, gaze, orthancVision, minasTirithVision} {
+		s.Include(decl, false)
+	}
+	selection = s.AliveDecls()
+	_, orthancVisionAlive = selection[orthancVision]
+	equal(t, orthancVisionAlive, true)
+	_, minasTirithVisionAlive := selection[minasTirithVision]
+	equal(t, minasTirithVisionAlive, true)
+}
+
 type testDecl struct {
 	obj types.Object // should match the object used in Dce.SetName when set
 	dce Info
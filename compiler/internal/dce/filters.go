@@ -49,6 +49,33 @@ func getFilters(o types.Object, nestTArgs, tArgs []types.Type) (objectFilter, me
 	return
 }
 
+// isMethod returns true if the object is a method, including the methods of
+// interfaces.
+func isMethod(o types.Object) bool {
+	f, ok := o.(*types.Func)
+	return ok && f.Type().(*types.Signature).Recv() != nil
+}
+
+// reflectsMethods returns true if the object gives access to the methods of
+// values without naming them in the code: the method lookups of the reflect
+// package and the wrappers exposing all exported methods to JavaScript.
+//
+// All the js wrapper constructors, such as MakeWrapper, MakeAsyncWrapper and
+// MakeCachedFullWrapper, depend on one of the unexported helpers which walk
+// the methods of the wrapped value, so matching the helpers covers them all.
+func reflectsMethods(o types.Object) bool {
+	if o.Pkg() == nil {
+		return false
+	}
+	switch o.Pkg().Path() {
+	case `reflect`:
+		return isMethod(o) && (o.Name() == `Method` || o.Name() == `MethodByName`)
+	case `github.com/gopherjs/gopherjs/js`:
+		return o.Name() == `makeWrapper` || o.Name() == `makeFullWrapper`
+	}
+	return false
+}
+
 // getObjectFilter returns the object filter that functions as the primary
 // name when determining if a declaration is alive or not.
 // See [naming design] for more information.
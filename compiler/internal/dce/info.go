@@ -27,9 +27,31 @@ type Info struct {
 	// See ./README.md for more information.
 	methodFilter string
 
+	// importPath is the import path of the package imported by the
+	// declaration, see SetAsImport.
+	importPath string
+
+	// pkgInit indicates if the declaration is a part of its package's
+	// initialization, see SetAsPackageInit.
+	pkgInit bool
+
 	// Set of fully qualified (including package path) DCE symbol
 	// and/or method names that this DCE declaration depends on.
 	deps map[string]struct{}
+
+	// exportedMethod is the name of the method, if the declaration is an
+	// exported method. Exported methods can be called through interfaces of
+	// any package, so they are matched with calls by name only.
+	// See Selector.EliminateMethods.
+	exportedMethod string
+
+	// Set of names of the exported methods this declaration may call,
+	// directly or through an interface.
+	methodUses map[string]struct{}
+
+	// reflectsMethods indicates if the declaration may call methods without
+	// naming them in the code, e.g. with reflect.Value.MethodByName.
+	reflectsMethods bool
 }
 
 // String gets a human-readable representation of the DCE info.
@@ -41,6 +63,12 @@ func (d *Info) String() string {
 	if d.unnamed() {
 		tags += `[unnamed] `
 	}
+	if d.importPath != `` {
+		tags += `[import ` + d.importPath + `] `
+	}
+	if d.pkgInit {
+		tags += `[package init] `
+	}
 	names := []string{}
 	if len(d.objectFilter) > 0 {
 		names = append(names, d.objectFilter+` `)
@@ -73,6 +101,26 @@ func (d *Info) SetAsAlive() {
 	d.alive = true
 }
 
+// SetAsImport marks the declaration as importing the package with the given
+// import path, i.e. initializing it before the importing package.
+//
+// When the Selector is aware of packages, such a declaration is alive only if
+// the imported package is alive and either the importing package is alive too,
+// or the imported package has to be initialized for its side effects.
+func (d *Info) SetAsImport(importPath string) {
+	d.importPath = importPath
+}
+
+// SetAsPackageInit marks the declaration as a part of its package's
+// initialization that has no effects outside of the package, like an init()
+// function that only populates package-level variables.
+//
+// When the Selector is aware of packages, such a declaration is alive only if
+// any other declaration of its package is alive.
+func (d *Info) SetAsPackageInit() {
+	d.pkgInit = true
+}
+
 // SetName sets the name used by DCE to represent the declaration
 // this DCE info is attached to.
 //
@@ -86,6 +134,19 @@ func (d *Info) SetName(o types.Object, tNest, tArgs []types.Type) {
 
 	// Determine name(s) for DCE.
 	d.objectFilter, d.methodFilter = getFilters(o, tNest, tArgs)
+	if isMethod(o) && o.Exported() {
+		d.exportedMethod = o.Name()
+	}
+}
+
+// SetCondition makes the declaration named with SetName alive only if the
+// package-level function with the given package path and name is depended on
+// by a live declaration too, e.g. code only used by that function.
+func (d *Info) SetCondition(pkgPath, funcName string) {
+	if d.objectFilter == `` || d.methodFilter != `` {
+		panic(fmt.Errorf(`may only set a condition once for a named declaration without a method name, %s`, d.String()))
+	}
+	d.methodFilter = pkgPath + `.` + funcName
 }
 
 // addDep add a declaration dependencies used by DCE
@@ -94,6 +155,25 @@ func (d *Info) addDep(o types.Object, tNest, tArgs []types.Type) {
 	objectFilter, methodFilter := getFilters(o, tNest, tArgs)
 	d.addDepName(objectFilter)
 	d.addDepName(methodFilter)
+	if isMethod(o) {
+		d.addMethodUse(o.(*types.Func))
+	} else if reflectsMethods(o) {
+		d.reflectsMethods = true
+	}
+}
+
+// addMethodUse records that the declaration may call the given method.
+func (d *Info) addMethodUse(m *types.Func) {
+	if reflectsMethods(m) {
+		d.reflectsMethods = true
+	}
+	if !m.Exported() {
+		return // Unexported methods are matched by their method filters.
+	}
+	if d.methodUses == nil {
+		d.methodUses = make(map[string]struct{})
+	}
+	d.methodUses[m.Name()] = struct{}{}
 }
 
 // addDepName adds a declaration dependency by name.
@@ -106,6 +186,17 @@ func (d *Info) addDepName(depName string) {
 	}
 }
 
+// getMethodUses gets the names of the exported methods the declaration may
+// call, sorted by name.
+func (d *Info) getMethodUses() []string {
+	uses := make([]string, 0, len(d.methodUses))
+	for name := range d.methodUses {
+		uses = append(uses, name)
+	}
+	sort.Strings(uses)
+	return uses
+}
+
 // getDeps gets the dependencies for the declaration sorted by name.
 func (id *Info) getDeps() []string {
 	deps := make([]string, len(id.deps))
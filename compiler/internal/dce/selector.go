@@ -1,5 +1,10 @@
 package dce
 
+import (
+	"sort"
+	"strings"
+)
+
 // DeclConstraint is type constraint for any code declaration that has
 // dead-code elimination (DCE) information attached to it and will be
 // used in a set.
@@ -12,8 +17,73 @@ type DeclConstraint interface {
 type Selector[D DeclConstraint] struct {
 	byFilter map[string][]*declInfo[D]
 
-	// A queue of live decls to find other live decls.
+	// A queue of live decls to find other live decls. The queue is processed
+	// in the first-in-first-out order, so that the recorded retention reasons
+	// form the shortest chains from the initially alive decls.
 	pendingDecls []D
+
+	// The reason each decl in or past the live queue was found to be alive.
+	retention map[D]Retention[D]
+
+	// The go:linkname implementations kept alive by each referencing decl.
+	links map[D][]D
+
+	// Exported method elimination state, see EliminateMethods.
+	eliminateMethods bool
+	keptMethods      []string
+	methodsReflected bool
+
+	// Package-level DCE state for the decls included with IncludeInPackage,
+	// with packages listed in the order they were first seen.
+	pkgOf    map[D]string
+	packages map[string]*pkgInfo[D]
+	pkgOrder []string
+}
+
+type pkgInfo[D DeclConstraint] struct {
+	// keep indicates that the package is alive regardless of its decls.
+	keep bool
+	// effectful indicates that the package has to be initialized for its side
+	// effects, because it has decls alive on their own or imports such a
+	// package.
+	effectful bool
+	// alive indicates that any decl of the package was found to be alive.
+	alive bool
+	// Decls marked with SetAsPackageInit and SetAsImport.
+	inits   []D
+	imports []D
+}
+
+// Reasons for a declaration to be alive on its own, see Retention.Root.
+const (
+	// RootAlive is used for declarations marked as alive, e.g. entry points.
+	RootAlive = `alive`
+	// RootUnnamed is used for declarations without DCE information.
+	RootUnnamed = `unnamed`
+	// RootLinkname is used for implementations of go:linkname directives
+	// included with Include.
+	RootLinkname = `go:linkname`
+	// RootPackage is used for declarations of packages kept alive regardless
+	// of their declarations, see KeepPackage.
+	RootPackage = `package`
+)
+
+// LinknameDep is the Retention.Dep of the go:linkname implementations kept
+// alive by the declarations referencing them, see IncludeLink.
+const LinknameDep = `go:linkname`
+
+// Retention describes why a declaration was kept alive by the Selector.
+type Retention[D DeclConstraint] struct {
+	// Root is the reason for the declaration to be alive on its own, one of
+	// RootAlive, RootUnnamed, RootLinkname or RootPackage. Empty if the declaration was kept
+	// alive by another one.
+	Root string
+	// KeptBy is the live declaration that depends on this one.
+	KeptBy D
+	// Dep is the DCE name through which KeptBy depends on this declaration,
+	// or LinknameDep if KeptBy references this declaration with a go:linkname
+	// directive.
+	Dep string
 }
 
 type declInfo[D DeclConstraint] struct {
@@ -23,6 +93,10 @@ type declInfo[D DeclConstraint] struct {
 }
 
 // Include will add a new declaration to be checked as alive or not.
+//
+// Declarations marked with SetAsImport or SetAsPackageInit are always alive
+// when included this way, use IncludeInPackage to eliminate them along with
+// their packages.
 func (s *Selector[D]) Include(decl D, implementsLink bool) {
 	if s.byFilter == nil {
 		s.byFilter = make(map[string][]*declInfo[D])
@@ -30,32 +104,235 @@ func (s *Selector[D]) Include(decl D, implementsLink bool) {
 
 	dce := decl.Dce()
 
-	if dce.isAlive() {
-		s.pendingDecls = append(s.pendingDecls, decl)
+	if dce.isAlive() || dce.pkgInit {
+		root := RootAlive
+		if dce.unnamed() {
+			root = RootUnnamed
+		}
+		s.enqueue(decl, Retention[D]{Root: root})
 		return
 	}
 
 	if implementsLink {
-		s.pendingDecls = append(s.pendingDecls, decl)
+		s.enqueue(decl, Retention[D]{Root: RootLinkname})
 	}
 
-	info := &declInfo[D]{decl: decl}
+	info := &declInfo[D]{
+		decl:         decl,
+		objectFilter: dce.objectFilter,
+		methodFilter: dce.methodFilter,
+	}
+	if s.eliminateMethods && dce.exportedMethod != `` && info.objectFilter != `` {
+		info.methodFilter = methodDep(dce.exportedMethod)
+	}
+	s.addDeclInfo(info)
+}
 
-	if dce.objectFilter != `` {
-		info.objectFilter = dce.objectFilter
-		s.byFilter[info.objectFilter] = append(s.byFilter[info.objectFilter], info)
+// EliminateMethods enables the elimination of exported methods, which are
+// otherwise alive along with their receiver type, since they may be called
+// through interfaces. With it, an exported method is alive only if its
+// receiver type is alive and any live declaration may call a method with the
+// same name, directly or through an interface.
+//
+// The methods with the given names are assumed to be called, e.g. by
+// hand-written JavaScript. Once any live declaration reflects on method sets,
+// e.g. with reflect.Value.MethodByName, all exported methods of live types are
+// kept alive, same as without this option.
+//
+// This should be called before any declarations are included.
+func (s *Selector[D]) EliminateMethods(keep ...string) {
+	s.eliminateMethods = true
+	s.keptMethods = keep
+}
+
+// methodDep is the DCE name satisfied once any live declaration may call an
+// exported method with the given name.
+func methodDep(name string) string {
+	return `method ` + name
+}
+
+// satisfyAllMethods keeps all exported methods of live types alive, because
+// the given live declaration may call any of them.
+func (s *Selector[D]) satisfyAllMethods(d D) {
+	deps := []string{}
+	for dep := range s.byFilter {
+		if strings.HasPrefix(dep, methodDep(``)) {
+			deps = append(deps, dep)
+		}
+	}
+	sort.Strings(deps)
+	for _, dep := range deps {
+		s.satisfy(dep, Retention[D]{KeptBy: d, Dep: dep})
+	}
+}
+
+// IncludeInPackage will add a new declaration of the package with the given
+// import path to be checked as alive or not.
+//
+// A package is alive if any of its declarations are alive. The declarations
+// marked with SetAsPackageInit are alive only if their package is alive, and
+// the ones marked with SetAsImport only if the imported package is alive and
+// either the importing package is alive or the imported one has to be
+// initialized for its side effects. Thus a package, whose declarations are
+// all dead and whose initialization has no side effects, is eliminated as a
+// whole.
+//
+// Unlike with Include, a go:linkname implementation isn't alive on its own, but
+// only if a live declaration references it, see IncludeLink. Since such
+// references don't follow the import graph, the implementing package is always
+// initialized once it is alive.
+func (s *Selector[D]) IncludeInPackage(pkgPath string, decl D, implementsLink bool) {
+	pkg := s.pkg(pkgPath)
+	if s.pkgOf == nil {
+		s.pkgOf = make(map[D]string)
 	}
+	s.pkgOf[decl] = pkgPath
 
-	if dce.methodFilter != `` {
-		info.methodFilter = dce.methodFilter
+	dce := decl.Dce()
+	switch {
+	case dce.importPath != ``:
+		pkg.imports = append(pkg.imports, decl)
+		return
+	case dce.pkgInit:
+		pkg.inits = append(pkg.inits, decl)
+		return
+	case dce.isAlive() || implementsLink:
+		pkg.effectful = true
+	}
+	s.Include(decl, false)
+}
+
+// IncludeLink records that the reference declaration gets its implementation
+// via a go:linkname directive, so the implementation is alive whenever the
+// reference is alive.
+func (s *Selector[D]) IncludeLink(reference, implementation D) {
+	if s.links == nil {
+		s.links = make(map[D][]D)
+	}
+	s.links[reference] = append(s.links[reference], implementation)
+}
+
+// KeepPackage marks the package with the given import path as alive
+// regardless of its declarations, e.g. because the package has hand-written
+// JavaScript code that is not tracked by DCE.
+func (s *Selector[D]) KeepPackage(pkgPath string) {
+	s.pkg(pkgPath).keep = true
+}
+
+// PackageAlive returns true if the package with the given import path has been
+// found to be alive. This should only be called after AliveDecls.
+// Returns false for packages without any declarations included with
+// IncludeInPackage.
+func (s *Selector[D]) PackageAlive(pkgPath string) bool {
+	pkg, ok := s.packages[pkgPath]
+	return ok && pkg.alive
+}
+
+func (s *Selector[D]) pkg(pkgPath string) *pkgInfo[D] {
+	if s.packages == nil {
+		s.packages = make(map[string]*pkgInfo[D])
+	}
+	pkg, ok := s.packages[pkgPath]
+	if !ok {
+		pkg = &pkgInfo[D]{}
+		s.packages[pkgPath] = pkg
+		s.pkgOrder = append(s.pkgOrder, pkgPath)
+	}
+	return pkg
+}
+
+// packageDep is the DCE name satisfied once the package with the given import
+// path is alive. It can't collide with object names, which have no spaces.
+func packageDep(pkgPath string) string {
+	return `package ` + pkgPath
+}
+
+// includePackages registers the package init and import decls under the
+// names of the packages they depend on and marks the kept packages as alive.
+func (s *Selector[D]) includePackages() {
+	if s.byFilter == nil {
+		s.byFilter = make(map[string][]*declInfo[D])
+	}
+
+	// Propagate the need to be initialized for side effects to the importers.
+	importers := map[string][]string{}
+	queue := []string{}
+	for _, path := range s.pkgOrder {
+		pkg := s.packages[path]
+		for _, d := range pkg.imports {
+			imported := d.Dce().importPath
+			importers[imported] = append(importers[imported], path)
+		}
+		if pkg.keep {
+			pkg.effectful = true
+		}
+		if pkg.effectful {
+			queue = append(queue, path)
+		}
+	}
+	for len(queue) != 0 {
+		path := queue[0]
+		queue = queue[1:]
+		for _, importer := range importers[path] {
+			if pkg := s.packages[importer]; !pkg.effectful {
+				pkg.effectful = true
+				queue = append(queue, importer)
+			}
+		}
+	}
+
+	for _, path := range s.pkgOrder {
+		pkg := s.packages[path]
+		for _, d := range pkg.inits {
+			s.addDeclInfo(&declInfo[D]{decl: d, objectFilter: packageDep(path)})
+		}
+		for _, d := range pkg.imports {
+			imported, ok := s.packages[d.Dce().importPath]
+			switch {
+			case !ok:
+				// Nothing is known about the imported package, keep it to be safe.
+				s.enqueue(d, Retention[D]{Root: RootAlive})
+			case imported.effectful:
+				s.addDeclInfo(&declInfo[D]{decl: d, objectFilter: packageDep(d.Dce().importPath)})
+			default:
+				s.addDeclInfo(&declInfo[D]{decl: d, objectFilter: packageDep(d.Dce().importPath), methodFilter: packageDep(path)})
+			}
+		}
+	}
+
+	for _, path := range s.pkgOrder {
+		if pkg := s.packages[path]; pkg.keep {
+			pkg.alive = true
+			s.satisfy(packageDep(path), Retention[D]{Root: RootPackage})
+		}
+	}
+}
+
+// addDeclInfo registers the decl info under its filters.
+func (s *Selector[D]) addDeclInfo(info *declInfo[D]) {
+	if info.objectFilter != `` {
+		s.byFilter[info.objectFilter] = append(s.byFilter[info.objectFilter], info)
+	}
+	if info.methodFilter != `` {
 		s.byFilter[info.methodFilter] = append(s.byFilter[info.methodFilter], info)
 	}
 }
 
+// enqueue adds the decl to the live queue, recording why it is alive unless
+// a reason was already recorded.
+func (s *Selector[D]) enqueue(decl D, reason Retention[D]) {
+	if s.retention == nil {
+		s.retention = make(map[D]Retention[D])
+	}
+	if _, ok := s.retention[decl]; !ok {
+		s.retention[decl] = reason
+	}
+	s.pendingDecls = append(s.pendingDecls, decl)
+}
+
 func (s *Selector[D]) popPending() D {
-	max := len(s.pendingDecls) - 1
-	d := s.pendingDecls[max]
-	s.pendingDecls = s.pendingDecls[:max]
+	d := s.pendingDecls[0]
+	s.pendingDecls = s.pendingDecls[1:]
 	return d
 }
 
@@ -63,31 +340,104 @@ func (s *Selector[D]) popPending() D {
 // after dead-code elimination.
 // This should only be called once all declarations have been included.
 func (s *Selector[D]) AliveDecls() map[D]struct{} {
+	s.includePackages()
+	for _, name := range s.keptMethods {
+		s.satisfy(methodDep(name), Retention[D]{Root: RootAlive})
+	}
+
 	dceSelection := make(map[D]struct{}) // Known live decls.
 	for len(s.pendingDecls) != 0 {
 		d := s.popPending()
+		if _, ok := dceSelection[d]; ok {
+			continue // Already processed, e.g. enqueued both via a link and a name.
+		}
 		dce := d.Dce()
 
 		dceSelection[d] = struct{}{} // Mark the decl as live.
 
+		for _, impl := range s.links[d] {
+			s.enqueue(impl, Retention[D]{KeptBy: d, Dep: LinknameDep})
+		}
+
+		// A live decl makes its package alive, which may bring the package's
+		// init and import decls alive too.
+		if path, ok := s.pkgOf[d]; ok && !s.packages[path].alive {
+			s.packages[path].alive = true
+			dep := packageDep(path)
+			s.satisfy(dep, Retention[D]{KeptBy: d, Dep: dep})
+		}
+
+		if s.eliminateMethods {
+			for _, name := range dce.getMethodUses() {
+				dep := methodDep(name)
+				s.satisfy(dep, Retention[D]{KeptBy: d, Dep: dep})
+			}
+			if dce.reflectsMethods && !s.methodsReflected {
+				s.methodsReflected = true
+				s.satisfyAllMethods(d)
+			}
+		}
+
 		// Consider all decls the current one is known to depend on and possible add
 		// them to the live queue.
 		for _, dep := range dce.getDeps() {
-			if infos, ok := s.byFilter[dep]; ok {
-				delete(s.byFilter, dep)
-				for _, info := range infos {
-					if info.objectFilter == dep {
-						info.objectFilter = ``
-					}
-					if info.methodFilter == dep {
-						info.methodFilter = ``
-					}
-					if info.objectFilter == `` && info.methodFilter == `` {
-						s.pendingDecls = append(s.pendingDecls, info.decl)
-					}
-				}
-			}
+			s.satisfy(dep, Retention[D]{KeptBy: d, Dep: dep})
 		}
 	}
 	return dceSelection
 }
+
+// satisfy clears the given DCE name from the filters of the decls waiting for
+// it and adds the decls with all filters cleared to the live queue, recording
+// the given reason.
+func (s *Selector[D]) satisfy(dep string, reason Retention[D]) {
+	infos, ok := s.byFilter[dep]
+	if !ok {
+		return
+	}
+	delete(s.byFilter, dep)
+	for _, info := range infos {
+		if info.objectFilter == dep {
+			info.objectFilter = ``
+		}
+		if info.methodFilter == dep {
+			info.methodFilter = ``
+		}
+		if info.objectFilter == `` && info.methodFilter == `` {
+			s.enqueue(info.decl, reason)
+		}
+	}
+}
+
+// Chain returns the chain of live declarations through which the given
+// declaration was found to be alive, starting with a declaration alive on its
+// own and ending with the given one. The chain is one of the shortest, except
+// that a declaration with both an object and a method filter is linked to
+// the declaration which satisfied the last of them. Returns nil if the
+// declaration is dead.
+func (s *Selector[D]) Chain(decl D) []D {
+	if _, ok := s.retention[decl]; !ok {
+		return nil
+	}
+	chain := []D{decl}
+	for {
+		r := s.retention[decl]
+		if r.Root != `` {
+			break
+		}
+		decl = r.KeptBy
+		chain = append(chain, decl)
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
+
+// Retention returns the reason the given declaration was found to be alive.
+// This should only be called after AliveDecls. Returns false if the
+// declaration is dead.
+func (s *Selector[D]) Retention(decl D) (Retention[D], bool) {
+	r, ok := s.retention[decl]
+	return r, ok
+}
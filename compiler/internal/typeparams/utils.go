@@ -105,6 +105,12 @@ func HasTypeParams(typ types.Type) bool {
 	}
 }
 
+// IsGeneric returns true if the given type refers to any type parameters,
+// meaning that it must be substituted before it can be used as a concrete type.
+func IsGeneric(typ types.Type) bool {
+	return isGeneric(nil, []types.Type{typ})
+}
+
 // isGeneric will search all the given types in `typ` and their subtypes for a
 // *types.TypeParam. This will not check if a type could be generic,
 // but if each instantiation is not completely concrete yet.
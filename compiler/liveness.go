@@ -0,0 +1,106 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LivenessStep is a link in a chain of live declarations, see ExplainLiveness.
+type LivenessStep struct {
+	// Decl is the live declaration.
+	Decl *Decl
+	// Root is set for the first step of the chain and tells why its declaration
+	// is alive on its own: "alive" for entry points, such as main and init
+	// functions, or variables with side-effecting initializers, "unnamed" for
+	// declarations not subject to dead-code elimination and "package" for
+	// declarations of packages kept regardless of their use, such as runtime.
+	Root string
+	// Dep is the dead-code elimination name through which the declaration of
+	// the previous step depends on this one, or "go:linkname" if the previous
+	// step references this one with a go:linkname directive. Empty for the
+	// first step.
+	Dep string
+}
+
+// ExplainLiveness returns the shortest chain of live declarations, starting
+// with an entry point of the program, through which the named symbol of the
+// package with the given import path is kept alive by dead-code elimination.
+//
+// The symbol is named the way it is declared in the package, for example
+// "Sprintf" for a function, "pp" for a type or "pp.doPrintf" or
+// "(*pp).doPrintf" for a method. Instances of generic symbols match their
+// generic name.
+func ExplainLiveness(pkgs []*Archive, pkgPath, symbol string) ([]LivenessStep, error) {
+	var pkg *Archive
+	for _, p := range pkgs {
+		if p.ImportPath == pkgPath {
+			pkg = p
+			break
+		}
+	}
+	if pkg == nil {
+		return nil, fmt.Errorf("package %q is not a dependency of the program", pkgPath)
+	}
+
+	_, sel := selectLiveDecls(pkgs)
+	sel.AliveDecls()
+
+	found := false
+	var best []*Decl
+	for _, d := range pkg.Declarations {
+		if !declMatches(d, pkgPath, symbol) {
+			continue
+		}
+		found = true
+		chain := sel.Chain(d)
+		if chain == nil {
+			continue
+		}
+		// A function and the variable holding it are kept alive by the same
+		// dependencies, prefer explaining the function code on a tie.
+		if best == nil || len(chain) < len(best) ||
+			len(chain) == len(best) && strings.HasPrefix(best[len(best)-1].FullName, `funcVar:`) {
+			best = chain
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no declaration of %s found in package %q", symbol, pkgPath)
+	}
+	if best == nil {
+		return nil, fmt.Errorf("%s.%s is eliminated as dead code", pkgPath, symbol)
+	}
+
+	steps := make([]LivenessStep, len(best))
+	for i, d := range best {
+		retention, _ := sel.Retention(d)
+		steps[i] = LivenessStep{Decl: d, Root: retention.Root, Dep: retention.Dep}
+	}
+	return steps, nil
+}
+
+// declMatches returns true if the declaration belongs to the symbol of the
+// given package, as named by the user of ExplainLiveness.
+func declMatches(d *Decl, pkgPath, symbol string) bool {
+	_, name, ok := strings.Cut(d.FullName, ":")
+	if !ok {
+		return false
+	}
+	name, ok = strings.CutPrefix(name, pkgPath+".")
+	if !ok {
+		return false
+	}
+	if i := strings.IndexByte(name, '<'); i != -1 && strings.IndexByte(symbol, '<') == -1 {
+		// Match instances of generic symbols by their generic name.
+		name = name[:i]
+	}
+	return name == symbol || unparenRecv(name) == unparenRecv(symbol)
+}
+
+// unparenRecv strips the pointer indicator from a method name in the
+// "(*T).Method" form.
+func unparenRecv(name string) string {
+	if rest, ok := strings.CutPrefix(name, "(*"); ok {
+		return strings.Replace(rest, ")", "", 1)
+	}
+	return name
+}
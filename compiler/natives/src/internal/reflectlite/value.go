@@ -17,7 +17,9 @@ func (v Value) object() *js.Object {
 		if val != js.Global.Get("$ifaceNil") && val.Get("constructor") != jsType(v.typ) {
 			switch v.typ.Kind() {
 			case Uint64, Int64:
-				val = jsType(v.typ).New(val.Get("$high"), val.Get("$low"))
+				if !js.Global.Get("$bigInt64").Bool() {
+					val = jsType(v.typ).New(val.Get("$high"), val.Get("$low"))
+				}
 			case Complex64, Complex128:
 				val = jsType(v.typ).New(val.Get("$real"), val.Get("$imag"))
 			case Slice:
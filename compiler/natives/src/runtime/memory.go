@@ -0,0 +1,134 @@
+//go:build js
+
+package runtime
+
+import "github.com/gopherjs/gopherjs/js"
+
+// hostMemory is a snapshot of the memory usage reported by the JavaScript host.
+//
+// JavaScript engines don't expose allocator internals, so only the coarse
+// figures available through the host APIs are known, and all of them are zero
+// if the host doesn't report memory usage at all.
+type hostMemory struct {
+	heapUsed  uint64 // bytes occupied by live and not yet collected JS objects
+	heapTotal uint64 // bytes reserved for the JS heap
+	external  uint64 // bytes allocated outside of the JS heap, e.g. ArrayBuffers
+	total     uint64 // total bytes used by the process (or the page)
+}
+
+// measuredMemory caches the most recent result of the asynchronous
+// performance.measureUserAgentSpecificMemory() API, which is only available to
+// cross-origin isolated pages in some browsers.
+var (
+	measuredMemory  uint64
+	measurePending  bool
+	totalAlloc      uint64 // see ReadMemStats
+	lastHeapUsed    uint64
+	heapUsedSampled bool
+)
+
+// readHostMemory queries the memory usage from process.memoryUsage() on Node.js
+// or performance.memory and performance.measureUserAgentSpecificMemory() in
+// browsers.
+func readHostMemory() hostMemory {
+	var m hostMemory
+	if process := js.Global.Get("process"); process != js.Undefined && process.Get("memoryUsage") != js.Undefined {
+		usage := process.Call("memoryUsage")
+		m.heapUsed = usage.Get("heapUsed").Uint64()
+		m.heapTotal = usage.Get("heapTotal").Uint64()
+		m.external = usage.Get("external").Uint64()
+		m.total = usage.Get("rss").Uint64()
+		return m
+	}
+
+	performance := js.Global.Get("performance")
+	if performance == js.Undefined {
+		return m
+	}
+	if memory := performance.Get("memory"); memory != js.Undefined {
+		m.heapUsed = memory.Get("usedJSHeapSize").Uint64()
+		m.heapTotal = memory.Get("totalJSHeapSize").Uint64()
+		m.total = m.heapTotal
+	}
+	if performance.Get("measureUserAgentSpecificMemory") != js.Undefined && js.Global.Get("crossOriginIsolated").Bool() {
+		// The measurement may take a while, so we report the result of the
+		// previous one and start a new measurement for the subsequent calls.
+		if !measurePending {
+			measurePending = true
+			performance.Call("measureUserAgentSpecificMemory").Call("then", func(result *js.Object) {
+				measuredMemory = result.Get("bytes").Uint64()
+				measurePending = false
+			}, func(*js.Object) {
+				measurePending = false
+			})
+		}
+		if measuredMemory > m.total {
+			m.total = measuredMemory
+		}
+	}
+	return m
+}
+
+// ReadMemStats populates m with memory allocator statistics.
+//
+// GopherJS derives the statistics from the memory usage reported by the
+// JavaScript host, so only the heap and system totals are meaningful. Since
+// the host doesn't report cumulative allocations, TotalAlloc is approximated
+// by adding up increases of HeapAlloc observed between ReadMemStats calls.
+// Statistics that have no JavaScript counterpart are always zero.
+func ReadMemStats(m *MemStats) {
+	*m = MemStats{}
+	hm := readHostMemory()
+
+	m.Alloc = hm.heapUsed
+	m.HeapAlloc = hm.heapUsed
+	m.HeapInuse = hm.heapUsed
+	m.HeapSys = hm.heapTotal
+	if hm.heapTotal > hm.heapUsed {
+		m.HeapIdle = hm.heapTotal - hm.heapUsed
+	}
+	m.Sys = hm.total
+	if hm.total > hm.heapTotal {
+		m.OtherSys = hm.total - hm.heapTotal
+	}
+	m.EnableGC = true
+
+	if !heapUsedSampled {
+		totalAlloc = hm.heapUsed
+		heapUsedSampled = true
+	} else if hm.heapUsed > lastHeapUsed {
+		totalAlloc += hm.heapUsed - lastHeapUsed
+	}
+	lastHeapUsed = hm.heapUsed
+	m.TotalAlloc = totalAlloc
+}
+
+// readMetric returns the current value of the runtime/metrics metric with the
+// given name, or false if GopherJS doesn't support the metric.
+//
+// All supported metrics have uint64 values.
+func readMetric(name string) (value uint64, ok bool) {
+	switch name {
+	case "/memory/classes/total:bytes":
+		return readHostMemory().total, true
+	case "/memory/classes/heap/objects:bytes":
+		return readHostMemory().heapUsed, true
+	case "/memory/classes/heap/unused:bytes":
+		hm := readHostMemory()
+		if hm.heapTotal > hm.heapUsed {
+			return hm.heapTotal - hm.heapUsed, true
+		}
+		return 0, true
+	case "/memory/classes/other:bytes":
+		return readHostMemory().external, true
+	case "/sched/goroutines:goroutines":
+		return uint64(NumGoroutine()), true
+	case "/sched/gomaxprocs:threads":
+		return uint64(GOMAXPROCS(0)), true
+	case "/gopherjs/sched/goroutines-created:goroutines":
+		return js.Global.Get("$createdGoroutines").Uint64(), true
+	case "/gopherjs/sched/switches:events":
+		return js.Global.Get("$goroutineSwitches").Uint64(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,16 @@
+//go:build js
+
+package metrics_test
+
+import "testing"
+
+//gopherjs:purge The runtime doesn't provide the full list of upstream metrics.
+func runtime_readMetricNames() []string
+
+func TestNames(t *testing.T) {
+	t.Skip("GopherJS runtime supports only a subset of the upstream metrics.")
+}
+
+func TestDocs(t *testing.T) {
+	t.Skip("GopherJS runtime supports only a subset of the upstream metrics.")
+}
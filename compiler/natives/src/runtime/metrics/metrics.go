@@ -0,0 +1,65 @@
+//go:build js
+
+package metrics
+
+import _ "unsafe" // For go:linkname
+
+//gopherjs:purge Metrics are read one by one with runtime_readMetric instead.
+func runtime_readMetrics()
+
+//go:linkname runtime_readMetric runtime.readMetric
+func runtime_readMetric(name string) (value uint64, ok bool)
+
+// gopherjsDesc describes metrics specific to the GopherJS runtime.
+var gopherjsDesc = []Description{
+	{
+		Name:        "/gopherjs/sched/goroutines-created:goroutines",
+		Description: "Count of goroutines created since the program started.",
+		Kind:        KindUint64,
+		Cumulative:  true,
+	},
+	{
+		Name:        "/gopherjs/sched/switches:events",
+		Description: "Count of times the GopherJS scheduler resumed a goroutine since the program started.",
+		Kind:        KindUint64,
+		Cumulative:  true,
+	},
+}
+
+func init() {
+	// Only a subset of the upstream metrics can be meaningfully reported by
+	// the GopherJS runtime, so we limit All() to the supported ones, which lets
+	// users rely on every listed metric having a valid value.
+	supported := make([]Description, 0, len(allDesc)+len(gopherjsDesc))
+	for _, d := range allDesc {
+		if _, ok := runtime_readMetric(d.Name); ok {
+			supported = append(supported, d)
+		}
+	}
+
+	// Keep the descriptions sorted lexicographically by name, as documented.
+	for _, d := range gopherjsDesc {
+		i := len(supported)
+		for i > 0 && supported[i-1].Name > d.Name {
+			i--
+		}
+		supported = append(supported, Description{})
+		copy(supported[i+1:], supported[i:])
+		supported[i] = d
+	}
+	allDesc = supported
+}
+
+// Read populates each Value field in the given slice of metric samples.
+//
+// Metrics not listed by All have values of KindBad.
+func Read(m []Sample) {
+	for i := range m {
+		v, ok := runtime_readMetric(m[i].Name)
+		if !ok {
+			m[i].Value = Value{kind: KindBad}
+			continue
+		}
+		m[i].Value = Value{kind: KindUint64, scalar: v}
+	}
+}
@@ -0,0 +1,154 @@
+//go:build js
+
+package runtime
+
+import "github.com/gopherjs/gopherjs/js"
+
+type stringer interface {
+	String() string
+}
+
+// formatPanic formats an unrecovered panic report in the same way the upstream
+// Go runtime does: the panic value followed by the stack trace of the
+// panicking goroutine. It is called by the prelude when a panic escapes a
+// goroutine, with the JavaScript stack trace captured at the panic site.
+func formatPanic(v any, stack *js.Object, goid int) (report string) {
+	defer func() {
+		if recover() != nil {
+			report = "panic: <panic while printing panic value>\n"
+		}
+	}()
+
+	report = "panic: " + panicValueString(v) + "\n\n"
+	report += "goroutine " + itoa(goid) + " [running]:\n"
+	if stack == js.Undefined || stack == nil {
+		return report
+	}
+	lines := stack.Call("split", "\n").Call("slice", 1 /*skip error message*/)
+	for _, frame := range parseCallstack(lines) {
+		if !showFrame(frame.FuncName) {
+			continue
+		}
+		report += frame.FuncName + "(...)\n\t" + frame.File + ":" + itoa(frame.Line) + "\n"
+	}
+	return report
+}
+
+// showFrame reports whether the frame of the named function should be included
+// in the panic stack trace. Similar to the upstream runtime, unexported runtime
+// functions are omitted, as well as functions that don't look like Go
+// functions, such as GopherJS prelude internals.
+func showFrame(name string) bool {
+	if js.InternalObject(name).Call("indexOf", ".").Int() < 0 {
+		return false
+	}
+	const prefix = "runtime."
+	if len(name) > len(prefix) && name[:len(prefix)] == prefix {
+		c := name[len(prefix)]
+		return c >= 'A' && c <= 'Z'
+	}
+	return true
+}
+
+// panicValueString formats a panic value the way upstream printpanicval does.
+func panicValueString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "nil"
+	case error:
+		return v.Error()
+	case stringer:
+		return v.String()
+	case string:
+		return v
+	case int64:
+		return int64String(v)
+	case uint64:
+		return uint64String(v)
+	case complex64:
+		return complexString(complex128(v))
+	case complex128:
+		return complexString(v)
+	case bool, int, int8, int16, int32, uint, uint8, uint16, uint32, uintptr, float32, float64:
+		return jsString(js.InternalObject(v).Get("$val"))
+	}
+
+	// A value of a named type, which is printed as a conversion expression for
+	// basic underlying types.
+	typ := js.InternalObject(v).Get("constructor")
+	typeString := typ.Get("string").String()
+	val := js.InternalObject(v).Get("$val")
+	switch typ.Get("kind").Int() {
+	case kindString:
+		return typeString + `("` + val.String() + `")`
+	case kindInt64:
+		return typeString + "(" + int64String(int64(uint64Bits(val))) + ")"
+	case kindUint64:
+		return typeString + "(" + uint64String(uint64Bits(val)) + ")"
+	case kindComplex64, kindComplex128:
+		return typeString + complexString(complex(val.Get("$real").Float(), val.Get("$imag").Float()))
+	case kindArray, kindChan, kindFunc, kindInterface, kindMap, kindPtr, kindSlice, kindStruct, kindUnsafePointer:
+		// Upstream prints the address of the value, which GopherJS doesn't have.
+		return "(" + typeString + ") {...}"
+	}
+	return typeString + "(" + jsString(val) + ")"
+}
+
+// Type kinds, as defined by the $kind* constants in the prelude.
+const (
+	kindInt64         = 6
+	kindUint64        = 11
+	kindComplex64     = 15
+	kindComplex128    = 16
+	kindArray         = 17
+	kindChan          = 18
+	kindFunc          = 19
+	kindInterface     = 20
+	kindMap           = 21
+	kindPtr           = 22
+	kindSlice         = 23
+	kindString        = 24
+	kindStruct        = 25
+	kindUnsafePointer = 26
+)
+
+// uint64Bits returns the bits of a 64-bit integer represented by a JS object
+// with $high and $low properties, or by a BigInt with the bigint64 experiment.
+func uint64Bits(val *js.Object) uint64 {
+	if js.Global.Get("$bigInt64").Bool() {
+		return val.Uint64()
+	}
+	return uint64(val.Get("$high").Int64())<<32 | uint64(val.Get("$low").Int64())
+}
+
+func jsString(o *js.Object) string {
+	return js.Global.Get("String").Invoke(o).String()
+}
+
+func complexString(c complex128) string {
+	im := jsString(js.InternalObject(imag(c)))
+	if imag(c) >= 0 {
+		im = "+" + im
+	}
+	return "(" + jsString(js.InternalObject(real(c))) + im + "i)"
+}
+
+func int64String(i int64) string {
+	if i < 0 {
+		return "-" + uint64String(uint64(-i))
+	}
+	return uint64String(uint64(i))
+}
+
+func uint64String(u uint64) string {
+	var buf [20]byte
+	i := len(buf)
+	for {
+		i--
+		buf[i] = byte('0' + u%10)
+		u /= 10
+		if u == 0 {
+			return string(buf[i:])
+		}
+	}
+}
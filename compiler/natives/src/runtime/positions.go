@@ -0,0 +1,161 @@
+//go:build js
+
+package runtime
+
+import "github.com/gopherjs/gopherjs/js"
+
+// goPosition is a decoded entry of the position table the compiler embeds into
+// the program, see sourcemapx.PositionTable for details. File and function
+// indices are 1-based, zero means unknown.
+type goPosition struct {
+	genLine, genCol int
+	file, line, fn  int
+}
+
+// positionTable maps locations within the generated JavaScript code to the Go
+// positions, which allows us to report Go positions in the call stack even if
+// the host doesn't apply source maps to the stack traces.
+var positionTable struct {
+	decoded   bool
+	script    string // The script file name as it appears in stack traces.
+	offset    int    // Offset of the program's first line within the script.
+	files     []string
+	funcs     []string
+	positions []goPosition
+}
+
+// loadPositionTable decodes the position table when it's needed for the first
+// time. Returns false if the program was compiled without the table.
+func loadPositionTable() bool {
+	if positionTable.decoded {
+		return positionTable.positions != nil
+	}
+	positionTable.decoded = true
+
+	table := js.Global.Get("$goPositions")
+	if table == nil {
+		return false
+	}
+
+	// The anchor was created at a known line within the program, so its stack
+	// trace reveals where the program is located within the script.
+	anchor := table.Get("anchor").Get("stack")
+	if anchor == js.Undefined {
+		return false
+	}
+	lines := anchor.Call("split", "\n")
+	for i := 0; i < lines.Length(); i++ {
+		frame := ParseCallFrame(lines.Index(i))
+		if frame.Line > 0 {
+			positionTable.script = frame.File
+			positionTable.offset = frame.Line - table.Get("line").Int()
+			break
+		}
+	}
+	if positionTable.script == "" {
+		return false
+	}
+
+	files := table.Get("files")
+	for i := 0; i < files.Length(); i++ {
+		positionTable.files = append(positionTable.files, files.Index(i).String())
+	}
+	funcs := table.Get("funcs")
+	for i := 0; i < funcs.Length(); i++ {
+		positionTable.funcs = append(positionTable.funcs, funcs.Index(i).String())
+	}
+	positionTable.positions = decodePositions(table.Get("mappings").String())
+	return true
+}
+
+// decodePositions decodes the base64 VLQ encoded position table mappings.
+func decodePositions(mappings string) []goPosition {
+	positions := []goPosition{}
+	var fields [5]int
+	field := 0
+	value, shift := 0, 0
+	p := goPosition{}
+	for i := 0; i < len(mappings); i++ {
+		digit := vlqDigit(mappings[i])
+		value |= (digit & 0x1f) << shift
+		if digit&0x20 != 0 {
+			shift += 5
+			continue
+		}
+		if value&1 != 0 {
+			fields[field] = -(value >> 1)
+		} else {
+			fields[field] = value >> 1
+		}
+		value, shift = 0, 0
+		field++
+		if field < len(fields) {
+			continue
+		}
+		field = 0
+
+		if fields[0] != 0 {
+			p.genLine += fields[0]
+			p.genCol = fields[1]
+		} else {
+			p.genCol += fields[1]
+		}
+		p.file += fields[2]
+		p.line += fields[3]
+		p.fn += fields[4]
+		positions = append(positions, p)
+	}
+	return positions
+}
+
+func vlqDigit(c byte) int {
+	switch {
+	case c >= 'A' && c <= 'Z':
+		return int(c - 'A')
+	case c >= 'a' && c <= 'z':
+		return int(c-'a') + 26
+	case c >= '0' && c <= '9':
+		return int(c-'0') + 52
+	case c == '+':
+		return 62
+	default:
+		return 63
+	}
+}
+
+// resolveFrame replaces the JavaScript position of the frame with the Go
+// position from the position table. Frames outside of the compiled Go code, or
+// already mapped to Go sources by the host, are returned unchanged.
+func resolveFrame(frame basicFrame) basicFrame {
+	if !loadPositionTable() || frame.File != positionTable.script {
+		return frame
+	}
+	line := frame.Line - positionTable.offset
+	col := frame.Col - 1 // Stack traces use 1-based columns.
+
+	// Find the last position at or before the frame location.
+	positions := positionTable.positions
+	lo, hi := 0, len(positions)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if p := positions[mid]; p.genLine < line || p.genLine == line && p.genCol <= col {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	if lo == 0 {
+		return frame
+	}
+	p := positions[lo-1]
+	if p.file == 0 {
+		return frame
+	}
+	frame.File = positionTable.files[p.file-1]
+	frame.Line = p.line
+	frame.Col = 0
+	if p.fn != 0 {
+		frame.FuncName = positionTable.funcs[p.fn-1]
+	}
+	return frame
+}
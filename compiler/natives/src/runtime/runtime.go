@@ -70,6 +70,7 @@ func init() {
 	js.Global.Set("$jsObjectPtr", jsPkg.Get("Object").Get("ptr"))
 	js.Global.Set("$jsErrorPtr", jsPkg.Get("Error").Get("ptr"))
 	js.Global.Set("$throwRuntimeError", js.InternalObject(throw))
+	js.Global.Set("$formatPanic", js.InternalObject(formatPanic))
 	buildVersion = js.Global.Get("$goVersion").String()
 	// avoid dead code elimination
 	var e error
@@ -148,14 +149,18 @@ var (
 	// upstream Go runtime. To improve interoperability, we filter them out from
 	// the stack trace.
 	hiddenFrames = map[string]bool{
-		"$callDeferred": true,
+		"$callDeferred":      true,
+		"$callDeferredAsync": true,
+		"$deferredCalls":     true,
+		"$panicError":        true,
 	}
 	// The following GopherJS prelude functions have differently-named
 	// counterparts in the upstream Go runtime. Some standard library code relies
 	// on the names matching, so we perform this substitution.
 	knownFrames = map[string]string{
-		"$panic":     "runtime.gopanic",
-		"$goroutine": "runtime.goexit",
+		"$panic":        "runtime.gopanic",
+		"$goroutine":    "runtime.goexit",
+		"$runGoroutine": "runtime.goexit", // With the asyncawait experiment.
 	}
 )
 
@@ -170,6 +175,7 @@ func parseCallstack(lines *js.Object) []basicFrame {
 		if alias, ok := knownFrames[frame.FuncName]; ok {
 			frame.FuncName = alias
 		}
+		frame = resolveFrame(frame)
 		frames = append(frames, frame)
 		if frame.FuncName == "runtime.goexit" {
 			break // We've reached the bottom of the goroutine stack.
@@ -222,6 +228,7 @@ func ParseCallFrame(info *js.Object) basicFrame {
 		col = parts.Index(parts.Length() - 1).Int()
 	}
 	fn := info.Call("substring", info.Call("indexOf", "at ").Int()+3, info.Call("indexOf", " (").Int())
+	fn = fn.Call("replace", js.Global.Get("RegExp").New(`^async `), "")
 	if idx := fn.Call("indexOf", "[as ").Int(); idx > 0 {
 		fn = fn.Call("substring", idx+4, fn.Call("indexOf", "]"))
 	}
@@ -309,7 +316,14 @@ type Frame struct {
 	Entry    uintptr
 }
 
-func GC() {}
+// GC runs a garbage collection if the host exposes it to the program, for
+// example when Node.js is started with the --expose-gc flag. Otherwise it is
+// a no-op, since JavaScript doesn't allow triggering garbage collection.
+func GC() {
+	if gc := js.Global.Get("gc"); gc != js.Undefined {
+		gc.Invoke()
+	}
+}
 
 func Goexit() {
 	js.Global.Get("$curGoroutine").Set("exit", true)
@@ -380,14 +394,47 @@ type MemStats struct {
 	}
 }
 
-func ReadMemStats(m *MemStats) {
-	// TODO(nevkontakte): This function is effectively unimplemented and may
-	// lead to silent unexpected behaviors. Consider panicing explicitly.
-}
+// SetFinalizer sets the finalizer associated with obj to the provided
+// finalizer function. See the upstream documentation for the full contract.
+//
+// GopherJS implements finalizers on top of the JavaScript FinalizationRegistry
+// API: once obj is collected, the finalizer is called in a new goroutine with a
+// pointer equivalent to obj. Because the collected object can't be resurrected,
+// fields of a struct obj are moved into a separate storage and accessed through
+// property accessors, which makes them somewhat slower to access. Finalizers
+// for pointers to arrays, as well as all finalizers on hosts without
+// FinalizationRegistry support, are never run.
+func SetFinalizer(obj any, finalizer any) {
+	if obj == nil {
+		throw("runtime.SetFinalizer: first argument is nil")
+	}
+	o := js.InternalObject(obj)
+	typ := o.Get("constructor")
+	if typ.Get("kind").Int() != js.Global.Get("$kindPtr").Int() {
+		throw("runtime.SetFinalizer: first argument is " + typ.Get("string").String() + ", not pointer")
+	}
+	if o == typ.Get("nil") {
+		throw("runtime.SetFinalizer: pointer not in allocated block")
+	}
 
-func SetFinalizer(x, f any) {
-	// TODO(nevkontakte): This function is effectively unimplemented and may
-	// lead to silent unexpected behaviors. Consider panicing explicitly.
+	if finalizer == nil {
+		js.Global.Call("$setFinalizer", o, nil)
+		return
+	}
+
+	f := js.InternalObject(finalizer)
+	ftyp := f.Get("constructor")
+	if ftyp.Get("kind").Int() != js.Global.Get("$kindFunc").Int() {
+		throw("runtime.SetFinalizer: second argument is " + ftyp.Get("string").String() + ", not a function")
+	}
+	if ftyp.Get("variadic").Bool() {
+		throw("runtime.SetFinalizer: cannot pass " + typ.Get("string").String() + " to finalizer " + ftyp.Get("string").String() + " because dotdotdot")
+	}
+	params := ftyp.Get("params")
+	if params.Length() != 1 || !js.Global.Call("$assertType", o, params.Index(0), true).Index(1).Bool() {
+		throw("runtime.SetFinalizer: cannot pass " + typ.Get("string").String() + " to finalizer " + ftyp.Get("string").String())
+	}
+	js.Global.Call("$setFinalizer", o, f.Get("$val"))
 }
 
 type Func struct {
@@ -470,7 +517,13 @@ func NumCgoCall() int64 {
 	return 0
 }
 
-func KeepAlive(any) {}
+// KeepAlive marks its argument as currently reachable.
+//
+// JavaScript keeps an object reachable until the end of the current job once a
+// WeakRef to it has been created, which is what KeepAlive relies upon.
+func KeepAlive(x any) {
+	js.Global.Call("$keepAlive", js.InternalObject(x))
+}
 
 // An errorString represents a runtime error described by a single string.
 type errorString string
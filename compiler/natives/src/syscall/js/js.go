@@ -80,6 +80,190 @@ func FuncOf(fn func(this Value, args []Value) any) Func {
 	}
 }
 
+// AsyncFuncOf returns a function to be used by JavaScript, which returns a
+// Promise. Unlike with FuncOf, fn is run in a new goroutine and may block. The
+// promise is fulfilled with the value returned by fn, or rejected if fn returns
+// a non-nil error or panics.
+//
+// AsyncFuncOf is a GopherJS-specific extension of the syscall/js API.
+func AsyncFuncOf(fn func(this Value, args []Value) (any, error)) Func {
+	js.Global.Set("$exportedFunctions", js.Global.Get("$exportedFunctions").Int()+1)
+	return Func{
+		Value: objectToValue(js.MakeAsyncFunc(func(this *js.Object, args []*js.Object) (any, error) {
+			vargs := make([]Value, len(args))
+			for i, a := range args {
+				vargs[i] = objectToValue(a)
+			}
+			result, err := fn(objectToValue(this), vargs)
+			if jsErr, ok := err.(Error); ok {
+				return nil, &js.Error{Object: jsErr.internal()}
+			} else if err != nil {
+				return nil, err
+			}
+			return ValueOf(result).internal(), nil
+		})),
+	}
+}
+
+// Await blocks the calling goroutine until the promise settles and returns its
+// fulfillment value. If the promise is rejected, Await returns an Error holding
+// the rejection reason. Values other than promises or thenables are returned
+// as is. Await may not be called from a function created with FuncOf, use a
+// goroutine or AsyncFuncOf instead.
+//
+// Await is a GopherJS-specific extension of the syscall/js API.
+func Await(promise Value) (Value, error) {
+	v, err := js.Await(promise.internal())
+	if err != nil {
+		return Value{}, Error{Value: objectToValue(err.(*js.Error).Object)}
+	}
+	return objectToValue(v), nil
+}
+
+// Await waits for the promise or thenable v to settle. It is equivalent to
+// calling Await(v).
+//
+// Value.Await is a GopherJS-specific extension of the syscall/js API.
+func (v Value) Await() (Value, error) {
+	return Await(v)
+}
+
+// Iterate returns a sequence over the values produced by the JavaScript
+// iterable v, as a for...of loop would. For example, the values of a Map are
+// its [key, value] entries, the values of a string are its code points. The
+// sequence has the shape of an iter.Seq[Value] and can be used with
+// range-over-func where it is available, or called directly:
+//
+//	js.Iterate(set)(func(v js.Value) bool {
+//		fmt.Println(v)
+//		return true
+//	})
+//
+// If the iteration is stopped early, the iterator's return method is called,
+// which allows generators and other iterators to release their resources.
+// Iterate panics if v is not iterable. JavaScript exceptions thrown by the
+// iterator are raised as panics with an Error.
+//
+// Iterate is a GopherJS-specific extension of the syscall/js API.
+func Iterate(v Value) func(yield func(Value) bool) {
+	return func(yield func(Value) bool) {
+		it := getIterator(v, symbolIterator)
+		if it == nil {
+			panic(&ValueError{"Iterate", v.Type()})
+		}
+		done := false
+		defer func() {
+			if !done {
+				callOptional(it, "return")
+			}
+		}()
+		for {
+			r := callMethod(it, "next")
+			if r.Get("done").Bool() {
+				done = true
+				return
+			}
+			if !yield(objectToValue(r.Get("value"))) {
+				return
+			}
+		}
+	}
+}
+
+// IterateAsync returns a sequence over the values produced by the JavaScript
+// async iterable v, as a for await...of loop would. In addition to async
+// iterables, such as async generators or a ReadableStream, v may be a
+// synchronous iterable, in which case each of its values is awaited, or a
+// ReadableStream that doesn't implement the async iteration protocol, in which
+// case it is read with a reader that is released once the sequence ends. The
+// sequence has the shape of an iter.Seq2[Value, error].
+//
+// If awaiting a value fails, the sequence yields a zero Value and an Error
+// holding the rejection reason, and then ends. If the iteration is stopped
+// early, the iterator is closed, or the stream cancelled.
+//
+// Like Await, the sequence blocks the calling goroutine and may not be used
+// from a function created with FuncOf. IterateAsync panics if v is not
+// iterable.
+//
+// IterateAsync is a GopherJS-specific extension of the syscall/js API.
+func IterateAsync(v Value) func(yield func(Value, error) bool) {
+	return func(yield func(Value, error) bool) {
+		next, closer := "next", "return"
+		sync := false
+		it := getIterator(v, symbolAsyncIterator)
+		if it == nil {
+			it = getIterator(v, symbolIterator)
+			sync = true
+		}
+		if it == nil && v.Type().isObject() && v.Get("getReader").Type() == TypeFunction {
+			it = callMethod(v.internal(), "getReader")
+			next, closer, sync = "read", "cancel", false
+			defer callOptional(it, "releaseLock")
+		}
+		if it == nil {
+			panic(&ValueError{"IterateAsync", v.Type()})
+		}
+		done := false
+		defer func() {
+			if !done {
+				callOptional(it, closer)
+			}
+		}()
+		for {
+			r, err := Await(objectToValue(callMethod(it, next)))
+			if err != nil {
+				done = true
+				yield(Value{}, err)
+				return
+			}
+			if r.Get("done").Bool() {
+				done = true
+				return
+			}
+			value := r.Get("value")
+			if sync {
+				if value, err = Await(value); err != nil {
+					yield(Value{}, err)
+					return
+				}
+			}
+			if !yield(value, nil) {
+				return
+			}
+		}
+	}
+}
+
+// getIterator returns the iterator obtained by calling the method of v keyed
+// by the symbol sym, or nil if v doesn't have such a method.
+func getIterator(v Value, sym *js.Object) *js.Object {
+	if vType := v.Type(); !vType.isObject() && vType != TypeString {
+		return nil
+	}
+	o := v.internal()
+	method := reflectGet.Invoke(js.Global.Get("Object").Invoke(o), sym)
+	if getValueType(method) != TypeFunction {
+		return nil
+	}
+	defer convertJSError()
+	return method.Call("call", o)
+}
+
+// callMethod calls the method m of o without arguments, converting JavaScript
+// exceptions to Error panics.
+func callMethod(o *js.Object, m string) *js.Object {
+	defer convertJSError()
+	return o.Call(m)
+}
+
+// callOptional calls the method m of o without arguments if it exists.
+func callOptional(o *js.Object, m string) {
+	if getValueType(o.Get(m)) == TypeFunction {
+		callMethod(o, m)
+	}
+}
+
 type Error struct {
 	Value
 }
@@ -105,9 +289,12 @@ func objectToValue(obj *js.Object) Value {
 }
 
 var (
-	id         *js.Object
-	instanceOf *js.Object
-	typeOf     *js.Object
+	id                  *js.Object
+	instanceOf          *js.Object
+	typeOf              *js.Object
+	reflectGet          *js.Object
+	symbolIterator      *js.Object
+	symbolAsyncIterator *js.Object
 )
 
 func init() {
@@ -115,6 +302,9 @@ func init() {
 		id = js.Global.Get("$id")
 		instanceOf = js.Global.Get("$instanceOf")
 		typeOf = js.Global.Get("$typeOf")
+		reflectGet = js.Global.Get("Reflect").Get("get")
+		symbolIterator = js.Global.Get("Symbol").Get("iterator")
+		symbolAsyncIterator = js.Global.Get("Symbol").Get("asyncIterator")
 	}
 }
 
@@ -347,6 +537,7 @@ func (e *ValueError) Error() string {
 // CopyBytesToGo copies bytes from the Uint8Array src to dst.
 // It returns the number of bytes copied, which will be the minimum of the lengths of src and dst.
 // CopyBytesToGo panics if src is not an Uint8Array.
+// Use BytesOf to access the contents of src without copying.
 func CopyBytesToGo(dst []byte, src Value) int {
 	vlen := src.v.Length()
 	if dlen := len(dst); dlen < vlen {
@@ -359,6 +550,7 @@ func CopyBytesToGo(dst []byte, src Value) int {
 // CopyBytesToJS copies bytes from src to the Uint8Array dst.
 // It returns the number of bytes copied, which will be the minimum of the lengths of src and dst.
 // CopyBytesToJS panics if dst is not an Uint8Array.
+// Use TypedArrayOf to pass a byte slice to JavaScript without copying.
 func CopyBytesToJS(dst Value, src []byte) int {
 	dt, ok := dst.v.Interface().([]byte)
 	if !ok {
@@ -366,3 +558,62 @@ func CopyBytesToJS(dst Value, src []byte) int {
 	}
 	return copy(dt, src)
 }
+
+// TypedArrayElement is the set of element types whose slices are backed by
+// JavaScript typed arrays. Since int and uint are 32 bits wide in GopherJS,
+// they map to Int32Array and Uint32Array respectively.
+//
+// TypedArrayElement is a GopherJS-specific extension of the syscall/js API.
+type TypedArrayElement interface {
+	~int8 | ~int16 | ~int32 | ~int | ~uint8 | ~uint16 | ~uint32 | ~uint | ~float32 | ~float64
+}
+
+// TypedArrayOf returns a JavaScript typed array (Int8Array, Uint8Array,
+// Float32Array and so on, depending on the element type) that is a view over
+// the elements of s. No data is copied: the typed array and s share the same
+// memory, so writes through either of them are visible to the other. The view
+// covers exactly s[0:len(s)]. Once s is reallocated, for example by an append
+// that exceeds its capacity, the new slice no longer shares memory with the
+// typed array.
+//
+// TypedArrayOf is a GopherJS-specific extension of the syscall/js API.
+func TypedArrayOf[T TypedArrayElement](s []T) Value {
+	o := js.InternalObject(s)
+	offset := o.Get("$offset").Int()
+	return objectToValue(o.Get("$array").Call("subarray", offset, offset+o.Get("$length").Int()))
+}
+
+// SliceOf returns a Go slice that uses the memory of the JavaScript typed
+// array or ArrayBuffer v as its backing array, without copying. A typed array
+// must match the element type of the result (see TypedArrayElement), an
+// ArrayBuffer is viewed as a whole. The length and capacity of the result are
+// the number of elements in v; writes through either of them are visible to
+// the other until the slice is reallocated by an append that exceeds its
+// capacity. If the ArrayBuffer holding the data is detached, for example by
+// transferring it to a worker, the slice must no longer be used.
+// SliceOf panics if v is neither a typed array of the matching type nor an
+// ArrayBuffer.
+//
+// SliceOf is a GopherJS-specific extension of the syscall/js API.
+func SliceOf[T TypedArrayElement](v Value) []T {
+	var s []T
+	sliceType := js.InternalObject(s).Get("constructor")
+	array := v.internal()
+	if vType := v.Type(); !vType.isObject() {
+		panic(&ValueError{"SliceOf", vType})
+	}
+	if c := array.Get("constructor"); c != sliceType.Get("nativeArray") && c != js.Global.Get("ArrayBuffer") {
+		panic("syscall/js: SliceOf: expected v to be an ArrayBuffer or " + sliceType.Get("nativeArray").Get("name").String())
+	}
+	obj := sliceType.New(array)
+	return *(*[]T)(unsafe.Pointer(&obj))
+}
+
+// BytesOf returns a byte slice that shares memory with the Uint8Array or
+// ArrayBuffer v, without copying. It is a shorthand for SliceOf[byte](v) and
+// follows the same aliasing rules.
+//
+// BytesOf is a GopherJS-specific extension of the syscall/js API.
+func BytesOf(v Value) []byte {
+	return SliceOf[byte](v)
+}
@@ -2,7 +2,11 @@
 
 package js_test
 
-import "testing"
+import (
+	"reflect"
+	"syscall/js"
+	"testing"
+)
 
 func TestIntConversion(t *testing.T) {
 	// Same as upstream, but only test cases appropriate for a 32-bit environment.
@@ -16,3 +20,167 @@ func TestIntConversion(t *testing.T) {
 func TestGarbageCollection(t *testing.T) {
 	t.Skip("GC is not supported by GopherJS")
 }
+
+func TestAwait(t *testing.T) {
+	v, err := js.Await(js.Global().Get("Promise").Call("resolve", "done"))
+	if err != nil {
+		t.Fatalf("Got: js.Await() returned error: %v. Want: no error.", err)
+	}
+	if got := v.String(); got != "done" {
+		t.Errorf("Got: js.Await() = %q. Want: %q.", got, "done")
+	}
+
+	_, err = js.Await(js.Global().Get("Promise").Call("reject", js.Global().Get("Error").New("boom")))
+	if _, ok := err.(js.Error); !ok {
+		t.Fatalf("Got: js.Await() returned error %#v. Want: js.Error.", err)
+	}
+	if got, want := err.Error(), "JavaScript error: boom"; got != want {
+		t.Errorf("Got: error %q. Want: %q.", got, want)
+	}
+}
+
+func TestAsyncFuncOf(t *testing.T) {
+	c := make(chan string)
+	fn := js.AsyncFuncOf(func(this js.Value, args []js.Value) (any, error) {
+		return <-c + args[0].String(), nil
+	})
+	defer fn.Release()
+
+	p := fn.Invoke("!")
+	go func() { c <- "done" }()
+	v, err := js.Await(p)
+	if err != nil {
+		t.Fatalf("Got: awaiting the async function returned error: %v. Want: no error.", err)
+	}
+	if got, want := v.String(), "done!"; got != want {
+		t.Errorf("Got: async function result %q. Want: %q.", got, want)
+	}
+}
+
+func TestTypedArrayOf(t *testing.T) {
+	s := []float32{1, 2, 3, 4}
+	a := js.TypedArrayOf(s[1:3])
+	if got, want := a.Get("constructor").Get("name").String(), "Float32Array"; got != want {
+		t.Fatalf("Got: js.TypedArrayOf() returned %s. Want: %s.", got, want)
+	}
+	if got := a.Length(); got != 2 {
+		t.Fatalf("Got: typed array length %d. Want: 2.", got)
+	}
+	a.SetIndex(0, 20)
+	s[2] = 30
+	if s[1] != 20 {
+		t.Errorf("Got: s[1] = %v after writing to the typed array. Want: 20.", s[1])
+	}
+	if got := a.Index(1).Float(); got != 30 {
+		t.Errorf("Got: typed array element 1 = %v after writing to the slice. Want: 30.", got)
+	}
+
+	if got, want := js.TypedArrayOf([]int(nil)).Get("constructor").Get("name").String(), "Int32Array"; got != want {
+		t.Errorf("Got: js.TypedArrayOf([]int(nil)) returned %s. Want: %s.", got, want)
+	}
+}
+
+func TestSliceOf(t *testing.T) {
+	a := js.Global().Get("Float64Array").New(3)
+	s := js.SliceOf[float64](a)
+	if len(s) != 3 || cap(s) != 3 {
+		t.Fatalf("Got: len %d, cap %d. Want: len 3, cap 3.", len(s), cap(s))
+	}
+	s[0] = 1.5
+	a.SetIndex(2, 2.5)
+	if got := a.Index(0).Float(); got != 1.5 {
+		t.Errorf("Got: typed array element 0 = %v after writing to the slice. Want: 1.5.", got)
+	}
+	if s[2] != 2.5 {
+		t.Errorf("Got: s[2] = %v after writing to the typed array. Want: 2.5.", s[2])
+	}
+
+	buf := js.Global().Get("ArrayBuffer").New(4)
+	b := js.BytesOf(buf)
+	b[3] = 42
+	if got := js.Global().Get("Uint8Array").New(buf).Index(3).Int(); got != 42 {
+		t.Errorf("Got: byte 3 of the ArrayBuffer = %d. Want: 42.", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Got: js.SliceOf[int32]() on a Float64Array did not panic. Want: panic.")
+		}
+	}()
+	js.SliceOf[int32](a)
+}
+
+func TestIterate(t *testing.T) {
+	m := js.Global().Get("Map").New()
+	m.Call("set", "a", 1)
+	m.Call("set", "b", 2)
+	var got []string
+	js.Iterate(m)(func(entry js.Value) bool {
+		got = append(got, entry.Index(0).String()+"="+entry.Index(1).String())
+		return true
+	})
+	if want := []string{"a=1", "b=2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Got: Map entries %q. Want: %q.", got, want)
+	}
+
+	gen := js.Global().Call("eval", `(function* () { try { yield 1; yield 2; } finally { globalThis.$iterateClosed = true; } })()`)
+	count := 0
+	js.Iterate(gen)(func(v js.Value) bool {
+		count++
+		return false
+	})
+	if count != 1 || !js.Global().Get("$iterateClosed").Truthy() {
+		t.Errorf("Got: %d values, generator closed: %v. Want: 1 value, generator closed.", count, js.Global().Get("$iterateClosed").Truthy())
+	}
+
+	defer func() {
+		if _, ok := recover().(*js.ValueError); !ok {
+			t.Errorf("Got: js.Iterate() on a number did not panic with a ValueError. Want: a ValueError.")
+		}
+	}()
+	js.Iterate(js.ValueOf(42))(func(js.Value) bool { return true })
+}
+
+func TestIterateAsync(t *testing.T) {
+	gen := js.Global().Call("eval", `(async function* () { yield "a"; yield Promise.resolve("b"); throw new Error("boom"); })()`)
+	var got []string
+	var gotErr error
+	js.IterateAsync(gen)(func(v js.Value, err error) bool {
+		if err != nil {
+			gotErr = err
+			return false
+		}
+		got = append(got, v.String())
+		return true
+	})
+	if want := []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Got: values %q. Want: %q.", got, want)
+	}
+	if gotErr == nil || gotErr.Error() != "JavaScript error: boom" {
+		t.Errorf("Got: error %v. Want: JavaScript error: boom.", gotErr)
+	}
+
+	promises := js.Global().Call("eval", `[Promise.resolve(1), 2]`)
+	sum := 0
+	js.IterateAsync(promises)(func(v js.Value, err error) bool {
+		if err != nil {
+			t.Fatalf("Got: error %v. Want: no error.", err)
+		}
+		sum += v.Int()
+		return true
+	})
+	if sum != 3 {
+		t.Errorf("Got: sum of awaited values %d. Want: 3.", sum)
+	}
+}
+
+func TestValueAwait(t *testing.T) {
+	thenable := js.Global().Call("eval", `({ then(resolve) { resolve(42); } })`)
+	v, err := thenable.Await()
+	if err != nil {
+		t.Fatalf("Got: Value.Await() returned error: %v. Want: no error.", err)
+	}
+	if got := v.Int(); got != 42 {
+		t.Errorf("Got: Value.Await() = %d. Want: 42.", got)
+	}
+}
@@ -0,0 +1,64 @@
+//go:build js
+
+// Package unique provides facilities for canonicalizing ("interning")
+// comparable values.
+//
+// The upstream package was introduced in Go 1.23. GopherJS implements it with
+// an intern map of weak pointers, which lets unused canonical values be
+// reclaimed on hosts supporting WeakRef and FinalizationRegistry.
+package unique
+
+import (
+	"runtime"
+	"weak"
+)
+
+// Handle is a globally unique identity for some value of type T.
+//
+// Two handles compare equal exactly if the two values used to create the
+// handles would have also compared equal. The comparison of two handles is
+// trivial and typically much more efficient than comparing the values used to
+// create them.
+type Handle[T comparable] struct {
+	value *T
+}
+
+// Value returns a shallow copy of the T value that produced the Handle.
+// Value is safe for concurrent use by multiple goroutines.
+func (h Handle[T]) Value() T {
+	return *h.value
+}
+
+// key wraps interned values, so that values of different types, which would be
+// equal as interface values (e.g. Make[any](1) and Make[int](1)), don't collide
+// in the intern map.
+type key[T comparable] struct{ value T }
+
+// handles is the intern map, keyed by key[T] and containing weak.Pointer[T]
+// values to the canonical copies.
+var handles = map[any]any{}
+
+// Make returns a globally unique handle for a value of type T. Handles
+// are equal if and only if the values used to produce them are equal.
+// Make is safe for concurrent use by multiple goroutines.
+func Make[T comparable](value T) Handle[T] {
+	k := key[T]{value}
+	if wp, ok := handles[k]; ok {
+		if ptr := wp.(weak.Pointer[T]).Value(); ptr != nil {
+			return Handle[T]{ptr}
+		}
+	}
+
+	ptr := new(T)
+	*ptr = value
+	wp := weak.Make(ptr)
+	handles[k] = wp
+	runtime.SetFinalizer(ptr, func(*T) {
+		// The entry may have been replaced by a new canonical copy after the weak
+		// pointer had been cleared, but before the finalizer was run.
+		if current, ok := handles[k]; ok && current.(weak.Pointer[T]) == wp {
+			delete(handles, k)
+		}
+	})
+	return Handle[T]{ptr}
+}
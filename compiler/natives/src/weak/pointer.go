@@ -0,0 +1,79 @@
+//go:build js
+
+// Package weak provides ways to safely reference memory weakly, that is,
+// without preventing its reclamation.
+//
+// The upstream package was introduced in Go 1.24. GopherJS implements it on top
+// of the JavaScript WeakRef API, so that libraries relying on weak pointers for
+// caches and canonicalization maps can be used with GopherJS. On hosts without
+// WeakRef support weak pointers retain their referents strongly, so that Value
+// never returns nil for a pointer made from a non-nil one.
+package weak
+
+import (
+	"unsafe"
+
+	"github.com/gopherjs/gopherjs/js"
+)
+
+// refs maps objects to their WeakRefs, so that all weak pointers made from the
+// same pointer share the same WeakRef and compare equal.
+var refs = js.Global.Get("WeakMap").New()
+
+// Pointer is a weak pointer to a value of type T.
+//
+// Just like regular pointers, Pointer may reference any part of an object, such
+// as a field of a struct or an element of an array.
+//
+// Two Pointer values always compare equal if the pointers from which they were
+// created compare equal. This property is retained even after the object
+// referenced by the pointer used to create a weak reference is reclaimed. If
+// multiple weak pointers are made to different offsets within the same object,
+// those pointers will not compare equal.
+//
+// Calling Make with a nil pointer returns a weak pointer whose Value always
+// returns nil. The zero value of a Pointer behaves as if it were created by
+// passing nil to Make and compares equal with such pointers.
+type Pointer[T any] struct {
+	_   [0]*T
+	ref *js.Object // WeakRef to the referenced object, or nil.
+}
+
+// Make creates a weak pointer from a pointer to some value of type T.
+func Make[T any](ptr *T) Pointer[T] {
+	if ptr == nil {
+		return Pointer[T]{}
+	}
+	obj := js.InternalObject(ptr)
+	ref := refs.Call("get", obj)
+	if ref == js.Undefined {
+		ref = newRef(obj)
+		refs.Call("set", obj, ref)
+	}
+	return Pointer[T]{ref: ref}
+}
+
+// Value returns the original pointer used to create the weak pointer.
+// It returns nil if the value pointed to by the original pointer was reclaimed
+// by the garbage collector.
+func (p Pointer[T]) Value() *T {
+	if p.ref == nil {
+		return nil
+	}
+	obj := p.ref.Call("deref")
+	if obj == js.Undefined {
+		return nil
+	}
+	return (*T)(unsafe.Pointer(obj.Unsafe()))
+}
+
+// newRef creates a WeakRef to the object, or a strong reference with the same
+// interface if the host doesn't support WeakRef.
+func newRef(obj *js.Object) *js.Object {
+	if weakRef := js.Global.Get("WeakRef"); weakRef != js.Undefined {
+		return weakRef.New(obj)
+	}
+	ref := js.Global.Get("Object").New()
+	ref.Set("deref", js.InternalObject(func() *js.Object { return obj }))
+	return ref
+}
@@ -0,0 +1,530 @@
+package compiler
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"github.com/gopherjs/gopherjs/compiler/astutil"
+	"github.com/gopherjs/gopherjs/compiler/internal/analysis"
+	"github.com/gopherjs/gopherjs/compiler/internal/typeparams"
+	"github.com/gopherjs/gopherjs/compiler/typesutil"
+)
+
+// This file implements the optional optimization passes enabled by the
+// optimize flag of Compile. They work on the Go AST as it is translated:
+//
+//   - Calls of small functions, whose body is a single return statement or
+//     call, are replaced with the function body, saving the JS function call
+//     and often making the callee dead code. Functions of other packages are
+//     inlined as long as they only refer to objects accessible via $packages.
+//     Functions marked with //go:noinline and functions reporting their
+//     callers, see reportsCaller, are never inlined.
+//   - Pointers to variables passed to inlined functions, which only dereference
+//     them, are replaced with the variables, which saves allocating pointer
+//     objects for variables of non-struct types.
+//   - Variables whose pointers don't outlive the expressions they're used in
+//     aren't boxed each time they're declared, so a single pointer object is
+//     allocated for them per call, see handleEscapingVars.
+//   - Branches of if statements and switch cases with conditions known at
+//     compile time are eliminated, along with the dead-code elimination
+//     dependencies of the code in the dead branches.
+
+// inlineBudget is the maximum number of AST nodes in the body of a function
+// that may be inlined.
+const inlineBudget = 40
+
+// inlineCandidate is a function that can be inlined into its callers.
+type inlineCandidate struct {
+	// recv is the receiver variable of a method, or nil.
+	recv *types.Var
+	// params are the parameter variables, nil for blank or unnamed parameters.
+	params []*types.Var
+	// result is the type the function returns, or nil if it has no results.
+	result types.Type
+	// body is the returned expression, or the called expression if the function
+	// has no results.
+	body ast.Expr
+	// derefOnly is the set of the receiver and parameter variables the body
+	// only uses to dereference the pointers they hold.
+	derefOnly map[*types.Var]bool
+}
+
+// inlineCandidate returns the inlineable function body of fun, or nil if calls
+// of the function can't be inlined into the package being compiled.
+func (pc *pkgContext) inlineCandidate(fun *types.Func) *inlineCandidate {
+	c, ok := pc.inlineCandidates[fun]
+	if !ok {
+		c = pc.newInlineCandidate(fun)
+		pc.inlineCandidates[fun] = c
+	}
+	return c
+}
+
+func (pc *pkgContext) newInlineCandidate(fun *types.Func) *inlineCandidate {
+	if typesutil.IsJsPackage(fun.Pkg()) || typeparams.HasTypeParams(fun.Type()) {
+		return nil
+	}
+	sig := fun.Type().(*types.Signature)
+	if sig.Recv() != nil && (types.IsInterface(sig.Recv().Type()) || sig.RecvTypeParams().Len() != 0) {
+		return nil
+	}
+	if sig.Results().Len() > 1 {
+		return nil
+	}
+	decl, info := pc.FuncDecl(fun)
+	if decl == nil || decl.Body == nil || len(decl.Body.List) != 1 || astutil.NoInline(decl) {
+		return nil
+	}
+	if pc.IsBlocking(typeparams.Instance{Object: fun}) {
+		return nil
+	}
+
+	c := &inlineCandidate{}
+	switch s := decl.Body.List[0].(type) {
+	case *ast.ReturnStmt:
+		if sig.Results().Len() != 1 || len(s.Results) != 1 {
+			return nil
+		}
+		c.body = s.Results[0]
+		c.result = sig.Results().At(0).Type()
+	case *ast.ExprStmt:
+		if _, ok := astutil.RemoveParens(s.X).(*ast.CallExpr); !ok || sig.Results().Len() != 0 {
+			return nil
+		}
+		c.body = s.X
+	default:
+		return nil
+	}
+
+	locals := map[types.Object]bool{}
+	if decl.Recv != nil && len(decl.Recv.List[0].Names) != 0 && !isBlank(decl.Recv.List[0].Names[0]) {
+		c.recv = info.Defs[decl.Recv.List[0].Names[0]].(*types.Var)
+		locals[c.recv] = true
+	}
+	for _, field := range decl.Type.Params.List {
+		if len(field.Names) == 0 {
+			c.params = append(c.params, nil)
+			continue
+		}
+		for _, name := range field.Names {
+			if isBlank(name) {
+				c.params = append(c.params, nil)
+				continue
+			}
+			param := info.Defs[name].(*types.Var)
+			c.params = append(c.params, param)
+			locals[param] = true
+		}
+	}
+
+	if !pc.inlineable(fun, c.body, info, locals) {
+		return nil
+	}
+	if info != pc.Info {
+		importTypesInfo(pc.Info.Info, info.Info, c.body)
+	}
+	c.derefOnly = derefOnly(c.body, info.Info, locals)
+	return c
+}
+
+// derefOnly returns the pointer variables among vars which are only used as
+// the operand of a dereference expression in the given AST.
+func derefOnly(root ast.Node, info *types.Info, vars map[types.Object]bool) map[*types.Var]bool {
+	uses := map[*types.Var]int{}
+	derefs := map[*types.Var]int{}
+	ast.Inspect(root, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Ident:
+			if v, ok := info.Uses[n].(*types.Var); ok && vars[v] {
+				uses[v]++
+			}
+		case *ast.StarExpr:
+			if id, ok := astutil.RemoveParens(n.X).(*ast.Ident); ok {
+				if v, ok := info.Uses[id].(*types.Var); ok && vars[v] {
+					derefs[v]++
+				}
+			}
+		}
+		return true
+	})
+
+	result := map[*types.Var]bool{}
+	for o := range vars {
+		v := o.(*types.Var)
+		if _, isPointer := v.Type().Underlying().(*types.Pointer); isPointer && uses[v] == derefs[v] {
+			result[v] = true
+		}
+	}
+	return result
+}
+
+// inlineable returns true if the function body only consists of expressions
+// that can be translated in a different function context than its own, which
+// may be in a different package.
+func (pc *pkgContext) inlineable(fun *types.Func, body ast.Expr, info *analysis.Info, locals map[types.Object]bool) bool {
+	// accessible returns true if the package-level object can be referenced
+	// from the package being compiled.
+	accessible := func(o types.Object) bool {
+		return o.Pkg() == nil || o.Pkg() == pc.Pkg || o.Exported() || !isPkgLevel(o)
+	}
+
+	nodes := 0
+	ok := true
+	ast.Inspect(body, func(n ast.Node) bool {
+		if !ok || n == nil {
+			return false
+		}
+		nodes++
+		if nodes > inlineBudget {
+			ok = false
+			return false
+		}
+		if e, isExpr := n.(ast.Expr); isExpr && typesutil.IsJsObject(info.TypeOf(e)) {
+			ok = false
+			return false
+		}
+
+		switch n := n.(type) {
+		case *ast.Ident:
+			if _, isInstance := info.Instances[n]; isInstance {
+				ok = false
+				break
+			}
+			switch o := info.Uses[n].(type) {
+			case nil, *types.Const, *types.PkgName, *types.Nil:
+			case *types.Builtin:
+				ok = o.Name() != "recover"
+			case *types.Var:
+				switch {
+				case o.IsField(), locals[o]:
+				case isPkgLevel(o):
+					ok = accessible(o)
+				default:
+					ok = false
+				}
+			case *types.Func:
+				ok = o != fun && accessible(o) && !pc.reportsCaller(o)
+			case *types.TypeName:
+				ok = !typesutil.IsJsPackage(o.Pkg())
+			default:
+				ok = false
+			}
+		case *ast.SelectorExpr:
+			sel := info.Selections[n]
+			if sel == nil {
+				break // Qualified identifier.
+			}
+			if sel.Obj() == fun || hasJsTag(sel) {
+				ok = false
+				break
+			}
+			if sel.Kind() == types.MethodVal {
+				// Calling a pointer method on an addressable value would take its
+				// address, which is bound to the variables of the function context.
+				_, pointerExpected := sel.Obj().Type().(*types.Signature).Recv().Type().(*types.Pointer)
+				_, isPointer := sel.Recv().Underlying().(*types.Pointer)
+				ok = isPointer || !pointerExpected
+			}
+		case *ast.CallExpr:
+			// Multiple results passed on to another function are unpacked with a
+			// separate statement.
+			if len(n.Args) == 1 {
+				_, isTuple := info.TypeOf(n.Args[0]).(*types.Tuple)
+				ok = !isTuple
+			}
+		case *ast.UnaryExpr:
+			ok = n.Op != token.AND && n.Op != token.ARROW
+		case *ast.BasicLit, *ast.BinaryExpr, *ast.ParenExpr, *ast.IndexExpr,
+			*ast.SliceExpr, *ast.StarExpr, *ast.TypeAssertExpr, *ast.CompositeLit,
+			*ast.KeyValueExpr, *ast.ArrayType, *ast.MapType:
+		default:
+			ok = false
+		}
+		return ok
+	})
+	return ok
+}
+
+// reportsCaller returns true if the function reports its callers, or directly
+// calls a function which does, since which caller is reported changes when a
+// function calling it is inlined.
+func (pc *pkgContext) reportsCaller(fun *types.Func) bool {
+	if isCallerFunc(fun) {
+		return true
+	}
+	reports, ok := pc.callerReporters[fun]
+	if ok {
+		return reports
+	}
+	pc.callerReporters[fun] = false // Guards against recursive functions.
+	if decl, info := pc.FuncDecl(fun); decl != nil && decl.Body != nil {
+		ast.Inspect(decl.Body, func(n ast.Node) bool {
+			if id, isIdent := n.(*ast.Ident); isIdent {
+				if o, isFunc := info.Uses[id].(*types.Func); isFunc && isCallerFunc(o) {
+					reports = true
+				}
+			}
+			return !reports
+		})
+	}
+	pc.callerReporters[fun] = reports
+	return reports
+}
+
+// isCallerFunc returns true for the standard library functions reporting
+// their callers: runtime.Caller and runtime.Callers, the log and log/slog
+// packages, which record the position of the log call, and the methods of the
+// testing package, which report the position of failures and skip the
+// functions marked with Helper.
+func isCallerFunc(o *types.Func) bool {
+	if o.Pkg() == nil {
+		return false
+	}
+	switch o.Pkg().Path() {
+	case "runtime":
+		return o.Name() == "Caller" || o.Name() == "Callers"
+	case "log", "log/slog":
+		return true
+	case "testing":
+		return o.Type().(*types.Signature).Recv() != nil
+	}
+	return false
+}
+
+// hasJsTag returns true if the selection accesses a struct field through a
+// field with a js tag.
+func hasJsTag(sel *types.Selection) bool {
+	t := sel.Recv()
+	for _, index := range sel.Index() {
+		if ptr, isPtr := t.Underlying().(*types.Pointer); isPtr {
+			t = ptr.Elem()
+		}
+		s, ok := t.Underlying().(*types.Struct)
+		if !ok {
+			return false
+		}
+		if getJsTag(s.Tag(index)) != "" {
+			return true
+		}
+		t = s.Field(index).Type()
+	}
+	return false
+}
+
+// importTypesInfo copies the type information about the nodes of the given
+// AST from the types info of one package to another.
+func importTypesInfo(dst, src *types.Info, root ast.Node) {
+	ast.Inspect(root, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case ast.Expr:
+			if tv, ok := src.Types[n]; ok {
+				dst.Types[n] = tv
+			}
+		}
+		switch n := n.(type) {
+		case *ast.Ident:
+			if o, ok := src.Uses[n]; ok {
+				dst.Uses[n] = o
+			}
+		case *ast.SelectorExpr:
+			if sel, ok := src.Selections[n]; ok {
+				dst.Selections[n] = sel
+			}
+		}
+		return true
+	})
+}
+
+// inlineCall returns the translated body of the called function or method fun
+// if the call can be inlined, or nil otherwise. recv is the selector of the
+// called method, or nil for function calls.
+//
+// The arguments are assigned to fresh local variables standing for the
+// parameters, which the body is translated with, so that arguments are
+// evaluated exactly once and in order.
+func (fc *funcContext) inlineCall(e *ast.CallExpr, fun *types.Func, recv *ast.SelectorExpr) *expression {
+	if !fc.pkgCtx.optimize || fc.parent == nil || fc.Blocking[e] || fc.pkgCtx.inlining[fun] {
+		return nil
+	}
+	c := fc.pkgCtx.inlineCandidate(fun)
+	if c == nil {
+		return nil
+	}
+
+	fc.pkgCtx.inlining[fun] = true
+	defer delete(fc.pkgCtx.inlining, fun)
+
+	sig := fun.Type().(*types.Signature)
+
+	// Pointers to variables, which the body only dereferences, are replaced
+	// with the variables themselves, which saves allocating the pointers.
+	var recvAlias ast.Expr
+	if recv != nil && c.recv != nil && c.derefOnly[c.recv] {
+		recvAlias = fc.receiverVar(recv)
+	}
+	argAliases := make([]ast.Expr, len(c.params))
+	hasAliases := false
+	if !sig.Variadic() && len(e.Args) == len(c.params) {
+		for i, param := range c.params {
+			if param != nil && c.derefOnly[param] && !fc.Blocking[e.Args[i]] {
+				argAliases[i] = fc.addressedVar(e.Args[i])
+				hasAliases = hasAliases || argAliases[i] != nil
+			}
+		}
+	}
+
+	var vars []*types.Var
+	bind := func(v *types.Var, alias ast.Expr) string {
+		vars = append(vars, v)
+		if alias != nil {
+			fc.pkgCtx.derefAliases[v] = alias
+			return ""
+		}
+		name := fc.newLocalVariable(v.Name())
+		fc.objectNames[v] = name
+		return name
+	}
+	defer func() {
+		for _, v := range vars {
+			delete(fc.objectNames, v)
+			delete(fc.pkgCtx.derefAliases, v)
+		}
+	}()
+
+	var recvName string
+	if c.recv != nil {
+		recvName = bind(c.recv, recvAlias)
+	}
+	paramNames := make([]string, len(c.params))
+	for i, param := range c.params {
+		if param != nil {
+			paramNames[i] = bind(param, argAliases[i])
+		}
+	}
+
+	// Translate the body first, to make sure it translates into a single
+	// expression, which can be evaluated after the arguments.
+	prevOutput := fc.output
+	fc.output = nil
+	var body *expression
+	if c.result != nil {
+		body = fc.translateImplicitConversion(c.body, c.result)
+	} else {
+		body = fc.translateExpr(c.body)
+	}
+	stmts := fc.output
+	fc.output = prevOutput
+	if len(stmts) != 0 {
+		return nil
+	}
+
+	var parts []string
+	if recv != nil && recvAlias == nil {
+		r, _ := fc.translateReceiver(recv)
+		if recvName != "" {
+			parts = append(parts, recvName+" = "+r.String())
+		} else {
+			parts = append(parts, r.String())
+		}
+	}
+	var args []string
+	if hasAliases {
+		args = make([]string, len(e.Args))
+		for i, arg := range e.Args {
+			if argAliases[i] == nil {
+				args[i] = fc.translateImplicitConversionWithCloning(arg, sig.Params().At(i).Type()).String()
+			}
+		}
+	} else {
+		args = fc.translateArgs(sig, e.Args, e.Ellipsis.IsValid())
+	}
+	for i, arg := range args {
+		switch {
+		case argAliases[i] != nil:
+		case paramNames[i] != "":
+			parts = append(parts, paramNames[i]+" = "+arg)
+		case !isJSLiteral(arg):
+			parts = append(parts, arg)
+		}
+	}
+	if len(parts) == 0 {
+		return body
+	}
+	return fc.formatExpr("(%s, %s)", strings.Join(parts, ", "), body.String())
+}
+
+// receiverVar returns the variable the method selected by e is called on, if
+// the method has a pointer receiver and is called with the address of the
+// variable, or nil otherwise.
+func (fc *funcContext) receiverVar(e *ast.SelectorExpr) ast.Expr {
+	sel, _ := fc.selectionOf(e)
+	if len(sel.Index()) != 1 {
+		return nil
+	}
+	if _, isPointer := sel.Recv().Underlying().(*types.Pointer); isPointer {
+		return fc.addressedVar(e.X)
+	}
+	if id, ok := astutil.RemoveParens(e.X).(*ast.Ident); ok {
+		if _, ok := fc.pkgCtx.Uses[id].(*types.Var); ok {
+			return id
+		}
+	}
+	return nil
+}
+
+// addressedVar returns the variable whose address e takes, or nil if e isn't
+// an address-of expression of a variable.
+func (fc *funcContext) addressedVar(e ast.Expr) ast.Expr {
+	u, ok := astutil.RemoveParens(e).(*ast.UnaryExpr)
+	if !ok || u.Op != token.AND {
+		return nil
+	}
+	id, ok := astutil.RemoveParens(u.X).(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	if _, ok := fc.pkgCtx.Uses[id].(*types.Var); !ok {
+		return nil
+	}
+	return id
+}
+
+// isJSLiteral returns true if the JS expression is a literal, which doesn't
+// need to be evaluated for side effects.
+func isJSLiteral(s string) bool {
+	switch s {
+	case "true", "false", "null", "undefined", `""`:
+		return true
+	}
+	if s == "" || !strings.ContainsRune("0123456789-", rune(s[0])) {
+		return false
+	}
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789.-en", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// foldBranches eliminates the clauses of a branching statement whose
+// conditions are known to be false at compile time, and turns the first clause
+// known to be true into the default clause, dropping the rest.
+func (fc *funcContext) foldBranches(caseClauses []*ast.CaseClause, defaultClause *ast.CaseClause) ([]*ast.CaseClause, *ast.CaseClause) {
+	if !fc.pkgCtx.optimize {
+		return caseClauses, defaultClause
+	}
+	var live []*ast.CaseClause
+	for _, clause := range caseClauses {
+		b, known := analysis.BoolValue(clause.List[0], fc.pkgCtx.Info.Info)
+		switch {
+		case !known:
+			live = append(live, clause)
+		case b:
+			return live, &ast.CaseClause{Body: clause.Body}
+		}
+	}
+	return live, defaultClause
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/gopherjs/gopherjs/compiler/internal/typeparams"
 	"github.com/gopherjs/gopherjs/compiler/sources"
 	"github.com/gopherjs/gopherjs/compiler/typesutil"
+	"github.com/gopherjs/gopherjs/internal/experiments"
 	"github.com/gopherjs/gopherjs/internal/sourcemapx"
 )
 
@@ -43,6 +44,18 @@ type pkgContext struct {
 	fileSet      *token.FileSet
 	errList      errlist.ErrorList
 	instanceSet  *typeparams.PackageInstanceSets
+	// optimize enables the optimization passes, see optimize.go.
+	optimize bool
+	// inlineCandidates caches inlineCandidate results by function.
+	inlineCandidates map[*types.Func]*inlineCandidate
+	// inlining is the set of functions whose bodies are being inlined, which
+	// prevents expanding recursive calls infinitely.
+	inlining map[*types.Func]bool
+	// callerReporters caches reportsCaller results by function.
+	callerReporters map[*types.Func]bool
+	// derefAliases maps the pointer variables of inlined functions to the
+	// variables they point to, which dereferencing them is replaced with.
+	derefAliases map[types.Object]ast.Expr
 }
 
 // isMain returns true if this is the main package of the program.
@@ -123,7 +136,7 @@ type funcContext struct {
 	funcLitCounter int
 }
 
-func newRootCtx(tContext *types.Context, srcs *sources.Sources, minify bool) *funcContext {
+func newRootCtx(tContext *types.Context, srcs *sources.Sources, minify, optimize bool) *funcContext {
 	funcCtx := &funcContext{
 		FuncInfo: srcs.TypeInfo.InitFuncInfo,
 		pkgCtx: &pkgContext{
@@ -138,6 +151,12 @@ func newRootCtx(tContext *types.Context, srcs *sources.Sources, minify bool) *fu
 			minify:       minify,
 			fileSet:      srcs.FileSet,
 			instanceSet:  srcs.TypeInfo.InstanceSets,
+
+			optimize:         optimize,
+			inlineCandidates: make(map[*types.Func]*inlineCandidate),
+			inlining:         make(map[*types.Func]bool),
+			callerReporters:  make(map[*types.Func]bool),
+			derefAliases:     make(map[types.Object]ast.Expr),
 		},
 		allVars:     make(map[string]int),
 		varPtrNames: make(map[*types.Var]string),
@@ -162,7 +181,10 @@ type flowData struct {
 //
 // Provided sources must be prepared so that the type information has been determined,
 // and the source files have been sorted by name to ensure reproducible JavaScript output.
-func Compile(srcs *sources.Sources, tContext *types.Context, minify bool) (_ *Archive, err error) {
+//
+// If optimize is true, small functions are inlined into their callers and
+// branches with constant conditions are eliminated before emitting the code.
+func Compile(srcs *sources.Sources, tContext *types.Context, minify, optimize bool) (_ *Archive, err error) {
 	defer func() {
 		e := recover()
 		if e == nil {
@@ -178,9 +200,12 @@ func Compile(srcs *sources.Sources, tContext *types.Context, minify bool) (_ *Ar
 		err = bailout(fmt.Errorf("unexpected compiler panic while building package %q: %v", srcs.ImportPath, e))
 	}()
 
-	rootCtx := newRootCtx(tContext, srcs, minify)
+	rootCtx := newRootCtx(tContext, srcs, minify, optimize)
 
 	importedPaths, importDecls := rootCtx.importDecls()
+	if d := rootCtx.jsNamingDecl(srcs.Files); d != nil {
+		importDecls = append(importDecls, d)
+	}
 
 	vars, functions, typeNames := rootCtx.topLevelObjects(srcs)
 	// More named types may be added to the list when function bodies are processed.
@@ -293,6 +318,15 @@ func PrepareAllSources(allSources []*sources.Sources, importer sources.Importer,
 		allInfo[i] = src.TypeInfo
 	}
 	analysis.PropagateAnalysis(allInfo)
+	if experiments.Env.MangleProps {
+		jsPkgs := map[*types.Package]bool{}
+		for _, src := range allSources {
+			if len(src.JSFiles) != 0 {
+				jsPkgs[src.Package] = true
+			}
+		}
+		analysis.ManglePropNames(allInfo, jsPkgs, reservedKeywords)
+	}
 	return nil
 }
 
@@ -310,7 +344,7 @@ func (fc *funcContext) initArgs(ty types.Type) string {
 			if !method.Exported() {
 				pkgPath = method.Pkg().Path()
 			}
-			methods[i] = fmt.Sprintf(`{prop: "%s", name: "%s", pkg: "%s", typ: $funcType(%s)}`, method.Name(), method.Name(), pkgPath, fc.initArgs(method.Type()))
+			methods[i] = fmt.Sprintf(`{prop: "%s", name: "%s", pkg: "%s", typ: $funcType(%s)}`, fc.methodName(method), method.Name(), pkgPath, fc.initArgs(method.Type()))
 		}
 		return fmt.Sprintf("[%s]", strings.Join(methods, ", "))
 	case *types.Map:
@@ -339,7 +373,7 @@ func (fc *funcContext) initArgs(ty types.Type) string {
 			}
 			ft := fc.fieldType(t, i)
 			fields[i] = fmt.Sprintf(`{prop: "%s", name: %s, embedded: %t, exported: %t, typ: %s, tag: %s}`,
-				fieldName(t, i), encodeString(field.Name()), field.Anonymous(), field.Exported(), fc.typeName(ft), encodeString(t.Tag(i)))
+				fc.fieldName(t, i), encodeString(field.Name()), field.Anonymous(), field.Exported(), fc.typeName(ft), encodeString(t.Tag(i)))
 		}
 		return fmt.Sprintf(`"%s", [%s]`, pkgPath, strings.Join(fields, ", "))
 	case *types.TypeParam:
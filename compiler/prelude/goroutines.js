@@ -43,20 +43,7 @@ var $callDeferred = (deferred, jsErr, fromPanic) => {
                 if (deferred === undefined) {
                     /* The panic reached the top of the stack. Clear it and throw it as a JavaScript error. */
                     $panicStackDepth = null;
-                    if (localPanicValue.Object instanceof Error) {
-                        throw localPanicValue.Object;
-                    }
-                    var msg;
-                    if (localPanicValue.constructor === $String) {
-                        msg = localPanicValue.$val;
-                    } else if (localPanicValue.Error !== undefined) {
-                        msg = localPanicValue.Error();
-                    } else if (localPanicValue.String !== undefined) {
-                        msg = localPanicValue.String();
-                    } else {
-                        msg = localPanicValue;
-                    }
-                    throw new Error(msg);
+                    throw $panicError(localPanicValue);
                 }
             }
             var call = deferred.pop();
@@ -109,6 +96,30 @@ var $callDeferred = (deferred, jsErr, fromPanic) => {
     }
 };
 
+/* Returns the JavaScript error representing the panic, which remembers the panic site for the Go-style report, see $reportPanic. */
+var $panicError = value => {
+    var goPanic = { value, stack: new Error().stack };
+    if (value.Object instanceof Error) {
+        if (value.Object.$goPanic === undefined) {
+            Object.defineProperty(value.Object, "$goPanic", { value: goPanic, configurable: true });
+        }
+        return value.Object;
+    }
+    var msg;
+    if (value.constructor === $String) {
+        msg = value.$val;
+    } else if (value.Error !== undefined) {
+        msg = value.Error();
+    } else if (value.String !== undefined) {
+        msg = value.String();
+    } else {
+        msg = value;
+    }
+    var err = new Error(msg);
+    err.$goPanic = goPanic;
+    return err;
+};
+
 var $panic = value => {
     $curGoroutine.panicStack.push(value);
     $callDeferred(null, null, true);
@@ -122,10 +133,38 @@ var $recover = () => {
 };
 var $throw = err => { throw err; };
 
-var $noGoroutine = { asleep: false, exit: false, deferStack: [], panicStack: [] };
+var $formatPanic; /* set by package "runtime" */
+/* Prints an unrecovered panic that escaped the goroutine with a Go-style stack trace and exits with status 2, like a native Go program does. Only done on Node.js, in browsers the error is left to propagate to the host. */
+var $reportPanic = (err, goroutine) => {
+    if (err === null || err === undefined || $formatPanic === undefined || $global.process === undefined || typeof $global.process.exit !== "function") {
+        return;
+    }
+    var goPanic = err.$goPanic;
+    if (goPanic === undefined) {
+        if (!(err instanceof Error) || $jsErrorPtr === undefined) {
+            return;
+        }
+        /* A JavaScript exception that wasn't turned into a Go panic by a deferred call. */
+        goPanic = { value: new $jsErrorPtr(err), stack: err.stack };
+    }
+    $flushConsole();
+    var report = $formatPanic(goPanic.value, goPanic.stack, goroutine.id);
+    if ($isAsyncCall($formatPanic, report)) {
+        return report.then(report => {
+            console.error(report);
+            $global.process.exit(2);
+        });
+    }
+    console.error(report);
+    $global.process.exit(2);
+};
+
+var $noGoroutine = { id: 0, asleep: false, exit: false, deferStack: [], panicStack: [] };
 var $curGoroutine = $noGoroutine, $totalGoroutines = 0, $awakeGoroutines = 0, $checkForDeadlock = true, $exportedFunctions = 0;
+var $createdGoroutines = 0, $goroutineSwitches = 0; /* Reported by runtime/metrics. */
 var $mainFinished = false;
 var $go = (fun, args) => {
+    $createdGoroutines++;
     $totalGoroutines++;
     $awakeGoroutines++;
     var $goroutine = () => {
@@ -140,6 +179,7 @@ var $go = (fun, args) => {
             $goroutine.exit = true;
         } catch (err) {
             if (!$goroutine.exit) {
+                $reportPanic(err, $goroutine);
                 throw err;
             }
         } finally {
@@ -159,6 +199,7 @@ var $go = (fun, args) => {
             }
         }
     };
+    $goroutine.id = $createdGoroutines;
     $goroutine.asleep = false;
     $goroutine.exit = false;
     $goroutine.deferStack = [];
@@ -177,6 +218,7 @@ var $runScheduled = () => {
         var start = Date.now();
         var r;
         while ((r = $scheduled.shift()) !== undefined) {
+            $goroutineSwitches++;
             r();
             // We need to interrupt this loop in order to allow the event loop to
             // process timers, IO, etc. However, invoking scheduling through
@@ -213,6 +255,18 @@ var $setTimeout = (f, t) => {
     }, t);
 };
 
+/* Calls onFulfilled or onRejected once the promise (or any other value) settles. Similar to $setTimeout, the pending promise counts as an awake goroutine, so that a goroutine waiting for it isn't reported as a deadlock. */
+var $awaitPromise = (promise, onFulfilled, onRejected) => {
+    $awakeGoroutines++;
+    Promise.resolve(promise).then(value => {
+        $awakeGoroutines--;
+        onFulfilled(value);
+    }, reason => {
+        $awakeGoroutines--;
+        onRejected(reason);
+    });
+};
+
 var $block = () => {
     if ($curGoroutine === $noGoroutine) {
         $throwRuntimeError("cannot block in JavaScript callback, fix by wrapping code in goroutine");
@@ -220,6 +274,12 @@ var $block = () => {
     $curGoroutine.asleep = true;
 };
 
+/* Blocks the current goroutine and returns the frame of a blocking call, which calls resume() to get the call's result once the goroutine is scheduled again. */
+var $suspend = resume => {
+    $block();
+    return { $blk: resume };
+};
+
 var $restore = (context, params) => {
     if (context !== undefined && context.$blk !== undefined) {
         return context;
@@ -227,6 +287,12 @@ var $restore = (context, params) => {
     return params;
 }
 
+/* Returns true if r, the result of calling f, is the promise of a blocking Go function, which the caller must await. Only the asyncawait experiment compiles blocking functions into async functions, see goroutines_async.js. */
+var $isAsyncCall = (f, r) => false;
+
+/* Returns r, the result of calling f on behalf of another function, marked as a promise to await if f is a blocking Go function, see $isAsyncCall. */
+var $forwardCall = (f, r) => r;
+
 var $send = (chan, value) => {
     if (chan.$closed) {
         $throwRuntimeError("send on closed channel");
@@ -248,14 +314,11 @@ var $send = (chan, value) => {
         $schedule(thisGoroutine);
         return value;
     });
-    $block();
-    return {
-        $blk() {
-            if (closedDuringSend) {
-                $throwRuntimeError("send on closed channel");
-            }
+    return $suspend(() => {
+        if (closedDuringSend) {
+            $throwRuntimeError("send on closed channel");
         }
-    };
+    });
 };
 var $recv = chan => {
     var queuedSend = chan.$sendQueue.shift();
@@ -271,14 +334,13 @@ var $recv = chan => {
     }
 
     var thisGoroutine = $curGoroutine;
-    var f = { $blk() { return this.value; } };
+    var value;
     var queueEntry = v => {
-        f.value = v;
+        value = v;
         $schedule(thisGoroutine);
     };
     chan.$recvQueue.push(queueEntry);
-    $block();
-    return f;
+    return $suspend(() => value);
 };
 var $close = chan => {
     if (chan.$closed) {
@@ -344,7 +406,7 @@ var $select = comms => {
 
     var entries = [];
     var thisGoroutine = $curGoroutine;
-    var f = { $blk() { return this.selection; } };
+    var selected;
     var removeFromQueues = () => {
         for (var i = 0; i < entries.length; i++) {
             var entry = entries[i];
@@ -361,7 +423,7 @@ var $select = comms => {
             switch (comm.length) {
                 case 1: /* recv */
                     var queueEntry = value => {
-                        f.selection = [i, value];
+                        selected = [i, value];
                         removeFromQueues();
                         $schedule(thisGoroutine);
                     };
@@ -373,7 +435,7 @@ var $select = comms => {
                         if (comm[0].$closed) {
                             $throwRuntimeError("send on closed channel");
                         }
-                        f.selection = [i];
+                        selected = [i];
                         removeFromQueues();
                         $schedule(thisGoroutine);
                         return comm[1];
@@ -384,6 +446,90 @@ var $select = comms => {
             }
         })(i);
     }
-    $block();
-    return f;
+    return $suspend(() => selected);
+};
+
+// Finalizers are implemented on top of FinalizationRegistry, if the host
+// provides it. Otherwise finalizers are never run, which is permitted by the
+// runtime.SetFinalizer contract.
+//
+// The registry callback has no access to the collected object, so $setFinalizer
+// detaches the object's state into a separate storage, which survives the
+// collection and is used to construct an equivalent pointer that is passed to
+// the finalizer.
+var $finalizerRegistry = (typeof FinalizationRegistry !== "undefined") ? new FinalizationRegistry(held => {
+    $go(held.fn, [held.resurrect()]);
+}) : null;
+
+// Returns a function constructing a pointer equivalent to ptr, without
+// retaining ptr itself, or null if ptr's state can't be detached from it.
+var $detachPointerState = ptr => {
+    var typ = ptr.constructor;
+    if (typ.elem !== undefined && typ.elem.kind === $kindStruct) {
+        var proto = Object.getPrototypeOf(ptr);
+        var state = {};
+        var properties = {};
+        for (var i = 0; i < typ.elem.fields.length; i++) {
+            var prop = typ.elem.fields[i].prop;
+            var desc = Object.getOwnPropertyDescriptor(ptr, prop);
+            if (desc === undefined || !desc.configurable || !("value" in desc)) {
+                return null; /* E.g. a proxy created by $pointerOfStructConversion. */
+            }
+            state[prop] = desc.value;
+            (fieldProp => {
+                properties[fieldProp] = {
+                    get() { return state[fieldProp]; },
+                    set(value) { state[fieldProp] = value; },
+                    enumerable: true,
+                    configurable: true,
+                };
+            })(prop);
+        }
+        Object.defineProperties(ptr, properties);
+        return () => {
+            var resurrected = Object.create(proto, properties);
+            resurrected.$val = resurrected;
+            return resurrected;
+        };
+    }
+    if (ptr.$get !== undefined && ptr.$set !== undefined) {
+        var get = ptr.$get, set = ptr.$set, target = ptr.$target;
+        return () => { return new typ(get, set, target); };
+    }
+    return null;
+};
+
+var $setFinalizer = (ptr, fn) => {
+    if ($finalizerRegistry === null || !Object.isExtensible(ptr)) {
+        return;
+    }
+    var finalizer = ptr.$finalizer;
+    if (fn === null) {
+        if (finalizer !== undefined && finalizer.active) {
+            $finalizerRegistry.unregister(finalizer);
+            finalizer.active = false;
+        }
+        return;
+    }
+    if (finalizer !== undefined && finalizer.active) {
+        $throwRuntimeError("runtime.SetFinalizer: finalizer already set");
+    }
+    if (finalizer === undefined) {
+        var resurrect = $detachPointerState(ptr);
+        if (resurrect === null) {
+            return;
+        }
+        finalizer = { resurrect, active: false };
+        Object.defineProperty(ptr, "$finalizer", { value: finalizer });
+    }
+    finalizer.active = true;
+    $finalizerRegistry.register(ptr, { fn, resurrect: finalizer.resurrect }, finalizer);
+};
+
+// Creating a WeakRef keeps its target alive until the end of the current job,
+// which is the closest equivalent of runtime.KeepAlive the host provides.
+var $keepAlive = x => {
+    if ($finalizerRegistry !== null && typeof x === "object" && x !== null) {
+        new WeakRef(x);
+    }
 };
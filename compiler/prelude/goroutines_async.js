@@ -0,0 +1,214 @@
+// Goroutine support for the asyncawait experiment, which replaces parts of
+// goroutines.js. Blocking functions are compiled into async functions, which
+// await blocking calls in place instead of returning a frame to resume them.
+//
+// A goroutine runs one step at a time: from the moment it's scheduled until
+// it blocks or exits. The scheduler awaits the step before running the next
+// goroutine, so $curGoroutine remains valid across the awaits in between.
+
+/* The constructor of async functions, which blocking Go functions are compiled into. */
+var $AsyncFunction = (async () => { }).constructor;
+
+/* Returns true if r, the result of calling f, is the promise of a blocking Go function, which the caller must await. The decision is made from the callee, so that a JavaScript promise returned by a function that doesn't block, for example as a *js.Object, is passed on as it is. Functions which forward calls to a callee only known when called, such as promoted methods of embedded interfaces, mark the promises they pass on with $forwardCall. */
+var $isAsyncCall = (f, r) => f instanceof $AsyncFunction || (r instanceof Promise && r.$goAsync === true);
+
+/* Returns r, the result of calling f on behalf of another function, marked as a promise to await if f is a blocking Go function, see $isAsyncCall. */
+var $forwardCall = (f, r) => {
+    if ($isAsyncCall(f, r)) {
+        r.$goAsync = true;
+    }
+    return r;
+};
+
+/* Runs deferred calls of a function when it returns or a panic unwinds its frame. Unlike with resumable blocking functions, each function runs its own deferred calls, and a panic continues unwinding only once they are done. Promises returned by deferred calls are yielded, so that the async caller may await them. */
+var $deferredCalls = function* (deferred, jsErr) {
+    var goroutine = $curGoroutine;
+    var panicErr = null, panicValue;
+    if (jsErr !== null) {
+        panicErr = jsErr;
+        panicValue = $panicValueOf(jsErr);
+    }
+    var call;
+    while ((call = deferred.pop()) !== undefined) {
+        var panicking = panicValue !== undefined;
+        var outerPanicStackDepth = $panicStackDepth;
+        var outerPanicValue = $panicValue;
+        if (panicking) {
+            $panicStackDepth = $getStackDepth();
+            $panicValue = panicValue;
+        }
+        try {
+            var r = call[0].apply(call[2], call[1]);
+            if ($isAsyncCall(call[0], r)) {
+                yield r;
+            }
+            if (panicking && $panicStackDepth === null) {
+                /* error was recovered */
+                panicErr = null;
+                panicValue = undefined;
+            }
+        } catch (err) {
+            if (err !== null) { /* null is thrown by runtime.Goexit() */
+                /* The deferred call panicked, the new panic replaces the current one. */
+                panicErr = err;
+                panicValue = $panicValueOf(err);
+            }
+        } finally {
+            if (panicking) {
+                $panicStackDepth = outerPanicStackDepth;
+                $panicValue = outerPanicValue;
+            }
+        }
+    }
+    if (panicErr !== null) {
+        throw panicErr;
+    }
+    if (goroutine.exit) {
+        throw null; /* continue unwinding the goroutine, see runtime.Goexit() */
+    }
+};
+
+/* Returns the Go panic value represented by a JavaScript exception. */
+var $panicValueOf = err => {
+    if (err !== undefined && err !== null && err.$goPanic !== undefined) {
+        return err.$goPanic.value;
+    }
+    return new $jsErrorPtr(err);
+};
+
+var $callDeferred = (deferred, jsErr) => {
+    var calls = $deferredCalls(deferred, jsErr);
+    while (!calls.next().done) { }
+};
+
+var $callDeferredAsync = async (deferred, jsErr) => {
+    var calls = $deferredCalls(deferred, jsErr);
+    var step = calls.next();
+    while (!step.done) {
+        var result;
+        try {
+            result = await step.value;
+        } catch (err) {
+            step = calls.throw(err);
+            continue;
+        }
+        step = calls.next(result);
+    }
+};
+
+var $panic = value => {
+    throw $panicError(value);
+};
+
+var $go = (fun, args) => {
+    $createdGoroutines++;
+    $totalGoroutines++;
+    $awakeGoroutines++;
+    var $runGoroutine = async () => {
+        try {
+            var r = fun(...args);
+            if ($isAsyncCall(fun, r)) {
+                await r;
+            }
+            $goroutine.exit = true;
+        } catch (err) {
+            if (!$goroutine.exit) {
+                await $reportPanic(err, $goroutine);
+                $goroutine.fail(err);
+                return;
+            }
+        }
+        $goroutine.endStep();
+    };
+    var $goroutine = () => new Promise((endStep, fail) => {
+        $curGoroutine = $goroutine;
+        $goroutine.endStep = endStep;
+        $goroutine.fail = fail;
+        var resume = $goroutine.resume;
+        $goroutine.resume = undefined;
+        if (resume !== undefined) {
+            resume();
+        } else {
+            $runGoroutine();
+        }
+    }).finally(() => {
+        $curGoroutine = $noGoroutine;
+        if ($goroutine.exit) { /* also set by runtime.Goexit() */
+            $totalGoroutines--;
+            $goroutine.asleep = true;
+        }
+        if ($goroutine.asleep) {
+            $awakeGoroutines--;
+            if (!$mainFinished && $awakeGoroutines === 0 && $checkForDeadlock && $exportedFunctions === 0) {
+                console.error("fatal error: all goroutines are asleep - deadlock!");
+                if ($global.process !== undefined) {
+                    $global.process.exit(2);
+                }
+            }
+        }
+    });
+    $goroutine.id = $createdGoroutines;
+    $goroutine.asleep = false;
+    $goroutine.exit = false;
+    $goroutine.deferStack = [];
+    $goroutine.panicStack = [];
+    $schedule($goroutine);
+};
+
+var $schedulerRunning = false;
+var $runScheduled = async () => {
+    if ($schedulerRunning) {
+        // A goroutine step is in progress, try again later.
+        setTimeout($runScheduled);
+        return;
+    }
+    $schedulerRunning = true;
+    // See goroutines.js for why the timer is queued preemptively.
+    var nextRun = setTimeout($runScheduled);
+    try {
+        var start = Date.now();
+        var r;
+        while ((r = $scheduled.shift()) !== undefined) {
+            $goroutineSwitches++;
+            await r();
+            var elapsed = Date.now() - start;
+            if (elapsed > 4 || elapsed < 0) { break; }
+        }
+    } finally {
+        $schedulerRunning = false;
+        if ($scheduled.length == 0) {
+            clearTimeout(nextRun);
+        }
+    }
+};
+
+var $schedule = goroutine => {
+    if (goroutine.asleep) {
+        goroutine.asleep = false;
+        $awakeGoroutines++;
+    }
+    $scheduled.push(goroutine);
+    if (!$schedulerRunning) {
+        $runScheduled();
+    }
+};
+
+/* Blocks the current goroutine, ending its step. Returns a promise resolved once the goroutine is scheduled again. */
+var $block = () => {
+    if ($curGoroutine === $noGoroutine) {
+        $throwRuntimeError("cannot block in JavaScript callback, fix by wrapping code in goroutine");
+    }
+    var goroutine = $curGoroutine;
+    goroutine.asleep = true;
+    return new Promise(resume => {
+        goroutine.resume = resume;
+        goroutine.endStep();
+    });
+};
+
+/* Blocks the current goroutine and returns a promise of the result of resume(), which is called once the goroutine is scheduled again. The promise is marked to be awaited by the caller of the blocking primitive, such as $recv, see $isAsyncCall. */
+var $suspend = resume => {
+    var promise = $block().then(resume);
+    promise.$goAsync = true;
+    return promise;
+};
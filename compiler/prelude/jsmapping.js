@@ -40,6 +40,9 @@ var $externalize = (v, t, makeWrapper) => {
             return v;
         case $kindInt64:
         case $kindUint64:
+            if ($bigInt64) {
+                return v;
+            }
             return $flatten64(v);
         case $kindArray:
             if ($needsExternalization(t.elem)) {
@@ -56,10 +59,23 @@ var $externalize = (v, t, makeWrapper) => {
                 return v.$val.object;
             }
             return $externalize(v.$val, v.constructor, makeWrapper);
+        case $kindChan:
+            if (v === $chanNil) {
+                return null;
+            }
+            return $externalizeChan(v, t, makeWrapper);
         case $kindMap:
             if (v.keys === undefined) {
                 return null;
             }
+            if (t.key.kind !== $kindString) {
+                /* Keys of other types can't be represented as property names without loss, use a Map. */
+                var m = new Map();
+                for (var entry of v.values()) {
+                    m.set($externalize(entry.k, t.key, makeWrapper), $externalize(entry.v, t.elem, makeWrapper));
+                }
+                return m;
+            }
             var m = {};
             var keys = Array.from(v.keys());
             for (var i = 0; i < keys.length; i++) {
@@ -100,6 +116,9 @@ var $externalize = (v, t, makeWrapper) => {
         case $kindStruct:
             var timePkg = $packages["time"];
             if (timePkg !== undefined && v.constructor === timePkg.Time.ptr) {
+                if ($bigInt64) {
+                    return new Date(Number(v.UnixNano() / BigInt(1000000)));
+                }
                 var milli = $div64(v.UnixNano(), new $Int64(0, 1000000));
                 return new Date($flatten64(milli));
             }
@@ -137,25 +156,41 @@ var $externalize = (v, t, makeWrapper) => {
             }
 
             o = {};
-            for (var i = 0; i < t.fields.length; i++) {
-                var f = t.fields[i];
-                if (!f.exported) {
+            var fields = $jsFields(t);
+            for (var i = 0; i < fields.length; i++) {
+                var f = fields[i].field, fv = v[f.prop];
+                if (fields[i].omitempty && $isEmptyValue(fv, f.typ)) {
                     continue;
                 }
-                o[f.name] = $externalize(v[f.prop], f.typ, makeWrapper);
+                o[fields[i].name] = $externalize(fv, f.typ, makeWrapper);
             }
             return o;
     }
     $throwRuntimeError("cannot externalize " + t.string);
 };
 
-var $externalizeFunction = (v, t, passThis, makeWrapper) => {
+var $callAsync; /* set by package "github.com/gopherjs/gopherjs/js" */
+var $externalizeFunction = (v, t, passThis, makeWrapper, async) => {
     if (v === $throwNilPointerError) {
         return null;
     }
-    if (v.$externalizeWrapper === undefined) {
+    var wrapperProp = async ? "$externalizeAsyncWrapper" : "$externalizeWrapper";
+    if (v[wrapperProp] === undefined) {
         $checkForDeadlock = false;
-        v.$externalizeWrapper = function () {
+        var externalizeResult = result => {
+            switch (t.results.length) {
+                case 0:
+                    return;
+                case 1:
+                    return $externalize($copyIfRequired(result, t.results[0]), t.results[0], makeWrapper);
+                default:
+                    for (var i = 0; i < t.results.length; i++) {
+                        result[i] = $externalize($copyIfRequired(result[i], t.results[i]), t.results[i], makeWrapper);
+                    }
+                    return result;
+            }
+        };
+        v[wrapperProp] = function () {
             var args = [];
             for (var i = 0; i < t.params.length; i++) {
                 if (t.variadic && i === t.params.length - 1) {
@@ -168,21 +203,59 @@ var $externalizeFunction = (v, t, passThis, makeWrapper) => {
                 }
                 args.push($internalize(arguments[i], t.params[i], makeWrapper));
             }
-            var result = v.apply(passThis ? this : undefined, args);
-            switch (t.results.length) {
-                case 0:
-                    return;
-                case 1:
-                    return $externalize($copyIfRequired(result, t.results[0]), t.results[0], makeWrapper);
-                default:
-                    for (var i = 0; i < t.results.length; i++) {
-                        result[i] = $externalize($copyIfRequired(result[i], t.results[i]), t.results[i], makeWrapper);
-                    }
-                    return result;
+            var self = passThis ? this : undefined;
+            if (async) {
+                /* Run the function in a new goroutine, so that it may block, and return a promise of its results. */
+                return $callAsync(() => $forwardCall(v, v.apply(self, args))).then(externalizeResult);
+            }
+            var result = v.apply(self, args);
+            if ($isAsyncCall(v, result)) {
+                return result.then(externalizeResult);
             }
+            return externalizeResult(result);
+        };
+    }
+    return v[wrapperProp];
+};
+
+/* Returns a JS object giving access to the channel v of type t. Operations are asynchronous: send(value) returns a promise resolved once the value was accepted by the channel, recv() returns a promise of an object {value, ok} with the received value and whether the channel was still open, and close() closes the channel. Sending and receiving run in a new goroutine with $send and $recv, so they follow the Go channel semantics. Receivable channels are also async iterables over the received values, ending when the channel is closed. The object is reused for subsequent externalizations of the same channel and direction. */
+var $externalizeChan = (v, t, makeWrapper) => {
+    var wrapperProp = t.sendOnly ? "$externalizeSendWrapper" : (t.recvOnly ? "$externalizeRecvWrapper" : "$externalizeWrapper");
+    if (v[wrapperProp] !== undefined) {
+        return v[wrapperProp];
+    }
+    $checkForDeadlock = false;
+    var o = {};
+    Object.defineProperty(o, "$chan", { value: v });
+    Object.defineProperty(o, "$chanType", { value: t });
+    if (!t.recvOnly) {
+        o.send = value => {
+            var x = $internalize(value, t.elem, undefined, undefined, makeWrapper);
+            return $callAsync(() => $send(v, x)).then(() => undefined);
+        };
+        o.close = () => {
+            $close(v);
         };
     }
-    return v.$externalizeWrapper;
+    if (!t.sendOnly) {
+        o.recv = () => {
+            return $callAsync(() => $recv(v)).then(r => {
+                return { value: $externalize(r[0], t.elem, makeWrapper), ok: r[1] };
+            });
+        };
+        o[Symbol.asyncIterator] = () => {
+            return {
+                next() {
+                    return o.recv().then(r => {
+                        return r.ok ? { value: r.value, done: false } : { value: undefined, done: true };
+                    });
+                },
+                [Symbol.asyncIterator]() { return this; }
+            };
+        };
+    }
+    v[wrapperProp] = o;
+    return o;
 };
 
 var $internalize = (v, t, recv, seen, makeWrapper) => {
@@ -200,7 +273,7 @@ var $internalize = (v, t, recv, seen, makeWrapper) => {
         if (!(v !== null && v !== undefined && v.constructor === Date)) {
             $throwRuntimeError("cannot internalize time.Time from " + typeof v + ", must be Date");
         }
-        return timePkg.Unix(new $Int64(0, 0), new $Int64(0, v.getTime() * 1000000));
+        return timePkg.Unix($int64(0, $Int64), $int64(v.getTime() * 1000000, $Int64));
     }
 
     // Cache for values we've already internalized in order to deal with circular
@@ -231,7 +304,10 @@ var $internalize = (v, t, recv, seen, makeWrapper) => {
             return parseInt(v) >>> 0;
         case $kindInt64:
         case $kindUint64:
-            return new t(0, v);
+            if ($bigInt64 && typeof v === "bigint") {
+                return t.kind === $kindInt64 ? BigInt.asIntN(64, v) : BigInt.asUintN(64, v);
+            }
+            return $int64(Number(v), t);
         case $kindFloat32:
         case $kindFloat64:
             return parseFloat(v);
@@ -311,18 +387,42 @@ var $internalize = (v, t, recv, seen, makeWrapper) => {
                     return new funcType($internalize(v, funcType, makeWrapper));
                 case Number:
                     return new $Float64(parseFloat(v));
+                case BigInt:
+                    return $bigInt64 ? new $Int64($internalize(v, $Int64)) : $internalize(v, $Int64);
                 case String:
                     return new $String($internalize(v, $String, makeWrapper));
+                case Map:
+                    var anyMapType = $mapType($emptyInterface, $emptyInterface);
+                    return new anyMapType($internalize(v, anyMapType, recv, seen, makeWrapper));
                 default:
+                    if (v.$chan !== undefined) {
+                        return new v.$chanType(v.$chan);
+                    }
                     if ($global.Node && v instanceof $global.Node) {
                         return new $jsObjectPtr(v);
                     }
                     var mapType = $mapType($String, $emptyInterface);
                     return new mapType($internalize(v, mapType, recv, seen, makeWrapper));
             }
+        case $kindChan:
+            if (v === null || v === undefined) {
+                return $chanNil;
+            }
+            var ct = v.$chanType;
+            if (v.$chan === undefined || ct.elem !== t.elem || (ct !== t && (ct.sendOnly || ct.recvOnly))) {
+                $throwRuntimeError("cannot internalize " + t.string + " from " + (ct !== undefined ? ct.string : typeof v));
+            }
+            return v.$chan;
         case $kindMap:
             var m = new Map();
             seen.get(t).set(v, m);
+            if (v instanceof Map) {
+                for (var [key, value] of v) {
+                    var k = $internalize(key, t.key, recv, seen, makeWrapper);
+                    m.set(t.key.keyFor(k), { k, v: $internalize(value, t.elem, recv, seen, makeWrapper) });
+                }
+                return m;
+            }
             var keys = $keys(v);
             for (var i = 0; i < keys.length; i++) {
                 var k = $internalize(keys[i], t.key, recv, seen, makeWrapper);
@@ -391,19 +491,150 @@ var $internalize = (v, t, recv, seen, makeWrapper) => {
                 return o;
             }
             var n = new t.ptr();
-            for (var i = 0; i < t.fields.length; i++) {
-                var f = t.fields[i];
-                if (!f.exported) {
-                    continue;
-                }
-                var jsProp = v[f.name];
-                n[f.prop] = $internalize(jsProp, f.typ, recv, seen, makeWrapper);
+            var fields = $jsFields(t);
+            for (var i = 0; i < fields.length; i++) {
+                var f = fields[i].field;
+                n[f.prop] = $internalize(v[fields[i].name], f.typ, recv, seen, makeWrapper);
             }
             return n;
     }
     $throwRuntimeError("cannot internalize " + t.string);
 };
 
+/* $jsFieldNaming maps the strategies supported by the gopherjs:js-naming
+   directive to functions converting Go field names to JavaScript property names. */
+var $jsFieldNaming = {
+    camelCase: name => {
+        var n = 0;
+        while (n < name.length && name[n] >= "A" && name[n] <= "Z") {
+            n++;
+        }
+        if (n > 1 && n < name.length && name[n] >= "a" && name[n] <= "z") {
+            n--; /* Keep the capital letter starting the next word, e.g. in "HTTPServer". */
+        }
+        return name.substring(0, n).toLowerCase() + name.substring(n);
+    },
+    snake_case: name => {
+        return name.replace(/([a-z0-9])([A-Z])|([A-Z])([A-Z][a-z])/g, (m, a, b, c, d) => {
+            return a !== undefined ? a + "_" + b : c + "_" + d;
+        }).toLowerCase();
+    },
+};
+
+/* $jsFields returns the exported fields of the struct type along with the
+   JavaScript property names they map to and their options, as specified by
+   `js:"name,omitempty"` tags. Fields tagged with `js:"-"` are omitted, and
+   untagged fields are named according to the js-naming strategy of the package
+   declaring the type. */
+var $jsFields = t => {
+    if (t.jsFields !== undefined) {
+        return t.jsFields;
+    }
+    var pkg = t.named ? $packages[t.pkg] : undefined;
+    var naming = pkg !== undefined ? $jsFieldNaming[pkg.$jsNaming] : undefined;
+    var fields = [];
+    for (var i = 0; i < t.fields.length; i++) {
+        var f = t.fields[i];
+        if (!f.exported) {
+            continue;
+        }
+        var tag = $structTagLookup(f.tag, "js");
+        if (tag === "-") {
+            continue;
+        }
+        var options = tag.split(",");
+        var name = options[0];
+        if (name === "") {
+            name = naming !== undefined && !f.embedded ? naming(f.name) : f.name;
+        }
+        fields.push({ field: f, name: name, omitempty: options.indexOf("omitempty", 1) !== -1 });
+    }
+    t.jsFields = fields;
+    return fields;
+};
+
+/* $structTagLookup returns the value associated with the key in the struct
+   tag, same as reflect.StructTag.Get(). */
+var $structTagLookup = (tag, key) => {
+    while (tag !== "") {
+        var i = 0;
+        while (i < tag.length && tag[i] === " ") {
+            i++;
+        }
+        tag = tag.substring(i);
+        if (tag === "") {
+            break;
+        }
+        i = 0;
+        while (i < tag.length && tag[i] > " " && tag[i] !== ":" && tag[i] !== "\"" && tag[i] !== "\x7f") {
+            i++;
+        }
+        if (i === 0 || i + 1 >= tag.length || tag[i] !== ":" || tag[i + 1] !== "\"") {
+            break;
+        }
+        var name = tag.substring(0, i);
+        tag = tag.substring(i + 1);
+        i = 1;
+        while (i < tag.length && tag[i] !== "\"") {
+            if (tag[i] === "\\") {
+                i++;
+            }
+            i++;
+        }
+        if (i >= tag.length) {
+            break;
+        }
+        var qvalue = tag.substring(0, i + 1);
+        tag = tag.substring(i + 1);
+        if (name === key) {
+            try {
+                return JSON.parse(qvalue);
+            } catch (e) {
+                return "";
+            }
+        }
+    }
+    return "";
+};
+
+/* $isEmptyValue reports whether v is the zero value of the type, or an empty
+   array, slice, map or string, for the purposes of the omitempty option. */
+var $isEmptyValue = (v, t) => {
+    switch (t.kind) {
+        case $kindBool:
+            return !v;
+        case $kindInt64:
+        case $kindUint64:
+            if ($bigInt64) {
+                return v === BigInt(0);
+            }
+            return v.$high === 0 && v.$low === 0;
+        case $kindComplex64:
+        case $kindComplex128:
+            return v.$real === 0 && v.$imag === 0;
+        case $kindString:
+            return v === "";
+        case $kindArray:
+            return t.len === 0;
+        case $kindSlice:
+            return v.$length === 0;
+        case $kindMap:
+            return v.keys === undefined || v.size === 0;
+        case $kindPtr:
+            return v === t.nil;
+        case $kindInterface:
+            return v === $ifaceNil;
+        case $kindFunc:
+            return v === $throwNilPointerError;
+        case $kindChan:
+            return v === $chanNil;
+        case $kindStruct:
+            return false;
+        default:
+            return v === 0;
+    }
+};
+
 var $copyIfRequired = (v, typ) => {
     // interface values
     if (v && v.constructor && v.constructor.copy) {
@@ -427,3 +658,280 @@ var $isASCII = s => {
     }
     return true;
 };
+
+/* $jsonError throws the exception reported by js.MarshalObject and
+   js.UnmarshalObject when a value can't be converted. */
+var $jsonError = msg => {
+    var err = new Error("js: " + msg);
+    err.$jsonError = true;
+    throw err;
+};
+
+/* $jsonTypeError reports that the JavaScript value v can't be converted to the Go type t. */
+var $jsonTypeError = (v, t) => {
+    $jsonError("cannot unmarshal " + (v === null ? "null" : Array.isArray(v) ? "array" : typeof v) + " into Go value of type " + t.string);
+};
+
+/* $jsonFields returns the fields of the struct type t that are converted by
+   js.MarshalObject and js.UnmarshalObject, following the rules of encoding/json
+   for json struct tags and embedded structs. Embedded structs without a tag
+   name are inlined and come first, so that the fields of the outer struct take
+   precedence over the promoted ones. The result is cached in t.jsonFields. */
+var $jsonFields = t => {
+    if (t.jsonFields !== undefined) {
+        return t.jsonFields;
+    }
+    var inlined = [], fields = [];
+    for (var i = 0; i < t.fields.length; i++) {
+        var f = t.fields[i];
+        var tag = $structTagLookup(f.tag, "json");
+        if (tag === "-" || f.typ === $jsObjectPtr && f.embedded) {
+            continue;
+        }
+        var opts = tag.split(","), name = opts[0];
+        var ft = f.typ.kind === $kindPtr ? f.typ.elem : f.typ;
+        if (f.embedded && name === "" && ft.kind === $kindStruct) {
+            inlined.push({ field: f, inline: ft });
+            continue;
+        }
+        if (!f.exported) {
+            continue;
+        }
+        fields.push({ field: f, name: name || f.name, omitempty: opts.indexOf("omitempty", 1) !== -1 });
+    }
+    t.jsonFields = inlined.concat(fields);
+    return t.jsonFields;
+};
+
+/* $jsonEncode converts the Go value v of type t to a plain JavaScript value. */
+var $jsonEncode = (v, t) => {
+    if (t === $jsObjectPtr) {
+        return v;
+    }
+    switch (t.kind) {
+        case $kindBool:
+        case $kindInt:
+        case $kindInt8:
+        case $kindInt16:
+        case $kindInt32:
+        case $kindUint:
+        case $kindUint8:
+        case $kindUint16:
+        case $kindUint32:
+        case $kindUintptr:
+        case $kindFloat32:
+        case $kindFloat64:
+            return v;
+        case $kindInt64:
+        case $kindUint64:
+            return $flatten64(v);
+        case $kindString:
+            return $externalize(v, t);
+        case $kindArray:
+            return $mapArray(v, e => $jsonEncode(e, t.elem));
+        case $kindSlice:
+            if (v === t.nil) {
+                return null;
+            }
+            if (t.elem.kind === $kindUint8) {
+                return v.$array.slice(v.$offset, v.$offset + v.$length);
+            }
+            var a = new Array(v.$length);
+            for (var i = 0; i < v.$length; i++) {
+                a[i] = $jsonEncode(v.$array[v.$offset + i], t.elem);
+            }
+            return a;
+        case $kindMap:
+            if (v.keys === undefined) {
+                return null;
+            }
+            var o = {};
+            for (var entry of v.values()) {
+                var k = t.key.kind === $kindString ? $externalize(entry.k, t.key) : String($jsonEncode(entry.k, t.key));
+                o[k] = $jsonEncode(entry.v, t.elem);
+            }
+            return o;
+        case $kindPtr:
+            if (v === t.nil) {
+                return null;
+            }
+            return $jsonEncode(t.elem.kind === $kindStruct ? v : v.$get(), t.elem);
+        case $kindInterface:
+            if (v === $ifaceNil) {
+                return null;
+            }
+            return $jsonEncode(v.$val, v.constructor);
+        case $kindStruct:
+            return $jsonEncodeStruct(v, t, {});
+    }
+    $jsonError("unsupported type: " + t.string);
+};
+
+/* $jsonEncodeStruct sets the properties of the object o from the fields of the
+   struct value v of type t and returns o. It uses the converter generated by
+   the compiler for t if there is one. */
+var $jsonEncodeStruct = (v, t, o) => {
+    if (t.jsonEncode !== undefined) {
+        return t.jsonEncode(v, o);
+    }
+    var fields = $jsonFields(t);
+    for (var i = 0; i < fields.length; i++) {
+        var f = fields[i], fv = v[f.field.prop];
+        if (f.inline !== undefined) {
+            if (fv !== f.field.typ.nil) {
+                $jsonEncodeStruct(fv, f.inline, o);
+            }
+            continue;
+        }
+        if (f.omitempty && $isEmptyValue(fv, f.field.typ)) {
+            continue;
+        }
+        o[f.name] = $jsonEncode(fv, f.field.typ);
+    }
+    return o;
+};
+
+/* $jsonDecode converts the plain JavaScript value v to a Go value of type t.
+   Structs, and the values pointed to by non-nil pointers, are decoded into
+   old, the current Go value, as encoding/json does. JavaScript null leaves
+   old unchanged, except for types that have nil as their zero value. */
+var $jsonDecode = (v, t, old) => {
+    if (t === $jsObjectPtr) {
+        return v;
+    }
+    if (v === null || v === undefined) {
+        switch (t.kind) {
+            case $kindInterface:
+            case $kindMap:
+            case $kindPtr:
+            case $kindSlice:
+                return t.zero();
+        }
+        return old !== undefined ? old : t.zero();
+    }
+    switch (t.kind) {
+        case $kindBool:
+            if (typeof v !== "boolean") {
+                $jsonTypeError(v, t);
+            }
+            return v;
+        case $kindInt:
+        case $kindInt8:
+        case $kindInt16:
+        case $kindInt32:
+        case $kindUint:
+        case $kindUint8:
+        case $kindUint16:
+        case $kindUint32:
+        case $kindUintptr:
+        case $kindInt64:
+        case $kindUint64:
+            if (!Number.isInteger(v) && typeof v !== "bigint") {
+                $jsonTypeError(v, t);
+            }
+            return $internalize(v, t);
+        case $kindFloat32:
+        case $kindFloat64:
+            if (typeof v !== "number") {
+                $jsonTypeError(v, t);
+            }
+            return v;
+        case $kindString:
+            if (typeof v !== "string") {
+                $jsonTypeError(v, t);
+            }
+            return $internalize(v, t);
+        case $kindArray:
+            if (!Array.isArray(v)) {
+                $jsonTypeError(v, t);
+            }
+            var a = t.zero();
+            for (var i = 0; i < t.len && i < v.length; i++) {
+                a[i] = $jsonDecode(v[i], t.elem, a[i]);
+            }
+            return a;
+        case $kindSlice:
+            if (t.elem.kind === $kindUint8 && v instanceof Uint8Array) {
+                return new t(v.slice());
+            }
+            if (!Array.isArray(v)) {
+                $jsonTypeError(v, t);
+            }
+            return new t(v.map(e => $jsonDecode(e, t.elem)));
+        case $kindMap:
+            if (typeof v !== "object" || Array.isArray(v)) {
+                $jsonTypeError(v, t);
+            }
+            var m = new Map();
+            var keys = Object.keys(v);
+            for (var i = 0; i < keys.length; i++) {
+                var k = t.key.kind === $kindString ? keys[i] : Number(keys[i]);
+                k = $jsonDecode(k, t.key);
+                m.set(t.key.keyFor(k), { k, v: $jsonDecode(v[keys[i]], t.elem) });
+            }
+            return m;
+        case $kindPtr:
+            if (t.elem.kind === $kindStruct) {
+                return $jsonDecodeStruct(v, t.elem, old === undefined || old === t.nil ? new t.elem.ptr() : old);
+            }
+            if (old !== undefined && old !== t.nil) {
+                old.$set($jsonDecode(v, t.elem, old.$get()));
+                return old;
+            }
+            return $newDataPointer($jsonDecode(v, t.elem), t);
+        case $kindInterface:
+            if (t.methods.length !== 0) {
+                $jsonTypeError(v, t);
+            }
+            return $internalize(v, t);
+        case $kindStruct:
+            return $jsonDecodeStruct(v, t, old !== undefined ? old : new t.ptr());
+    }
+    $jsonError("unsupported type: " + t.string);
+};
+
+/* $jsonDecodeStruct sets the fields of the struct value v of type t from the
+   properties of the object o and returns v. It uses the converter generated
+   by the compiler for t if there is one. */
+var $jsonDecodeStruct = (o, t, v) => {
+    if (typeof o !== "object" || Array.isArray(o)) {
+        $jsonTypeError(o, t);
+    }
+    if (t.jsonDecode !== undefined) {
+        return t.jsonDecode(o, v);
+    }
+    var fields = $jsonFields(t);
+    for (var i = 0; i < fields.length; i++) {
+        var f = fields[i], prop = f.field.prop;
+        if (f.inline !== undefined) {
+            if (v[prop] === f.field.typ.nil) {
+                v[prop] = new f.inline.ptr();
+            }
+            $jsonDecodeStruct(o, f.inline, v[prop]);
+            continue;
+        }
+        var x = o[f.name];
+        if (x !== undefined) {
+            v[prop] = $jsonDecode(x, f.field.typ, v[prop]);
+        }
+    }
+    return v;
+};
+
+/* $jsonMarshal implements js.MarshalObject. */
+var $jsonMarshal = v => $jsonEncode(v, $emptyInterface);
+
+/* $jsonUnmarshal implements js.UnmarshalObject. */
+var $jsonUnmarshal = (o, p) => {
+    if (p === $ifaceNil) {
+        $jsonError("UnmarshalObject(nil)");
+    }
+    var t = p.constructor;
+    if (t.kind !== $kindPtr) {
+        $jsonError("UnmarshalObject(non-pointer " + t.string + ")");
+    }
+    if (p === t.nil) {
+        $jsonError("UnmarshalObject(nil " + t.string + ")");
+    }
+    $jsonDecode(o, t, p);
+};
@@ -31,9 +31,43 @@ var $floatKey = f => {
 };
 
 var $flatten64 = x => {
+    if ($bigInt64) {
+        return Number(x);
+    }
     return x.$high * 4294967296 + x.$low;
 };
 
+/* $int64 returns the int64 or uint64 value of type t closest to the number x,
+   in the representation selected by the bigint64 experiment. */
+var $int64 = (x, t) => {
+    if ($bigInt64) {
+        var b = $bigIntFromFloat(x);
+        return t.kind === $kindInt64 ? BigInt.asIntN(64, b) : BigInt.asUintN(64, b);
+    }
+    return new t(0, x);
+};
+
+/* $bigIntFromFloat converts the number x to a BigInt, discarding the
+   fractional part. NaN and infinities, which are implementation-specific in
+   Go, become zero. */
+var $bigIntFromFloat = x => {
+    return isFinite(x) ? BigInt(Math.trunc(x)) : BigInt(0);
+};
+
+var $divBigInt = (x, y) => {
+    if (y === BigInt(0)) {
+        $throwRuntimeError("integer divide by zero");
+    }
+    return x / y;
+};
+
+var $remBigInt = (x, y) => {
+    if (y === BigInt(0)) {
+        $throwRuntimeError("integer divide by zero");
+    }
+    return x % y;
+};
+
 var $shiftLeft64 = (x, y) => {
     if (y === 0) {
         return x;
@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"runtime/debug"
 	"strings"
+
+	"github.com/gopherjs/gopherjs/internal/experiments"
 )
 
 //go:embed prelude.js
@@ -22,6 +24,9 @@ var jsmapping string
 //go:embed goroutines.js
 var goroutines string
 
+//go:embed goroutines_async.js
+var goroutinesAsync string
+
 type PreludeFile struct {
 	Name   string
 	Source string
@@ -41,6 +46,9 @@ func PreludeFiles() (files []PreludeFile) {
 	add(`numberic.js`, numeric)
 	add(`types.js`, types)
 	add(`goroutines.js`, goroutines)
+	if experiments.Env.AsyncAwait {
+		add(`goroutines_async.js`, goroutinesAsync)
+	}
 	add(`jsmapping.js`, jsmapping)
 	return
 }
@@ -68,13 +68,22 @@ if (!$global.fs) {
 }
 
 var $linknames = {} // Collection of functions referenced by a go:linkname directive.
+var $goPositions = null; // Go position table used by runtime.Callers(), set by the compiler if enabled.
 var $packages = {}, $idCounter = 0;
 var $keys = m => { return m ? Object.keys(m) : []; };
 var $flushConsole = () => { };
 var $throwRuntimeError; /* set by package "runtime" */
 var $throwNilPointerError = () => { $throwRuntimeError("invalid memory address or nil pointer dereference"); };
 var $call = (fn, rcvr, args) => { return fn.apply(rcvr, args); };
-var $makeFunc = fn => { return function(...args) { return $externalize(fn(this, new ($sliceType($jsObjectPtr))($global.Array.prototype.slice.call(args, []))), $emptyInterface); }; };
+var $makeFunc = fn => {
+    return function(...args) {
+        var result = fn(this, new ($sliceType($jsObjectPtr))($global.Array.prototype.slice.call(args, [])));
+        if ($isAsyncCall(fn, result)) {
+            return result.then(result => $externalize(result, $emptyInterface));
+        }
+        return $externalize(result, $emptyInterface);
+    };
+};
 var $unused = v => { };
 var $print = console.log;
 // Under Node we can emulate print() more closely by avoiding a newline.
@@ -140,7 +149,7 @@ var $methodExpr = (typ, name) => {
                 if (typ.wrapped) {
                     args[0] = new typ(args[0]);
                 }
-                return Function.call.apply(method, args);
+                return $forwardCall(method, Function.call.apply(method, args));
             } finally {
                 $stackDepthOffset++;
             }
@@ -156,7 +165,8 @@ var $ifaceMethodExpr = name => {
         expr = $ifaceMethodExprs["$" + name] = (...args) => {
             $stackDepthOffset--;
             try {
-                return Function.call.apply(args[0][name], args);
+                var method = args[0][name];
+                return $forwardCall(method, Function.call.apply(method, args));
             } finally {
                 $stackDepthOffset++;
             }
@@ -524,6 +534,9 @@ var $equal = (a, b, type) => {
             return a.$real === b.$real && a.$imag === b.$imag;
         case $kindInt64:
         case $kindUint64:
+            if ($bigInt64) {
+                return a === b;
+            }
             return a.$high === b.$high && a.$low === b.$low;
         case $kindArray:
             if (a.length !== b.length) {
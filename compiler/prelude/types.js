@@ -101,6 +101,12 @@ var $newType = (size, kind, string, named, pkg, exported, constructor) => {
             break;
 
         case $kindInt64:
+            if ($bigInt64) {
+                typ = function (v) { this.$val = v; };
+                typ.wrapped = true;
+                typ.keyFor = $identity;
+                break;
+            }
             typ = function (high, low) {
                 this.$high = (high + Math.floor(Math.ceil(low) / 4294967296)) >> 0;
                 this.$low = low >>> 0;
@@ -110,6 +116,12 @@ var $newType = (size, kind, string, named, pkg, exported, constructor) => {
             break;
 
         case $kindUint64:
+            if ($bigInt64) {
+                typ = function (v) { this.$val = v; };
+                typ.wrapped = true;
+                typ.keyFor = $identity;
+                break;
+            }
             typ = function (high, low) {
                 this.$high = (high + Math.floor(Math.ceil(low) / 4294967296)) >>> 0;
                 this.$low = low >>> 0;
@@ -298,7 +310,8 @@ var $newType = (size, kind, string, named, pkg, exported, constructor) => {
                             if (v.$val === undefined) {
                                 v = new f.typ(v);
                             }
-                            return v[m.prop](...args);
+                            var method = v[m.prop];
+                            return $forwardCall(method, method.apply(v, args));
                         };
                     };
                     fields.forEach(f => {
@@ -347,6 +360,10 @@ var $newType = (size, kind, string, named, pkg, exported, constructor) => {
 
         case $kindInt64:
         case $kindUint64:
+            if ($bigInt64) {
+                typ.zero = () => { return BigInt(0); };
+                break;
+            }
         case $kindComplex64:
         case $kindComplex128:
             var zero = new typ(0, 0);
@@ -0,0 +1,181 @@
+package compiler
+
+import (
+	"io"
+
+	"github.com/gopherjs/gopherjs/compiler/internal/dce"
+	"github.com/gopherjs/gopherjs/internal/sizereport"
+	"github.com/gopherjs/gopherjs/internal/sourcemapx"
+)
+
+// sizeCounter is an io.Writer which adds the size of everything written
+// through it to the current target.
+type sizeCounter struct {
+	io.Writer
+	minified bool
+	target   *sizereport.Size
+}
+
+func (c *sizeCounter) Write(p []byte) (int, error) {
+	n, err := c.Writer.Write(p)
+	size := sizereport.Size{Bytes: n, MinifiedBytes: n}
+	if !c.minified {
+		size.MinifiedBytes = len(removeWhitespace(p[:n], true))
+	}
+	c.target.Add(size)
+	return n, err
+}
+
+// sizeRecorder fills a size report while the program is written, attributing
+// the written code to the packages and declarations it belongs to.
+//
+// All methods are no-ops on a nil recorder, which is used when no report was
+// requested.
+type sizeRecorder struct {
+	report  *sizereport.Report
+	filter  *sourcemapx.Filter
+	counter *sizeCounter
+	sel     *dce.Selector[*Decl]
+
+	pkg   *sizereport.Package
+	decls map[*Decl]*sizereport.Decl
+}
+
+// newSizeRecorder starts counting the code written into the filter. The
+// recorder must be finished to stop counting and to complete the report.
+func newSizeRecorder(report *sizereport.Report, w *sourcemapx.Filter, sel *dce.Selector[*Decl], mainPkg *Archive) *sizeRecorder {
+	if report == nil {
+		return nil
+	}
+	report.MainPackage = mainPkg.ImportPath
+	report.Minified = mainPkg.Minified
+	r := &sizeRecorder{
+		report:  report,
+		filter:  w,
+		counter: &sizeCounter{Writer: w.Writer, minified: mainPkg.Minified, target: &report.Overhead},
+		sel:     sel,
+	}
+	w.Writer = r.counter
+	return r
+}
+
+// finish stops counting and computes the total program size.
+func (r *sizeRecorder) finish() {
+	if r == nil {
+		return
+	}
+	r.filter.Writer = r.counter.Writer
+	r.report.Total = sizereport.Size{}
+	r.report.Total.Add(r.report.Overhead)
+	r.report.Total.Add(r.report.Prelude)
+	for _, pkg := range r.report.Packages {
+		r.report.Total.Add(pkg.Total)
+	}
+}
+
+// writeJS writes hand-written JavaScript source, such as the prelude, and
+// attributes its size to the target.
+//
+// Unlike the generated code, such sources are minified by a proper JS minifier,
+// so the minified size is determined by minifying the source separately.
+func (r *sizeRecorder) writeJS(w *sourcemapx.Filter, target *sizereport.Size, source, path string, minify bool) error {
+	if r == nil {
+		_, err := w.WriteJS(source, path, minify)
+		return err
+	}
+	var size sizereport.Size
+	r.counter.target = &size
+	_, err := w.WriteJS(source, path, minify)
+	r.counter.target = r.overhead()
+	if err != nil {
+		return err
+	}
+	if !minify {
+		size.MinifiedBytes, err = (&sourcemapx.Filter{Writer: io.Discard}).WriteJS(source, path, true)
+		if err != nil {
+			return err
+		}
+	}
+	target.Add(size)
+	return nil
+}
+
+// prelude returns the target for the size of the prelude, or nil if the
+// recorder is nil.
+func (r *sizeRecorder) prelude() *sizereport.Size {
+	if r == nil {
+		return nil
+	}
+	return &r.report.Prelude
+}
+
+// jsFiles returns the target for the size of the current package's .inc.js
+// files, or nil if the recorder is nil.
+func (r *sizeRecorder) jsFiles() *sizereport.Size {
+	if r == nil {
+		return nil
+	}
+	return &r.pkg.JSFiles
+}
+
+// overhead returns the target for code not attributed to anything more
+// specific.
+func (r *sizeRecorder) overhead() *sizereport.Size {
+	if r.pkg != nil {
+		return &r.pkg.Overhead
+	}
+	return &r.report.Overhead
+}
+
+// startPackage attributes the subsequently written code to the package, which
+// keeps the given declarations after dead-code elimination.
+func (r *sizeRecorder) startPackage(pkg *Archive, decls []*Decl) {
+	if r == nil {
+		return
+	}
+	r.pkg = &sizereport.Package{ImportPath: pkg.ImportPath}
+	r.decls = map[*Decl]*sizereport.Decl{}
+	for _, d := range decls {
+		entry := &sizereport.Decl{Name: d.FullName}
+		if retention, ok := r.sel.Retention(d); ok {
+			entry.Retention = sizereport.Retention{Root: retention.Root, Dep: retention.Dep}
+			if retention.KeptBy != nil {
+				entry.Retention.KeptBy = retention.KeptBy.FullName
+			}
+		}
+		r.decls[d] = entry
+		r.pkg.Decls = append(r.pkg.Decls, entry)
+	}
+	r.counter.target = r.overhead()
+}
+
+// endPackage computes the total size of the current package and adds it to
+// the report.
+func (r *sizeRecorder) endPackage() {
+	if r == nil {
+		return
+	}
+	r.pkg.Total.Add(r.pkg.Overhead)
+	r.pkg.Total.Add(r.pkg.JSFiles)
+	for _, d := range r.pkg.Decls {
+		r.pkg.Total.Add(d.Total)
+	}
+	r.report.Packages = append(r.report.Packages, r.pkg)
+	r.pkg, r.decls = nil, nil
+	r.counter.target = r.overhead()
+}
+
+// writeDecl writes code of the declaration d, attributing its size to the
+// given part of the declaration.
+func (r *sizeRecorder) writeDecl(w io.Writer, d *Decl, part string, code []byte) error {
+	if r == nil || len(code) == 0 {
+		_, err := w.Write(code)
+		return err
+	}
+	var size sizereport.Size
+	r.counter.target = &size
+	_, err := w.Write(code)
+	r.counter.target = r.overhead()
+	r.decls[d].Add(part, size)
+	return err
+}
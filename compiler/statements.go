@@ -13,6 +13,7 @@ import (
 	"github.com/gopherjs/gopherjs/compiler/filter"
 	"github.com/gopherjs/gopherjs/compiler/internal/analysis"
 	"github.com/gopherjs/gopherjs/compiler/typesutil"
+	"github.com/gopherjs/gopherjs/internal/experiments"
 )
 
 func (fc *funcContext) translateStmtList(stmts []ast.Stmt) {
@@ -73,6 +74,13 @@ func (fc *funcContext) translateStmt(stmt ast.Stmt, label *types.Label) {
 		if block, ok := ifStmt.Else.(*ast.BlockStmt); ok {
 			defaultClause = &ast.CaseClause{Body: block.List}
 		}
+		caseClauses, defaultClause = fc.foldBranches(caseClauses, defaultClause)
+		if len(caseClauses) == 0 {
+			if defaultClause != nil {
+				fc.translateStmtList(defaultClause.Body)
+			}
+			return
+		}
 		fc.translateBranchingStmt(caseClauses, defaultClause, false, fc.translateExpr, nil, fc.Flattened[s])
 
 	case *ast.SwitchStmt:
@@ -294,7 +302,7 @@ func (fc *funcContext) translateStmt(stmt ast.Stmt, label *types.Label) {
 					},
 				},
 			}
-			fc.Flattened[forStmt] = true
+			fc.flattenBlocking(forStmt)
 			fc.translateStmt(forStmt, label)
 
 		default:
@@ -344,10 +352,11 @@ func (fc *funcContext) translateStmt(stmt ast.Stmt, label *types.Label) {
 			fc.Printf("return%s;", rVal)
 			return
 		}
-		if !fc.Blocking[s] {
+		if !fc.Blocking[s] || experiments.Env.AsyncAwait {
 			// The function is flattened, but the return statement is non-blocking
-			// (i.e. doesn't lead to blocking deferred calls). A regular return
-			// is sufficient, but we also make sure to not resume function body.
+			// (i.e. doesn't lead to blocking deferred calls), or the function is
+			// async and awaits the deferred calls. A regular return is sufficient,
+			// but we also make sure to not resume function body.
 			fc.Printf("$s = -1; return%s;", rVal)
 			return
 		}
@@ -590,12 +599,27 @@ func (fc *funcContext) translateBranchingStmt(caseClauses []*ast.CaseClause, def
 	}
 
 	condStrs := make([]string, len(caseClauses))
+	// Statements that conditions of non-flattened clauses depend on, for
+	// example awaited blocking calls. They must only run if the preceding
+	// clauses didn't match.
+	condCode := make([][]byte, len(caseClauses))
+	nested := 0
 	for i, clause := range caseClauses {
-		conds := make([]string, len(clause.List))
-		for j, cond := range clause.List {
-			conds[j] = translateCond(cond).String()
+		translate := func() {
+			conds := make([]string, len(clause.List))
+			for j, cond := range clause.List {
+				conds[j] = translateCond(cond).String()
+			}
+			condStrs[i] = strings.Join(conds, " || ")
+		}
+		if flatten || i == 0 {
+			translate()
+		} else {
+			condCode[i] = fc.CatchOutput(nested+1, translate)
+			if len(condCode[i]) != 0 {
+				nested++
+			}
 		}
-		condStrs[i] = strings.Join(conds, " || ")
 		if flatten {
 			fc.Printf("/* */ if (%s) { $s = %d; continue; }", condStrs[i], caseOffset+i)
 		}
@@ -613,6 +637,13 @@ func (fc *funcContext) translateBranchingStmt(caseClauses []*ast.CaseClause, def
 	}
 
 	for i, clause := range caseClauses {
+		if len(condCode[i]) != 0 {
+			fc.Printf("%s{", prefix)
+			fc.Write(condCode[i])
+			fc.pkgCtx.indentation++
+			prefix = ""
+			suffix = " }" + suffix
+		}
 		fc.SetPos(clause.Pos())
 		fc.PrintCond(!flatten, fmt.Sprintf("%sif (%s) {", prefix, condStrs[i]), fmt.Sprintf("case %d:", caseOffset+i))
 		fc.Indented(func() {
@@ -631,6 +662,7 @@ func (fc *funcContext) translateBranchingStmt(caseClauses []*ast.CaseClause, def
 		})
 	}
 
+	fc.pkgCtx.indentation -= nested
 	fc.PrintCond(!flatten, "}"+suffix, fmt.Sprintf("case %d:", endCase))
 }
 
@@ -20,6 +20,7 @@ import (
 	"github.com/gopherjs/gopherjs/compiler/internal/analysis"
 	"github.com/gopherjs/gopherjs/compiler/internal/typeparams"
 	"github.com/gopherjs/gopherjs/compiler/typesutil"
+	"github.com/gopherjs/gopherjs/internal/experiments"
 	"github.com/gopherjs/gopherjs/internal/sourcemapx"
 )
 
@@ -202,7 +203,7 @@ func (fc *funcContext) translateSelection(sel typesutil.Selection, pos token.Pos
 		if jsTag := getJsTag(s.Tag(index)); jsTag != "" {
 			jsFieldName := s.Field(index).Name()
 			for {
-				fields = append(fields, fieldName(s, 0))
+				fields = append(fields, fc.fieldName(s, 0))
 				ft := fc.fieldType(s, 0)
 				if typesutil.IsJsObject(ft) {
 					return fields, jsTag
@@ -219,7 +220,7 @@ func (fc *funcContext) translateSelection(sel typesutil.Selection, pos token.Pos
 				}
 			}
 		}
-		fields = append(fields, fieldName(s, index))
+		fields = append(fields, fc.fieldName(s, index))
 		t = fc.fieldType(s, index)
 	}
 	return fields, ""
@@ -482,6 +483,9 @@ func (fc *funcContext) methodName(fun *types.Func) string {
 	if fun.Type().(*types.Signature).Recv() == nil {
 		panic(fmt.Errorf("expected a method, got a standalone function %v", fun))
 	}
+	if prop, ok := fc.pkgCtx.PropNames[fun.Name()]; ok {
+		return prop
+	}
 	// Method names are scoped to their receiver type and guaranteed to be
 	// unique within that, so we only need to make sure it's not a reserved keyword
 	return sanitizeName(fun.Name())
@@ -639,7 +643,7 @@ func (fc *funcContext) externalize(s string, t types.Type) string {
 	}
 	switch u := t.Underlying().(type) {
 	case *types.Basic:
-		if isNumeric(u) && !is64Bit(u) && !isComplex(u) {
+		if isNumeric(u) && (!is64Bit(u) || isBigInt(u)) && !isComplex(u) {
 			return s
 		}
 		if u.Kind() == types.UntypedNil {
@@ -657,7 +661,17 @@ func (fc *funcContext) handleEscapingVars(n ast.Node) {
 	fc.pkgCtx.escapingVars = newEscapingVars
 
 	var names []string
-	objs := analysis.EscapingObjects(n, fc.pkgCtx.Info.Info)
+	// With optimizations enabled, pointers that don't outlive their use are
+	// shared by all bindings of the variable, so taking them doesn't need a new
+	// binding. The pointer is still allocated, but once per call of the
+	// function rather than for each binding. This doesn't hold if the function
+	// is resumed after blocking, which makes the pointer refer to the variables
+	// of the previous call.
+	var addrEscapes func(*ast.UnaryExpr) bool
+	if fc.pkgCtx.optimize && !fc.IsBlocking() {
+		addrEscapes = fc.pkgCtx.AddrEscapes
+	}
+	objs := analysis.EscapingObjects(n, fc.pkgCtx.Info.Info, addrEscapes)
 	for _, obj := range objs {
 		names = append(names, fc.objectName(obj))
 		fc.pkgCtx.escapingVars[obj] = true
@@ -668,8 +682,12 @@ func (fc *funcContext) handleEscapingVars(n ast.Node) {
 	}
 }
 
-func fieldName(t *types.Struct, i int) string {
+// fieldName returns the JS property name of the i-th field of the struct.
+func (fc *funcContext) fieldName(t *types.Struct, i int) string {
 	name := t.Field(i).Name()
+	if prop, ok := fc.pkgCtx.PropNames[name]; ok {
+		return prop
+	}
 	if name == "_" || reservedKeywords[name] {
 		return fmt.Sprintf("%s$%d", name, i)
 	}
@@ -721,6 +739,22 @@ func is64Bit(t *types.Basic) bool {
 	return t.Kind() == types.Int64 || t.Kind() == types.Uint64
 }
 
+// isBigInt reports whether values of t are represented as JavaScript BigInts,
+// which is the case for 64-bit integers when the bigint64 experiment is
+// enabled.
+func isBigInt(t *types.Basic) bool {
+	return is64Bit(t) && experiments.Env.BigInt64
+}
+
+// flattenBlocking marks n as flattened, so that the function can be resumed
+// after a blocking call within n unblocks. Async functions await blocking calls
+// in place and don't need that.
+func (fc *funcContext) flattenBlocking(n ast.Node) {
+	if !experiments.Env.AsyncAwait {
+		fc.Flattened[n] = true
+	}
+}
+
 func isBoolean(t *types.Basic) bool {
 	return t.Info()&types.IsBoolean != 0
 }
@@ -792,7 +826,7 @@ func isBlank(expr ast.Expr) bool {
 func isWrapped(ty types.Type) bool {
 	switch t := ty.Underlying().(type) {
 	case *types.Basic:
-		return !is64Bit(t) && !isComplex(t) && t.Kind() != types.UntypedNil
+		return (!is64Bit(t) || isBigInt(t)) && !isComplex(t) && t.Kind() != types.UntypedNil
 	case *types.Array, *types.Chan, *types.Map, *types.Signature:
 		return true
 	case *types.Pointer:
@@ -873,7 +907,14 @@ func getJsTag(tag string) string {
 
 		if name == "js" {
 			value, _ := strconv.Unquote(qvalue)
-			return value
+			// Options following the name, such as omitempty, only affect
+			// conversions of structs without a *js.Object field, and fields
+			// tagged with "-" are regular Go fields.
+			if value == "-" {
+				return ""
+			}
+			jsName, _, _ := strings.Cut(value, ",")
+			return jsName
 		}
 	}
 	return ""
@@ -0,0 +1,508 @@
+package bindgen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func generate(t *testing.T, parse func(*Definitions, string, []byte) error, src string) string {
+	t.Helper()
+	defs := NewDefinitions()
+	if err := parse(defs, "test", []byte(src)); err != nil {
+		t.Fatalf("Got: parse error %q. Want: no error.", err)
+	}
+	out, err := Generate("dom", defs)
+	if err != nil {
+		t.Fatalf("Got: Generate() returned error %q. Want: no error.", err)
+	}
+	return string(out)
+}
+
+func TestWebIDL(t *testing.T) {
+	src := `
+		enum ScrollBehavior { "auto", "smooth", "" };
+		callback FrameRequestCallback = undefined (double time);
+
+		[Exposed=Window]
+		interface EventTarget {
+			constructor();
+			undefined addEventListener(DOMString type, EventListener? callback, optional (AddEventListenerOptions or boolean) options = {});
+		};
+
+		dictionary ScrollOptions { ScrollBehavior behavior = "auto"; };
+
+		interface mixin ParentNode {
+			readonly attribute unsigned long childElementCount;
+			Element? querySelector(DOMString selectors);
+		};
+
+		interface Node : EventTarget {
+			const unsigned short ELEMENT_NODE = 1;
+			attribute DOMString? textContent;
+			static sequence<DOMString> names(long long id, DOMString... more);
+		};
+
+		interface Element : Node {
+			[CEReactions] attribute DOMString id;
+		};
+		Element includes ParentNode;
+		partial interface Element {
+			readonly attribute FrozenArray<DOMString> classList;
+		};
+
+		namespace console {
+			undefined log(any... data);
+		};
+	`
+	want := `// Code generated by gopherjs bindgen. DO NOT EDIT.
+
+package dom
+
+import "github.com/gopherjs/gopherjs/js"
+
+// ScrollBehavior is the ScrollBehavior JavaScript enum.
+type ScrollBehavior string
+
+// Values of ScrollBehavior.
+const (
+	ScrollBehaviorAuto   ScrollBehavior = "auto"
+	ScrollBehaviorSmooth ScrollBehavior = "smooth"
+	ScrollBehaviorEmpty  ScrollBehavior = ""
+)
+
+// FrameRequestCallback is the FrameRequestCallback JavaScript callback type.
+type FrameRequestCallback func(time float64)
+
+// EventTarget wraps the EventTarget JavaScript interface.
+type EventTarget struct {
+	*js.Object
+}
+
+// NewEventTarget creates a new JavaScript EventTarget object.
+func NewEventTarget() *EventTarget {
+	return &EventTarget{Object: js.Global.Get("EventTarget").New()}
+}
+
+// AddEventListener calls the addEventListener JavaScript method.
+func (e *EventTarget) AddEventListener(typeArg string, callback any) {
+	e.Object.Call("addEventListener", typeArg, callback)
+}
+
+// AddEventListenerWithOptions calls the addEventListener JavaScript method.
+func (e *EventTarget) AddEventListenerWithOptions(typeArg string, callback any, options any) {
+	e.Object.Call("addEventListener", typeArg, callback, options)
+}
+
+// ScrollOptions wraps the ScrollOptions JavaScript dictionary.
+type ScrollOptions struct {
+	*js.Object
+	Behavior ScrollBehavior ` + "`" + `js:"behavior"` + "`" + `
+}
+
+// NewScrollOptions creates an empty ScrollOptions dictionary.
+func NewScrollOptions() *ScrollOptions {
+	return &ScrollOptions{Object: js.Global.Get("Object").New()}
+}
+
+// Node wraps the Node JavaScript interface.
+type Node struct {
+	*js.Object
+	TextContent string ` + "`" + `js:"textContent"` + "`" + `
+}
+
+// Constants of the Node JavaScript interface.
+const (
+	NodeElementNode = 1
+)
+
+// AddEventListener calls the addEventListener JavaScript method.
+func (n *Node) AddEventListener(typeArg string, callback any) {
+	n.Object.Call("addEventListener", typeArg, callback)
+}
+
+// AddEventListenerWithOptions calls the addEventListener JavaScript method.
+func (n *Node) AddEventListenerWithOptions(typeArg string, callback any, options any) {
+	n.Object.Call("addEventListener", typeArg, callback, options)
+}
+
+// NodeNames calls the Node.names JavaScript function.
+func NodeNames(id int64, more ...string) []string {
+	args := []any{id}
+	for _, v := range more {
+		args = append(args, v)
+	}
+	r := js.Global.Get("Node").Call("names", args...)
+	if r == nil || r == js.Undefined {
+		return nil
+	}
+	s := make([]string, r.Length())
+	for i := range s {
+		s[i] = r.Index(i).String()
+	}
+	return s
+}
+
+// Element wraps the Element JavaScript interface.
+type Element struct {
+	*js.Object
+	Id                string   ` + "`" + `js:"id"` + "`" + `
+	ClassList         []string ` + "`" + `js:"classList"` + "`" + `
+	ChildElementCount int      ` + "`" + `js:"childElementCount"` + "`" + `
+	TextContent       string   ` + "`" + `js:"textContent"` + "`" + `
+}
+
+// QuerySelector calls the querySelector JavaScript method.
+func (e *Element) QuerySelector(selectors string) *Element {
+	r := e.Object.Call("querySelector", selectors)
+	if r == nil || r == js.Undefined {
+		return nil
+	}
+	return &Element{Object: r}
+}
+
+// AddEventListener calls the addEventListener JavaScript method.
+func (e *Element) AddEventListener(typeArg string, callback any) {
+	e.Object.Call("addEventListener", typeArg, callback)
+}
+
+// AddEventListenerWithOptions calls the addEventListener JavaScript method.
+func (e *Element) AddEventListenerWithOptions(typeArg string, callback any, options any) {
+	e.Object.Call("addEventListener", typeArg, callback, options)
+}
+
+// ConsoleLog calls the console.log JavaScript function.
+func ConsoleLog(data ...any) {
+	js.Global.Get("console").Call("log", data...)
+}
+`
+	got := generate(t, ParseWebIDL, src)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Generate() returned diff (-want,+got):\n%s", diff)
+	}
+}
+
+func TestTypeScript(t *testing.T) {
+	src := `
+		/// <reference lib="es2015" />
+		type Mode = "open" | "closed";
+		type Handler = (this: Window, ev: Event, ...rest: any[]) => boolean;
+
+		interface Event {
+			readonly type: string;
+			readonly target: Node | null;
+			mode?: Mode;
+			composedPath(): Node[];
+			[key: string]: any;
+		}
+		declare var Event: {
+			prototype: Event;
+			new(type: string, init?: EventInit): Event;
+			readonly NONE: 0;
+		};
+
+		declare class Node {
+			static from(data: string | Uint8Array): Node;
+			private secret;
+			get name(): string;
+			set name(v: string);
+			item(index: number): Node | null;
+		}
+
+		export {};
+	`
+	want := `// Code generated by gopherjs bindgen. DO NOT EDIT.
+
+package dom
+
+import "github.com/gopherjs/gopherjs/js"
+
+// Mode is the Mode JavaScript enum.
+type Mode string
+
+// Values of Mode.
+const (
+	ModeOpen   Mode = "open"
+	ModeClosed Mode = "closed"
+)
+
+// Handler is the Handler JavaScript callback type.
+type Handler func(ev *Event, rest ...*js.Object) bool
+
+// Event wraps the Event JavaScript interface.
+type Event struct {
+	*js.Object
+	Type   string ` + "`" + `js:"type"` + "`" + `
+	Target *Node  ` + "`" + `js:"target"` + "`" + `
+	Mode   Mode   ` + "`" + `js:"mode"` + "`" + `
+}
+
+// NewEvent creates a new JavaScript Event object.
+func NewEvent(typeArg string) *Event {
+	return &Event{Object: js.Global.Get("Event").New(typeArg)}
+}
+
+// NewEventWithInit creates a new JavaScript Event object.
+func NewEventWithInit(typeArg string, init any) *Event {
+	return &Event{Object: js.Global.Get("Event").New(typeArg, init)}
+}
+
+// ComposedPath calls the composedPath JavaScript method.
+func (e *Event) ComposedPath() []*Node {
+	r := e.Object.Call("composedPath")
+	if r == nil || r == js.Undefined {
+		return nil
+	}
+	s := make([]*Node, r.Length())
+	for i := range s {
+		s[i] = &Node{Object: r.Index(i)}
+	}
+	return s
+}
+
+// EventNONE returns the Event.NONE JavaScript property.
+func EventNONE() float64 {
+	return js.Global.Get("Event").Get("NONE").Float()
+}
+
+// Node wraps the Node JavaScript interface.
+type Node struct {
+	*js.Object
+	Name string ` + "`" + `js:"name"` + "`" + `
+}
+
+// Item calls the item JavaScript method.
+func (n *Node) Item(index float64) *Node {
+	r := n.Object.Call("item", index)
+	if r == nil || r == js.Undefined {
+		return nil
+	}
+	return &Node{Object: r}
+}
+
+// NodeFrom calls the Node.from JavaScript function.
+func NodeFrom(data any) *Node {
+	r := js.Global.Get("Node").Call("from", data)
+	if r == nil || r == js.Undefined {
+		return nil
+	}
+	return &Node{Object: r}
+}
+`
+	got := generate(t, ParseTypeScript, src)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Generate() returned diff (-want,+got):\n%s", diff)
+	}
+}
+
+func TestTypeScriptNamespaces(t *testing.T) {
+	src := `
+		export declare function readFile(path: string, callback: (err: any, data: string) => void): void;
+		declare namespace fs.promises {
+			function access(path: string, mode?: number): Promise<void>;
+			const constants: { F_OK: number };
+		}
+		declare module "path" {
+			export const sep: string;
+			export function join(...paths: string[]): string;
+			export class Parser {
+				constructor(s: string);
+				parse(): string;
+			}
+		}
+		declare global {
+			var process: Process;
+		}
+		interface Process { readonly pid: number }
+		enum Mixed { A = "a", B = 1 }
+		export = fs;
+	`
+	want := `// Code generated by gopherjs bindgen. DO NOT EDIT.
+
+package node
+
+import "github.com/gopherjs/gopherjs/js"
+
+// FsPromisesConstants returns the fs.promises.constants JavaScript property.
+func FsPromisesConstants() *js.Object {
+	return js.Global.Get("fs").Get("promises").Get("constants")
+}
+
+// FsPromisesAccess calls the fs.promises.access JavaScript function.
+func FsPromisesAccess(path string) *js.Object {
+	return js.Global.Get("fs").Get("promises").Call("access", path)
+}
+
+// FsPromisesAccessWithMode calls the fs.promises.access JavaScript function.
+func FsPromisesAccessWithMode(path string, mode float64) *js.Object {
+	return js.Global.Get("fs").Get("promises").Call("access", path, mode)
+}
+
+// PathSep returns the path.sep JavaScript property.
+func PathSep() string {
+	return js.Global.Call("require", "path").Get("sep").String()
+}
+
+// PathJoin calls the path.join JavaScript function.
+func PathJoin(paths ...string) string {
+	args := []any{}
+	for _, v := range paths {
+		args = append(args, v)
+	}
+	return js.Global.Call("require", "path").Call("join", args...).String()
+}
+
+// Parser wraps the Parser JavaScript interface.
+type Parser struct {
+	*js.Object
+}
+
+// NewParser creates a new JavaScript Parser object.
+func NewParser(sArg string) *Parser {
+	return &Parser{Object: js.Global.Call("require", "path").Get("Parser").New(sArg)}
+}
+
+// Parse calls the parse JavaScript method.
+func (p *Parser) Parse() string {
+	return p.Object.Call("parse").String()
+}
+
+// Process wraps the Process JavaScript interface.
+type Process struct {
+	*js.Object
+	Pid float64 ` + "`" + `js:"pid"` + "`" + `
+}
+
+// Process2 returns the process JavaScript property.
+func Process2() *Process {
+	r := js.Global.Get("process")
+	if r == nil || r == js.Undefined {
+		return nil
+	}
+	return &Process{Object: r}
+}
+
+// ReadFile calls the readFile JavaScript function.
+func ReadFile(path string, callback any) {
+	js.Global.Call("readFile", path, callback)
+}
+`
+	defs := NewDefinitions()
+	if err := ParseTypeScript(defs, "test", []byte(src)); err != nil {
+		t.Fatalf("Got: parse error %q. Want: no error.", err)
+	}
+	out, err := Generate("node", defs)
+	if err != nil {
+		t.Fatalf("Got: Generate() returned error %q. Want: no error.", err)
+	}
+	if diff := cmp.Diff(want, string(out)); diff != "" {
+		t.Errorf("Generate() returned diff (-want,+got):\n%s", diff)
+	}
+	wantWarnings := []string{
+		"test:19: enum Mixed mixes string and numeric members, binding it as *js.Object",
+		`test:20: skipping unsupported export "="`,
+	}
+	if diff := cmp.Diff(wantWarnings, defs.Warnings); diff != "" {
+		t.Errorf("Got warnings diff (-want,+got):\n%s", diff)
+	}
+}
+
+func TestNameCollisions(t *testing.T) {
+	src := `
+		interface Thing {
+			attribute any object;
+			attribute long r;
+			undefined close();
+			undefined close(boolean force);
+			undefined select(long r, DOMString type, DOMString t);
+		};
+	`
+	got := generate(t, ParseWebIDL, src)
+	for _, want := range []string{
+		"ObjectField *js.Object `js:\"object\"`",
+		"R           int        `js:\"r\"`",
+		"func (t *Thing) Close() {",
+		"func (t *Thing) Close2(force bool) {",
+		"func (t *Thing) Select(rArg int, typeArg string, tArg string) {",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Got: generated code without %q:\n%s\nWant: it present.", want, got)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		descr string
+		parse func(*Definitions, string, []byte) error
+		src   string
+		want  string
+	}{{
+		descr: "unterminated comment",
+		parse: ParseWebIDL,
+		src:   "interface A {};\n/* comment",
+		want:  "test:2: unterminated comment",
+	}, {
+		descr: "missing semicolon",
+		parse: ParseWebIDL,
+		src:   "interface A {\n  attribute long x\n};",
+		want:  `test:3: expected ";", found "}"`,
+	}, {
+		descr: "unknown definition",
+		parse: ParseWebIDL,
+		src:   "foo bar;",
+		want:  `test:1: unexpected "foo"`,
+	}, {
+		descr: "bad enum value",
+		parse: ParseWebIDL,
+		src:   "enum E { a };",
+		want:  `test:1: expected enum value, found "a"`,
+	}, {
+		descr: "unterminated interface",
+		parse: ParseTypeScript,
+		src:   "interface A {\n  x: number;\n",
+		want:  "test:3: unexpected end of file",
+	}, {
+		descr: "bad parameter",
+		parse: ParseTypeScript,
+		src:   "interface A { f(: number): void }",
+		want:  `test:1: expected parameter, found ":"`,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.descr, func(t *testing.T) {
+			err := test.parse(NewDefinitions(), "test", []byte(test.src))
+			if err == nil || err.Error() != test.want {
+				t.Errorf("Got: error %v. Want: %q.", err, test.want)
+			}
+		})
+	}
+}
+
+func TestNames(t *testing.T) {
+	tests := []struct {
+		name      string
+		exported  string
+		parameter string
+	}{
+		{name: "getElementById", exported: "GetElementById", parameter: "getElementById"},
+		{name: "URL", exported: "URL", parameter: "URL"},
+		{name: "ELEMENT_NODE", exported: "ElementNode", parameter: "ELEMENT_NODE"},
+		{name: "image/png", exported: "ImagePng", parameter: "imagepng"},
+		{name: "2d", exported: "X2d", parameter: "arg2d"},
+		{name: "type", exported: "Type", parameter: "typeArg"},
+		{name: "string", exported: "String", parameter: "stringArg"},
+		{name: "$el", exported: "El", parameter: "el"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := exportedName(test.name); got != test.exported {
+				t.Errorf("Got: exportedName(%q) = %q. Want: %q.", test.name, got, test.exported)
+			}
+			if got := paramName(test.name); got != test.parameter {
+				t.Errorf("Got: paramName(%q) = %q. Want: %q.", test.name, got, test.parameter)
+			}
+		})
+	}
+}
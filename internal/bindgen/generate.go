@@ -0,0 +1,581 @@
+package bindgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	gotoken "go/token"
+	"go/types"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Generate renders Go bindings for the definitions as the gofmt-formatted
+// source of a package with the given name.
+//
+// Interfaces and dictionaries become structs embedding *js.Object, with
+// attributes, including the inherited ones and the ones of included mixins,
+// mapped onto fields with `js:"name"` tags. Operations become methods, and
+// operations with optional parameters get a method for each number of passed
+// arguments, e.g. AddEventListener and AddEventListenerWithOptions.
+// Constructors, static members and namespace members become package-level
+// functions prefixed with the interface name, and global functions and
+// variables become package-level functions of the same name.
+func Generate(pkg string, defs *Definitions) ([]byte, error) {
+	g := &generator{
+		defs:      defs,
+		names:     map[string]bool{},
+		typeNames: map[string]string{},
+		enums:     map[string]bool{},
+		callbacks: map[string]bool{},
+	}
+	for _, e := range defs.Enums {
+		g.typeNames[e.Name] = g.unique(g.names, exportedName(e.Name))
+		g.enums[e.Name] = true
+	}
+	for _, cb := range defs.Callbacks {
+		g.typeNames[cb.Name] = g.unique(g.names, exportedName(cb.Name))
+		g.callbacks[cb.Name] = true
+	}
+	for _, iface := range defs.Interfaces {
+		if iface.Mixin || iface.Namespace {
+			continue
+		}
+		g.typeNames[iface.Name] = g.unique(g.names, exportedName(iface.Name))
+	}
+
+	for _, e := range defs.Enums {
+		g.enum(e)
+	}
+	for _, cb := range defs.Callbacks {
+		g.callback(cb)
+	}
+	for _, iface := range defs.Interfaces {
+		if !iface.Mixin {
+			g.iface(iface)
+		}
+	}
+	g.iface(defs.Globals)
+
+	out := &bytes.Buffer{}
+	fmt.Fprintf(out, "// Code generated by gopherjs bindgen. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	if bytes.Contains(g.buf.Bytes(), []byte("js.")) {
+		fmt.Fprintf(out, "import %q\n\n", "github.com/gopherjs/gopherjs/js")
+	}
+	out.Write(g.buf.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated bindings: %w", err)
+	}
+	return src, nil
+}
+
+type generator struct {
+	defs *Definitions
+	buf  bytes.Buffer
+
+	names     map[string]bool   // Package-level Go names.
+	typeNames map[string]string // Go type names of the definitions.
+	enums     map[string]bool
+	callbacks map[string]bool
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// unique returns the name, or the name with a numeric suffix if it's already
+// in use, and marks it as used.
+func (g *generator) unique(used map[string]bool, name string) string {
+	candidate := name
+	for i := 2; used[candidate]; i++ {
+		candidate = name + strconv.Itoa(i)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// resolve follows typedefs to the underlying type.
+func (g *generator) resolve(t Type) Type {
+	for i := 0; t.Kind == Named && i < 100; i++ {
+		target, ok := g.defs.Typedefs[t.Name]
+		if !ok {
+			return t
+		}
+		t = target
+	}
+	if t.Kind == Named {
+		return Type{Kind: Any} // Typedef cycle.
+	}
+	return t
+}
+
+// goType returns the Go type of attributes and parameters of the type.
+func (g *generator) goType(t Type) string {
+	t = g.resolve(t)
+	switch t.Kind {
+	case Bool:
+		return "bool"
+	case String:
+		return "string"
+	case Int:
+		return "int"
+	case Int64:
+		return "int64"
+	case Float:
+		return "float64"
+	case Sequence:
+		return "[]" + g.goType(*t.Elem)
+	case Named:
+		name, ok := g.typeNames[t.Name]
+		switch {
+		case !ok:
+			// Types defined elsewhere, as well as mixins and namespaces.
+		case g.enums[t.Name] || g.callbacks[t.Name]:
+			return name
+		default:
+			return "*" + name
+		}
+	}
+	return "*js.Object"
+}
+
+// converter returns a function, which converts a *js.Object expression to the
+// Go type of t, or nil if there is no such conversion.
+func (g *generator) converter(t Type) func(expr string) string {
+	t = g.resolve(t)
+	switch t.Kind {
+	case Any:
+		return func(expr string) string { return expr }
+	case Bool:
+		return func(expr string) string { return expr + ".Bool()" }
+	case String:
+		return func(expr string) string { return expr + ".String()" }
+	case Int:
+		return func(expr string) string { return expr + ".Int()" }
+	case Int64:
+		return func(expr string) string { return expr + ".Int64()" }
+	case Float:
+		return func(expr string) string { return expr + ".Float()" }
+	case Named:
+		name, ok := g.typeNames[t.Name]
+		switch {
+		case !ok:
+			return func(expr string) string { return expr }
+		case g.enums[t.Name]:
+			return func(expr string) string { return name + "(" + expr + ".String())" }
+		case g.callbacks[t.Name]:
+			return nil
+		default:
+			return func(expr string) string { return "&" + name + "{Object: " + expr + "}" }
+		}
+	}
+	return nil
+}
+
+// resultType returns the Go result type of an operation or a static attribute
+// getter. Results which can't be converted to the Go type, such as callbacks
+// or nested sequences, are returned as *js.Object. Returns an empty string if
+// there is no result.
+func (g *generator) resultType(t Type) string {
+	t = g.resolve(t)
+	switch {
+	case t.Kind == Void:
+		return ""
+	case t.Kind == Sequence && g.resolve(*t.Elem).Kind != Sequence && g.converter(*t.Elem) != nil,
+		t.Kind != Sequence && g.converter(t) != nil:
+		return g.goType(t)
+	}
+	return "*js.Object"
+}
+
+// result writes the statements returning the value of the expression as the
+// result type.
+func (g *generator) result(t Type, expr string) {
+	t = g.resolve(t)
+	typ := g.resultType(t)
+	switch {
+	case typ == "":
+		g.printf("\t%s\n", expr)
+	case typ == "*js.Object":
+		g.printf("\treturn %s\n", expr)
+	case t.Kind == Sequence:
+		g.printf("\tr := %s\n", expr)
+		g.printf("\tif r == nil || r == js.Undefined {\n\t\treturn nil\n\t}\n")
+		g.printf("\ts := make(%s, r.Length())\n", typ)
+		g.printf("\tfor i := range s {\n\t\ts[i] = %s\n\t}\n", g.converter(*t.Elem)("r.Index(i)"))
+		g.printf("\treturn s\n")
+	case strings.HasPrefix(typ, "*"):
+		g.printf("\tr := %s\n", expr)
+		g.printf("\tif r == nil || r == js.Undefined {\n\t\treturn nil\n\t}\n")
+		g.printf("\treturn %s\n", g.converter(t)("r"))
+	default:
+		g.printf("\treturn %s\n", g.converter(t)(expr))
+	}
+}
+
+func (g *generator) enum(e *Enum) {
+	name := g.typeNames[e.Name]
+	g.printf("// %s is the %s JavaScript enum.\ntype %s string\n\n", name, e.Name, name)
+	if len(e.Values) == 0 {
+		return
+	}
+	g.printf("// Values of %s.\nconst (\n", name)
+	for _, v := range e.Values {
+		suffix := v.Name
+		if suffix == "" {
+			suffix = v.Value
+		}
+		suffix = camelCase(suffix)
+		if suffix == "" {
+			suffix = "Empty"
+		}
+		g.printf("\t%s %s = %q\n", g.unique(g.names, name+suffix), name, v.Value)
+	}
+	g.printf(")\n\n")
+}
+
+func (g *generator) callback(cb *Callback) {
+	name := g.typeNames[cb.Name]
+	params := g.params(cb.Params, map[string]bool{}, true)
+	g.printf("// %s is the %s JavaScript callback type.\n", name, cb.Name)
+	g.printf("type %s func(%s)", name, params.decl)
+	if t := g.resolve(cb.Result); t.Kind != Void {
+		g.printf(" %s", g.goType(t))
+	}
+	g.printf("\n\n")
+}
+
+// members returns the attributes, operations and constants of the interface,
+// including the inherited ones and the ones of the included mixins. Members
+// redeclared by the interface shadow the inherited ones, static members are
+// not inherited.
+func (g *generator) members(iface *Interface, visited map[string]bool) (attrs []*Attribute, ops []*Operation, consts []*Constant) {
+	if visited[iface.Name] {
+		return nil, nil, nil
+	}
+	visited[iface.Name] = true
+
+	attrs = append(attrs, iface.Attributes...)
+	ops = append(ops, iface.Operations...)
+	consts = append(consts, iface.Constants...)
+	related := append([]string{}, iface.Includes...)
+	if iface.Inherits != "" {
+		related = append(related, iface.Inherits)
+	}
+	for _, name := range related {
+		other := g.defs.lookup(name)
+		if other == nil {
+			continue
+		}
+		a, o, c := g.members(other, visited)
+		for _, attr := range a {
+			if !attr.Static {
+				attrs = append(attrs, attr)
+			}
+		}
+		for _, op := range o {
+			if !op.Static {
+				ops = append(ops, op)
+			}
+		}
+		if other.Mixin {
+			consts = append(consts, c...)
+		}
+	}
+	return attrs, ops, consts
+}
+
+func (g *generator) iface(iface *Interface) {
+	attrs, ops, consts := g.members(iface, map[string]bool{})
+
+	// Attributes shadow the inherited ones, operations are overloaded.
+	seenAttrs := map[string]bool{}
+	var instanceAttrs, staticAttrs []*Attribute
+	for _, attr := range attrs {
+		switch {
+		case attr.Static:
+			staticAttrs = append(staticAttrs, attr)
+		case !seenAttrs[attr.Name] && g.resolve(attr.Type).Kind != Void:
+			seenAttrs[attr.Name] = true
+			instanceAttrs = append(instanceAttrs, attr)
+		}
+	}
+	var instanceOps, staticOps []*Operation
+	for _, op := range ops {
+		if op.Static || iface.Namespace {
+			staticOps = append(staticOps, op)
+		} else {
+			instanceOps = append(instanceOps, op)
+		}
+	}
+	if iface.Namespace {
+		staticAttrs = append(staticAttrs, instanceAttrs...)
+		instanceAttrs = nil
+	}
+
+	prefix := ""
+	if iface != g.defs.Globals {
+		prefix = exportedName(iface.Name)
+	}
+	name := prefix
+	if !iface.Namespace {
+		name = g.typeNames[iface.Name]
+		kind := "interface"
+		if iface.Dictionary {
+			kind = "dictionary"
+		}
+		g.printf("// %s wraps the %s JavaScript %s.\ntype %s struct {\n\t*js.Object\n", name, iface.Name, kind, name)
+		members := map[string]bool{"Object": true}
+		for _, attr := range instanceAttrs {
+			field := exportedName(attr.Name)
+			if field == "Object" {
+				field = "ObjectField"
+			}
+			g.printf("\t%s %s `js:\"%s\"`\n", g.unique(members, field), g.goType(attr.Type), attr.Name)
+		}
+		g.printf("}\n\n")
+
+		if len(consts) > 0 {
+			g.printf("// Constants of the %s JavaScript %s.\nconst (\n", iface.Name, kind)
+			for _, c := range consts {
+				g.printf("\t%s = %s\n", g.unique(g.names, prefix+exportedName(c.Name)), c.Value)
+			}
+			g.printf(")\n\n")
+		}
+
+		if iface.Dictionary {
+			g.printf("// New%s creates an empty %s dictionary.\n", name, iface.Name)
+			g.printf("func %s() *%s {\n\treturn &%s{Object: js.Global.Get(\"Object\").New()}\n}\n\n", g.unique(g.names, "New"+name), name, name)
+		}
+		for _, ctor := range iface.Constructors {
+			for _, v := range g.variants(ctor, "New"+name) {
+				fn := g.unique(g.names, v.name)
+				params := g.params(v.params, map[string]bool{}, false)
+				g.printf("// %s creates a new JavaScript %s object.\n", fn, iface.Name)
+				g.printf("func %s(%s) *%s {\n", fn, params.decl, name)
+				params.prepare(g)
+				g.printf("\treturn &%s{Object: %s.New(%s)}\n}\n\n", name, jsObject(iface), params.args)
+			}
+		}
+
+		recv := strings.ToLower(name[:1])
+		for _, op := range instanceOps {
+			for _, v := range g.variants(op, exportedName(op.Name)) {
+				method := g.unique(members, v.name)
+				params := g.params(v.params, map[string]bool{recv: true}, false)
+				g.printf("// %s calls the %s JavaScript method.\n", method, op.Name)
+				g.printf("func (%s *%s) %s(%s) %s {\n", recv, name, method, params.decl, g.resultType(op.Result))
+				params.prepare(g)
+				g.result(op.Result, fmt.Sprintf("%s.Object.Call(%q%s)", recv, op.Name, params.tail()))
+				g.printf("}\n\n")
+			}
+		}
+	} else if len(consts) > 0 {
+		g.printf("// Constants of the %s JavaScript namespace.\nconst (\n", iface.Name)
+		for _, c := range consts {
+			g.printf("\t%s = %s\n", g.unique(g.names, prefix+exportedName(c.Name)), c.Value)
+		}
+		g.printf(")\n\n")
+	}
+
+	object := jsObject(iface)
+	for _, attr := range staticAttrs {
+		fn := g.unique(g.names, prefix+exportedName(attr.Name))
+		g.printf("// %s returns the %s JavaScript property.\n", fn, qualifiedName(iface, attr.Name))
+		g.printf("func %s() %s {\n", fn, g.resultType(attr.Type))
+		g.result(attr.Type, fmt.Sprintf("%s.Get(%q)", object, attr.Name))
+		g.printf("}\n\n")
+	}
+	for _, op := range staticOps {
+		for _, v := range g.variants(op, prefix+exportedName(op.Name)) {
+			fn := g.unique(g.names, v.name)
+			params := g.params(v.params, map[string]bool{}, false)
+			g.printf("// %s calls the %s JavaScript function.\n", fn, qualifiedName(iface, op.Name))
+			g.printf("func %s(%s) %s {\n", fn, params.decl, g.resultType(op.Result))
+			params.prepare(g)
+			g.result(op.Result, fmt.Sprintf("%s.Call(%q%s)", object, op.Name, params.tail()))
+			g.printf("}\n\n")
+		}
+	}
+}
+
+// jsObject returns the Go expression of the JavaScript object of the interface
+// or namespace, which holds its constructor and static members.
+func jsObject(iface *Interface) string {
+	object := "js.Global"
+	if iface.Module != "" {
+		object = fmt.Sprintf("js.Global.Call(\"require\", %q)", iface.Module)
+	}
+	path := iface.Path
+	if path == nil {
+		path = []string{iface.Name}
+	}
+	for _, name := range path {
+		object += fmt.Sprintf(".Get(%q)", name)
+	}
+	return object
+}
+
+// qualifiedName returns the JavaScript name of the static member of the
+// interface or namespace.
+func qualifiedName(iface *Interface, member string) string {
+	if iface.Name == "" {
+		return member
+	}
+	return iface.Name + "." + member
+}
+
+// variant is a Go function generated for an operation, which takes a subset
+// of the optional parameters.
+type variant struct {
+	name   string
+	params []*Param
+}
+
+// variants returns the variants of the operation: the first one only takes the
+// required parameters, and each following one takes one more optional
+// parameter and is named after it. A variadic parameter is always passed
+// together with the preceding ones.
+func (g *generator) variants(op *Operation, name string) []variant {
+	required := 0
+	for required < len(op.Params) && !op.Params[required].Optional && !op.Params[required].Variadic {
+		required++
+	}
+	variants := []variant{{name: name, params: op.Params[:required]}}
+	for n := required + 1; n <= len(op.Params); n++ {
+		last := op.Params[n-1]
+		if last.Variadic {
+			variants[len(variants)-1].params = op.Params[:n]
+			continue
+		}
+		variants = append(variants, variant{name: name + "With" + exportedName(last.Name), params: op.Params[:n]})
+	}
+	return variants
+}
+
+// paramList is the rendered parameter list of a generated function.
+type paramList struct {
+	decl     string // Parameter declarations.
+	args     string // Arguments passed to JavaScript.
+	variadic string // Name of the variadic parameter, if any.
+	fixed    []string
+
+	variadicAny bool // The variadic parameter can be passed as is.
+}
+
+// params renders the parameters, renaming the ones that are not valid Go
+// identifiers or collide with the given reserved names or the local variables
+// of the generated function bodies. Parameters of callbacks receive JavaScript
+// values, so they keep the *js.Object type.
+func (g *generator) params(params []*Param, reserved map[string]bool, callback bool) paramList {
+	used := map[string]bool{"js": true, "r": true, "s": true, "i": true, "args": true, "v": true}
+	for name := range reserved {
+		used[name] = true
+	}
+	var list paramList
+	var decls []string
+	for _, param := range params {
+		name := paramName(param.Name)
+		if used[name] {
+			name += "Arg"
+		}
+		name = g.unique(used, name)
+		typ := g.goType(param.Type)
+		if typ == "*js.Object" && !callback {
+			// Accept any value, which is externalized when passed to JavaScript.
+			typ = "any"
+		}
+		if param.Variadic {
+			list.variadicAny = typ == "any"
+			decls = append(decls, name+" ..."+typ)
+			list.variadic = name
+		} else {
+			decls = append(decls, name+" "+typ)
+			list.fixed = append(list.fixed, name)
+		}
+	}
+	list.decl = strings.Join(decls, ", ")
+	list.args = strings.Join(list.fixed, ", ")
+	switch {
+	case list.variadic == "":
+	case list.variadicAny && len(list.fixed) == 0:
+		list.args = list.variadic + "..."
+	default:
+		list.args = "args..."
+	}
+	return list
+}
+
+// prepare writes the statements collecting variadic arguments, if any.
+func (l paramList) prepare(g *generator) {
+	switch {
+	case l.variadic == "":
+		return
+	case l.variadicAny:
+		if len(l.fixed) > 0 {
+			g.printf("\targs := append([]any{%s}, %s...)\n", strings.Join(l.fixed, ", "), l.variadic)
+		}
+		return
+	}
+	g.printf("\targs := []any{%s}\n", strings.Join(l.fixed, ", "))
+	g.printf("\tfor _, v := range %s {\n\t\targs = append(args, v)\n\t}\n", l.variadic)
+}
+
+// tail returns the arguments to append to a call after the method name.
+func (l paramList) tail() string {
+	if l.args == "" {
+		return ""
+	}
+	return ", " + l.args
+}
+
+// paramName returns a valid Go identifier for the parameter name.
+func paramName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	name = b.String()
+	switch {
+	case name == "" || isDigit(name[0]):
+		return "arg" + name
+	case gotoken.IsKeyword(name) || types.Universe.Lookup(name) != nil:
+		return name + "Arg"
+	}
+	return name
+}
+
+// camelCase joins the alphanumeric words of s, capitalizing the first letter
+// of each word. Words of names without lower case letters, such as
+// ELEMENT_NODE, are converted to title case.
+func camelCase(s string) string {
+	screaming := strings.ToUpper(s) == s && strings.ContainsAny(s, "_-")
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, w := range words {
+		if screaming {
+			w = strings.ToLower(w)
+		}
+		first, size := utf8.DecodeRuneInString(w)
+		b.WriteRune(unicode.ToUpper(first))
+		b.WriteString(w[size:])
+	}
+	return b.String()
+}
+
+// exportedName returns an exported Go identifier for the JavaScript name.
+func exportedName(s string) string {
+	name := camelCase(s)
+	if name == "" || isDigit(name[0]) {
+		return "X" + name
+	}
+	return name
+}
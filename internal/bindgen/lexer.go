@@ -0,0 +1,238 @@
+package bindgen
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string // For strings, the unquoted value.
+	line int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of file"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// multiPunct lists punctuation tokens longer than one character, which are
+// recognized by both Web IDL and TypeScript lexers.
+var multiPunct = []string{"...", "=>"}
+
+// tokenize splits a C-like source into tokens, skipping whitespace and
+// comments. The syntax of identifiers, numbers and strings is shared by Web
+// IDL and TypeScript closely enough for the purposes of binding generation.
+func tokenize(name string, src string) ([]token, error) {
+	var tokens []token
+	line := 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("%s:%d: unterminated comment", name, line)
+			}
+			comment := src[i : i+2+end+2]
+			line += strings.Count(comment, "\n")
+			i += len(comment)
+		case isIdentStart(c):
+			start := i
+			for i < len(src) && isIdentPart(src[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[start:i], line: line})
+		case isDigit(c) || c == '.' && i+1 < len(src) && isDigit(src[i+1]):
+			start := i
+			hex := strings.HasPrefix(src[i:], "0x") || strings.HasPrefix(src[i:], "0X")
+			for i < len(src) {
+				if c := src[i]; isIdentPart(c) || c == '.' ||
+					!hex && (c == '+' || c == '-') && (src[i-1] == 'e' || src[i-1] == 'E') {
+					i++
+					continue
+				}
+				break
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: src[start:i], line: line})
+		case c == '"' || c == '\'' || c == '`':
+			start := line
+			value := &strings.Builder{}
+			i++
+			for {
+				if i >= len(src) {
+					return nil, fmt.Errorf("%s:%d: unterminated string", name, start)
+				}
+				if src[i] == c {
+					i++
+					break
+				}
+				if src[i] == '\n' {
+					line++
+				}
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+				}
+				value.WriteByte(src[i])
+				i++
+			}
+			tokens = append(tokens, token{kind: tokString, text: value.String(), line: start})
+		default:
+			text := src[i : i+1]
+			for _, p := range multiPunct {
+				if strings.HasPrefix(src[i:], p) {
+					text = p
+					break
+				}
+			}
+			tokens = append(tokens, token{kind: tokPunct, text: text, line: line})
+			i += len(text)
+		}
+	}
+	tokens = append(tokens, token{kind: tokEOF, line: line})
+	return tokens, nil
+}
+
+func isIdentStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == '$'
+}
+
+func isIdentPart(c byte) bool { return isIdentStart(c) || isDigit(c) }
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+// parser is a recursive descent parser state shared by both input formats.
+type parser struct {
+	name   string
+	tokens []token
+	pos    int
+	defs   *Definitions
+	scope  *Interface // TypeScript namespace being parsed, nil at the top level.
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) peekN(n int) token {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// is reports whether the next token is an identifier or punctuation with the
+// given text.
+func (p *parser) is(text string) bool {
+	t := p.peek()
+	return (t.kind == tokIdent || t.kind == tokPunct) && t.text == text
+}
+
+// accept consumes the next token if it has the given text.
+func (p *parser) accept(text string) bool {
+	if p.is(text) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		return p.errorf("expected %q, found %s", text, p.peek())
+	}
+	return nil
+}
+
+func (p *parser) ident() (string, error) {
+	t := p.peek()
+	if t.kind != tokIdent {
+		return "", p.errorf("expected identifier, found %s", t)
+	}
+	p.next()
+	return t.text, nil
+}
+
+// warnf records a warning about a declaration which can't be bound, at the
+// line of the last consumed token.
+func (p *parser) warnf(format string, args ...any) {
+	line := p.peek().line
+	if p.pos > 0 {
+		line = p.tokens[p.pos-1].line
+	}
+	p.defs.Warnings = append(p.defs.Warnings, fmt.Sprintf("%s:%d: %s", p.name, line, fmt.Sprintf(format, args...)))
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%s:%d: %s", p.name, p.peek().line, fmt.Sprintf(format, args...))
+}
+
+// skipBalanced skips tokens until the closing counterpart of the opening
+// bracket, which must be the next token.
+func (p *parser) skipBalanced() error {
+	closing := map[string]string{"(": ")", "[": "]", "{": "}", "<": ">"}
+	var stack []string
+	for {
+		t := p.next()
+		if t.kind == tokEOF {
+			return p.errorf("unbalanced brackets")
+		}
+		if t.kind != tokPunct {
+			continue
+		}
+		if c, ok := closing[t.text]; ok {
+			stack = append(stack, c)
+		} else if len(stack) > 0 && t.text == stack[len(stack)-1] {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			return nil
+		}
+	}
+}
+
+// skipUntil skips tokens up to and including the given punctuation at the
+// current nesting level.
+func (p *parser) skipUntil(text string) error {
+	for !p.is(text) {
+		switch {
+		case p.peek().kind == tokEOF:
+			return p.errorf("expected %q, found %s", text, p.peek())
+		case p.is("(") || p.is("[") || p.is("{"):
+			if err := p.skipBalanced(); err != nil {
+				return err
+			}
+		default:
+			p.next()
+		}
+	}
+	p.next()
+	return nil
+}
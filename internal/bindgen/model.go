@@ -0,0 +1,156 @@
+// Package bindgen generates Go bindings for JavaScript APIs described by Web
+// IDL or TypeScript declaration files.
+//
+// Both input formats are parsed into the same language-neutral model, which is
+// then rendered as a Go package. Each interface or dictionary becomes a struct
+// embedding *js.Object, with attributes mapped onto fields with `js:"name"`
+// tags, so that $internalize and $externalize take care of the type
+// conversions. Operations become methods that call the underlying JavaScript
+// methods and convert the results to the corresponding Go types.
+//
+// The parsers support the subset of both languages that is commonly used to
+// describe browser and Node.js APIs. Constructs that have no reasonable Go
+// counterpart, such as union or generic types, are mapped onto *js.Object.
+package bindgen
+
+// Kind of a type in the binding model.
+type Kind int
+
+const (
+	// Any is an arbitrary JavaScript value, represented by *js.Object.
+	Any Kind = iota
+	// Void is the absence of a value, only valid as a result type.
+	Void
+	Bool
+	String
+	Int
+	Int64
+	Float
+	// Named is a reference to an interface, dictionary, enum, callback or
+	// typedef, which may or may not be defined in the same Definitions.
+	Named
+	// Sequence is an array-like value with elements of the Elem type.
+	Sequence
+)
+
+// Type of an attribute, parameter or result.
+type Type struct {
+	Kind Kind
+	Name string // Referenced definition name for the Named kind.
+	Elem *Type  // Element type for the Sequence kind.
+}
+
+// Definitions is a set of JavaScript API definitions to generate bindings for.
+type Definitions struct {
+	Interfaces []*Interface
+	Enums      []*Enum
+	Callbacks  []*Callback
+	Typedefs   map[string]Type
+
+	// Globals is the namespace of the functions and variables declared in the
+	// global scope.
+	Globals *Interface
+	// Warnings describe the declarations which couldn't be bound.
+	Warnings []string
+
+	interfaces map[string]*Interface
+}
+
+// Interface describes a JavaScript interface, dictionary or namespace.
+type Interface struct {
+	Name     string
+	Inherits string   // Name of the parent interface, if any.
+	Includes []string // Names of the mixins included by the interface.
+
+	Dictionary bool // A plain object without prototype, such as an options bag.
+	Namespace  bool // A singleton object with static members only.
+	Mixin      bool // Members are merged into the interfaces including it.
+
+	// Module is the name of the module exporting the JavaScript object of the
+	// interface or namespace, which is loaded with require(). Empty for global
+	// objects.
+	Module string
+	// Path is the property path of the JavaScript object within the module or
+	// the global object. Nil means the object is the global named Name.
+	Path []string
+
+	Constants    []*Constant
+	Attributes   []*Attribute
+	Operations   []*Operation
+	Constructors []*Operation
+}
+
+// Constant is a constant interface member.
+type Constant struct {
+	Name  string
+	Value string // Go literal of the value.
+}
+
+// Attribute is a property of an interface or a dictionary member.
+type Attribute struct {
+	Name   string
+	Type   Type
+	Static bool
+}
+
+// Operation is a method of an interface or a constructor.
+type Operation struct {
+	Name   string
+	Params []*Param
+	Result Type
+	Static bool
+}
+
+// Param is an operation parameter.
+type Param struct {
+	Name     string
+	Type     Type
+	Optional bool
+	Variadic bool
+}
+
+// Enum is a set of string values.
+type Enum struct {
+	Name   string
+	Values []EnumValue
+}
+
+// EnumValue is a single enum value. Name is optional and, if empty, the Go
+// constant name is derived from the value.
+type EnumValue struct {
+	Name  string
+	Value string
+}
+
+// Callback is a JavaScript function type.
+type Callback struct {
+	Name   string
+	Params []*Param
+	Result Type
+}
+
+// NewDefinitions returns an empty set of definitions.
+func NewDefinitions() *Definitions {
+	return &Definitions{
+		Typedefs:   map[string]Type{},
+		Globals:    &Interface{Namespace: true, Path: []string{}},
+		interfaces: map[string]*Interface{},
+	}
+}
+
+// Interface returns the interface with the given name, creating it if it
+// doesn't exist yet. Partial definitions of the same interface are merged.
+func (d *Definitions) Interface(name string) *Interface {
+	if iface, ok := d.interfaces[name]; ok {
+		return iface
+	}
+	iface := &Interface{Name: name}
+	d.interfaces[name] = iface
+	d.Interfaces = append(d.Interfaces, iface)
+	return iface
+}
+
+// lookup returns the interface with the given name, or nil if not defined.
+func (d *Definitions) lookup(name string) *Interface {
+	return d.interfaces[name]
+}
@@ -0,0 +1,842 @@
+package bindgen
+
+import "strings"
+
+// ParseTypeScript parses TypeScript declarations, such as the contents of a
+// .d.ts file, and adds them to defs. The name is used in error messages.
+//
+// Interfaces and classes become interfaces, string literal unions and string
+// enums become enums and function type aliases become callbacks. Constructors
+// and static members declared by the `declare var X: { new(): X; ... }` idiom
+// of lib.dom.d.ts are attached to the interface of the same name. Functions and
+// variables become members of the namespace or ambient module declaring them,
+// or of the Globals. Declarations which can't be bound are skipped with a
+// warning.
+func ParseTypeScript(defs *Definitions, name string, src []byte) error {
+	tokens, err := tokenize(name, string(src))
+	if err != nil {
+		return err
+	}
+	p := &parser{name: name, tokens: tokens, defs: defs}
+	for p.peek().kind != tokEOF {
+		if err := p.tsStatement(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *parser) tsStatement() error {
+	if p.accept(";") {
+		return nil
+	}
+	p.accept("export")
+	p.accept("declare")
+	p.accept("default")
+	p.accept("abstract")
+	switch {
+	case p.accept("interface"):
+		return p.tsInterface()
+	case p.accept("class"):
+		return p.tsClass()
+	case p.accept("type"):
+		return p.tsTypeAlias()
+	case p.accept("enum"):
+		return p.tsEnum()
+	case p.is("const") && p.peekN(1).text == "enum":
+		p.next()
+		p.next()
+		return p.tsEnum()
+	case p.accept("var") || p.accept("let") || p.accept("const"):
+		return p.tsVar()
+	case p.accept("function"):
+		return p.tsFunction()
+	case p.accept("namespace") || p.accept("module"):
+		return p.tsNamespace()
+	case p.accept("global"):
+		// Augmentations of the global scope within modules.
+		return p.tsBlock(nil)
+	case p.is("{"):
+		if err := p.skipBalanced(); err != nil {
+			return err
+		}
+		p.accept("from")
+		if p.peek().kind == tokString {
+			p.next()
+		}
+		p.accept(";")
+		return nil
+	case p.accept("import"):
+		return p.tsSkipMember()
+	case p.is("=") || p.is("*") || p.is("as"):
+		p.warnf("skipping unsupported export %s", p.peek())
+		return p.tsSkipMember()
+	}
+	return p.errorf("unexpected %s", p.peek())
+}
+
+// tsNamespace parses a namespace or an ambient module declaration, whose
+// functions and variables are bound as members of the namespace object or of
+// the module loaded with require().
+func (p *parser) tsNamespace() error {
+	var module string
+	path := []string{}
+	if p.scope != nil {
+		module = p.scope.Module
+		path = append(path, p.scope.Path...)
+	}
+	if t := p.peek(); t.kind == tokString && p.scope == nil {
+		p.next()
+		module = t.text
+	} else {
+		for {
+			name, err := p.ident()
+			if err != nil {
+				return err
+			}
+			path = append(path, name)
+			if !p.accept(".") {
+				break
+			}
+		}
+	}
+	if p.accept(";") {
+		return nil // Shorthand ambient modules declare no members.
+	}
+
+	name := strings.Join(path, ".")
+	if module != "" {
+		name = strings.Join(append([]string{module}, path...), ".")
+	}
+	// Namespaces merged with classes or interfaces declare their static members.
+	ns := p.defs.lookup(name)
+	if ns == nil {
+		ns = p.defs.Interface(name)
+		ns.Namespace = true
+		ns.Module = module
+		ns.Path = path
+	}
+	return p.tsBlock(ns)
+}
+
+// tsBlock parses the statements of a namespace body, adding the functions and
+// variables declared there to the scope, or the Globals if scope is nil.
+func (p *parser) tsBlock(scope *Interface) error {
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	outer := p.scope
+	p.scope = scope
+	defer func() { p.scope = outer }()
+	for !p.accept("}") {
+		if p.peek().kind == tokEOF {
+			return p.errorf("unexpected %s", p.peek())
+		}
+		if err := p.tsStatement(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tsScope returns the namespace which functions and variables are currently
+// declared in.
+func (p *parser) tsScope() *Interface {
+	if p.scope != nil {
+		return p.scope
+	}
+	return p.defs.Globals
+}
+
+// tsPlace records where the JavaScript object of an interface or class
+// declared in the current namespace is found.
+func (p *parser) tsPlace(iface *Interface) {
+	if p.scope == nil || iface.Path != nil {
+		return
+	}
+	iface.Module = p.scope.Module
+	iface.Path = append(append([]string{}, p.scope.Path...), iface.Name)
+}
+
+// tsFunction parses a function declaration.
+func (p *parser) tsFunction() error {
+	p.accept("*") // Generator functions.
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if err := p.tsSkipTypeParams(); err != nil {
+		return err
+	}
+	params, err := p.tsParams()
+	if err != nil {
+		return err
+	}
+	result := Type{Kind: Any}
+	if p.accept(":") {
+		if result, err = p.tsType(); err != nil {
+			return err
+		}
+	}
+	if p.is("{") {
+		// Implementations in .ts files.
+		if err := p.skipBalanced(); err != nil {
+			return err
+		}
+	}
+	p.accept(";")
+	scope := p.tsScope()
+	scope.Operations = append(scope.Operations, &Operation{Name: name, Params: params, Result: result, Static: true})
+	return nil
+}
+
+// tsSkipTypeParams skips generic type parameters or arguments, if any.
+func (p *parser) tsSkipTypeParams() error {
+	if p.is("<") {
+		return p.skipBalanced()
+	}
+	return nil
+}
+
+func (p *parser) tsInterface() error {
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	iface := p.defs.Interface(name)
+	p.tsPlace(iface)
+	if err := p.tsSkipTypeParams(); err != nil {
+		return err
+	}
+	if p.accept("extends") {
+		for {
+			parent, err := p.tsQualifiedName()
+			if err != nil {
+				return err
+			}
+			if err := p.tsSkipTypeParams(); err != nil {
+				return err
+			}
+			switch {
+			case parent == "":
+				// Qualified names refer to declarations outside of the file.
+			case iface.Inherits == "":
+				iface.Inherits = parent
+			default:
+				iface.Includes = append(iface.Includes, parent)
+			}
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+	return p.tsMembers(iface)
+}
+
+func (p *parser) tsClass() error {
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	iface := p.defs.Interface(name)
+	p.tsPlace(iface)
+	if err := p.tsSkipTypeParams(); err != nil {
+		return err
+	}
+	if p.accept("extends") {
+		parent, err := p.tsQualifiedName()
+		if err != nil {
+			return err
+		}
+		iface.Inherits = parent
+		if err := p.tsSkipTypeParams(); err != nil {
+			return err
+		}
+	}
+	if p.accept("implements") {
+		for !p.is("{") && p.peek().kind != tokEOF {
+			if err := p.skipExpression(); err != nil {
+				return err
+			}
+		}
+	}
+	return p.tsMembers(iface)
+}
+
+// tsQualifiedName parses a possibly qualified name. Returns an empty string for
+// qualified names.
+func (p *parser) tsQualifiedName() (string, error) {
+	name, err := p.ident()
+	if err != nil {
+		return "", err
+	}
+	qualified := false
+	for p.accept(".") {
+		qualified = true
+		if _, err := p.ident(); err != nil {
+			return "", err
+		}
+	}
+	if qualified {
+		return "", nil
+	}
+	return name, nil
+}
+
+func (p *parser) tsTypeAlias() error {
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if err := p.tsSkipTypeParams(); err != nil {
+		return err
+	}
+	if err := p.expect("="); err != nil {
+		return err
+	}
+
+	if values, ok := p.tsStringLiterals(); ok {
+		enum := &Enum{Name: name}
+		for _, v := range values {
+			enum.Values = append(enum.Values, EnumValue{Value: v})
+		}
+		p.defs.Enums = append(p.defs.Enums, enum)
+		p.accept(";")
+		return nil
+	}
+
+	if p.tsIsFunctionType() {
+		cb := &Callback{Name: name}
+		if err := p.tsSkipTypeParams(); err != nil {
+			return err
+		}
+		if cb.Params, err = p.tsParams(); err != nil {
+			return err
+		}
+		if err := p.expect("=>"); err != nil {
+			return err
+		}
+		if cb.Result, err = p.tsType(); err != nil {
+			return err
+		}
+		p.defs.Callbacks = append(p.defs.Callbacks, cb)
+		p.accept(";")
+		return nil
+	}
+
+	t, err := p.tsType()
+	if err != nil {
+		return err
+	}
+	p.defs.Typedefs[name] = t
+	p.accept(";")
+	return nil
+}
+
+// tsStringLiterals parses a union of string literals, such as `"a" | "b"`.
+// If the type is anything else, no tokens are consumed.
+func (p *parser) tsStringLiterals() ([]string, bool) {
+	start := p.pos
+	var values []string
+	p.accept("|")
+	for {
+		t := p.peek()
+		if t.kind != tokString {
+			p.pos = start
+			return nil, false
+		}
+		p.next()
+		values = append(values, t.text)
+		if !p.accept("|") {
+			break
+		}
+	}
+	if !p.is(";") && !p.is("}") && p.peek().kind != tokEOF && p.peek().line == p.tokens[p.pos-1].line {
+		p.pos = start
+		return nil, false
+	}
+	return values, true
+}
+
+func (p *parser) tsEnum() error {
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	enum := &Enum{Name: name}
+	numbers := 0
+	for !p.accept("}") {
+		t := p.next()
+		if t.kind != tokIdent && t.kind != tokString {
+			return p.errorf("expected enum member, found %s", t)
+		}
+		if p.accept("=") && p.peek().kind == tokString {
+			enum.Values = append(enum.Values, EnumValue{Name: t.text, Value: p.next().text})
+		} else {
+			numbers++
+		}
+		for !p.is(",") && !p.is("}") {
+			if err := p.skipExpression(); err != nil {
+				return err
+			}
+		}
+		p.accept(",")
+	}
+	switch {
+	case numbers == 0:
+		p.defs.Enums = append(p.defs.Enums, enum)
+	case len(enum.Values) == 0:
+		p.defs.Typedefs[name] = Type{Kind: Float}
+	default:
+		p.warnf("enum %s mixes string and numeric members, binding it as *js.Object", name)
+		p.defs.Typedefs[name] = Type{Kind: Any}
+	}
+	return nil
+}
+
+// tsVar parses a variable declaration. Variables with an object literal type
+// containing construct signatures declare constructors and static members of
+// the interface with the same name, other variables are members of the current
+// namespace.
+func (p *parser) tsVar() error {
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	variable := &Attribute{Name: name, Type: Type{Kind: Any}, Static: true}
+	scope := p.tsScope()
+	if !p.accept(":") {
+		scope.Attributes = append(scope.Attributes, variable)
+		return p.tsSkipMember()
+	}
+	if !p.is("{") {
+		if variable.Type, err = p.tsType(); err != nil {
+			return err
+		}
+		scope.Attributes = append(scope.Attributes, variable)
+		return p.tsSkipMember()
+	}
+	static := &Interface{Name: name}
+	if err := p.tsMembers(static); err != nil {
+		return err
+	}
+	p.accept(";")
+	if len(static.Constructors) == 0 {
+		scope.Attributes = append(scope.Attributes, variable)
+		return nil
+	}
+	iface := p.defs.Interface(name)
+	p.tsPlace(iface)
+	iface.Constructors = append(iface.Constructors, static.Constructors...)
+	for _, attr := range static.Attributes {
+		if attr.Name == "prototype" {
+			continue
+		}
+		attr.Static = true
+		iface.Attributes = append(iface.Attributes, attr)
+	}
+	for _, op := range static.Operations {
+		op.Static = true
+		iface.Operations = append(iface.Operations, op)
+	}
+	return nil
+}
+
+// tsSkipMember skips tokens up to the end of the current member or
+// statement.
+func (p *parser) tsSkipMember() error {
+	for !p.is(";") && !p.is(",") && !p.is("}") && p.peek().kind != tokEOF {
+		if p.is("<") {
+			if err := p.skipBalanced(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := p.skipExpression(); err != nil {
+			return err
+		}
+	}
+	p.accept(";")
+	return nil
+}
+
+// tsIsModifier reports whether the next token is used as a member modifier
+// rather than the member name.
+func (p *parser) tsIsModifier() bool {
+	switch p.peek().text {
+	case "readonly", "static", "public", "protected", "private", "abstract", "declare", "get", "set", "async":
+	default:
+		return false
+	}
+	next := p.peekN(1)
+	return next.kind == tokIdent || next.kind == tokString || next.kind == tokNumber || next.text == "["
+}
+
+func (p *parser) tsMembers(iface *Interface) error {
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for !p.accept("}") {
+		if p.accept(";") || p.accept(",") {
+			continue
+		}
+		if p.peek().kind == tokEOF {
+			return p.errorf("unexpected %s", p.peek())
+		}
+		if err := p.tsMember(iface); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *parser) tsMember(iface *Interface) error {
+	static, hidden, accessor := false, false, ""
+	for p.tsIsModifier() {
+		switch t := p.next().text; t {
+		case "static":
+			static = true
+		case "private", "protected":
+			hidden = true
+		case "get", "set":
+			accessor = t
+		}
+	}
+	if hidden {
+		// Parse the member anyway to skip it, but don't record it.
+		iface = &Interface{}
+	}
+
+	switch {
+	case p.is("(") || p.is("<") || p.is("["):
+		// Call and index signatures and computed member names.
+		return p.tsSkipMember()
+	case p.is("new") && (p.peekN(1).text == "(" || p.peekN(1).text == "<"),
+		p.is("constructor") && p.peekN(1).text == "(":
+		p.next()
+		if err := p.tsSkipTypeParams(); err != nil {
+			return err
+		}
+		params, err := p.tsParams()
+		if err != nil {
+			return err
+		}
+		if p.accept(":") {
+			if _, err := p.tsType(); err != nil {
+				return err
+			}
+		}
+		iface.Constructors = append(iface.Constructors, &Operation{Params: params})
+		return nil
+	}
+
+	t := p.next()
+	if t.kind != tokIdent && t.kind != tokString {
+		// Numeric member names can't be accessed through Go fields.
+		return p.tsSkipMember()
+	}
+	name := t.text
+	p.accept("?")
+
+	if p.is("(") || p.is("<") {
+		if err := p.tsSkipTypeParams(); err != nil {
+			return err
+		}
+		params, err := p.tsParams()
+		if err != nil {
+			return err
+		}
+		result := Type{Kind: Any}
+		if p.accept(":") {
+			if result, err = p.tsType(); err != nil {
+				return err
+			}
+		}
+		switch accessor {
+		case "get":
+			iface.Attributes = append(iface.Attributes, &Attribute{Name: name, Type: result, Static: static})
+		case "set":
+			for _, attr := range iface.Attributes {
+				if attr.Name == name {
+					return nil // Already declared by the getter.
+				}
+			}
+			attr := &Attribute{Name: name, Type: Type{Kind: Any}, Static: static}
+			if len(params) > 0 {
+				attr.Type = params[0].Type
+			}
+			iface.Attributes = append(iface.Attributes, attr)
+		default:
+			iface.Operations = append(iface.Operations, &Operation{Name: name, Params: params, Result: result, Static: static})
+		}
+		return nil
+	}
+
+	if !p.accept(":") {
+		return p.tsSkipMember()
+	}
+	typ, err := p.tsType()
+	if err != nil {
+		return err
+	}
+	iface.Attributes = append(iface.Attributes, &Attribute{Name: name, Type: typ, Static: static})
+	if p.is("=") {
+		return p.tsSkipMember()
+	}
+	return nil
+}
+
+func (p *parser) tsParams() ([]*Param, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var params []*Param
+	for !p.accept(")") {
+		param := &Param{Variadic: p.accept("...")}
+		switch t := p.peek(); {
+		case t.kind == tokIdent:
+			param.Name = p.next().text
+		case p.is("{") || p.is("["):
+			// Destructuring patterns.
+			if err := p.skipBalanced(); err != nil {
+				return nil, err
+			}
+			param.Name = "arg"
+		default:
+			return nil, p.errorf("expected parameter, found %s", t)
+		}
+		param.Optional = p.accept("?")
+		param.Type = Type{Kind: Any}
+		if p.accept(":") {
+			var err error
+			if param.Type, err = p.tsType(); err != nil {
+				return nil, err
+			}
+		}
+		if p.accept("=") {
+			param.Optional = true
+			for !p.is(",") && !p.is(")") {
+				if err := p.skipExpression(); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if param.Variadic {
+			if param.Type.Kind == Sequence {
+				param.Type = *param.Type.Elem
+			} else {
+				param.Type = Type{Kind: Any}
+			}
+		}
+		if param.Name != "this" {
+			params = append(params, param)
+		}
+		if !p.accept(",") && !p.is(")") {
+			return nil, p.errorf("expected \",\" or \")\", found %s", p.peek())
+		}
+	}
+	return params, nil
+}
+
+// tsIsFunctionType reports whether the next tokens start a function type, such
+// as `(a: string) => void`.
+func (p *parser) tsIsFunctionType() bool {
+	if p.is("<") {
+		return true
+	}
+	if !p.is("(") {
+		return false
+	}
+	depth := 0
+	for i := p.pos; i < len(p.tokens); i++ {
+		switch t := p.tokens[i]; {
+		case t.kind != tokPunct:
+		case t.text == "(" || t.text == "[" || t.text == "{":
+			depth++
+		case t.text == ")" || t.text == "]" || t.text == "}":
+			depth--
+			if depth == 0 {
+				return i+1 < len(p.tokens) && p.tokens[i+1].text == "=>"
+			}
+		}
+	}
+	return false
+}
+
+// tsType parses a type expression. Unions of a single type with null or
+// undefined map onto that type, other unions and intersections onto Any.
+func (p *parser) tsType() (Type, error) {
+	p.accept("|")
+	var members []Type
+	for {
+		t, err := p.tsIntersectionType()
+		if err != nil {
+			return Type{}, err
+		}
+		members = append(members, t)
+		if !p.accept("|") {
+			break
+		}
+	}
+	if p.accept("extends") {
+		// Conditional types.
+		for !p.is(";") && !p.is(",") && !p.is(")") && !p.is("}") && !p.is(">") && p.peek().kind != tokEOF {
+			if err := p.skipExpression(); err != nil {
+				return Type{}, err
+			}
+		}
+		return Type{Kind: Any}, nil
+	}
+
+	var nonVoid []Type
+	for _, t := range members {
+		if t.Kind != Void {
+			nonVoid = append(nonVoid, t)
+		}
+	}
+	switch {
+	case len(nonVoid) == 0:
+		return Type{Kind: Void}, nil
+	case len(nonVoid) == 1:
+		return nonVoid[0], nil
+	}
+	for _, t := range nonVoid {
+		if t != nonVoid[0] || t.Kind == Sequence {
+			return Type{Kind: Any}, nil
+		}
+	}
+	return nonVoid[0], nil // A union of literals of the same type.
+}
+
+func (p *parser) tsIntersectionType() (Type, error) {
+	p.accept("&")
+	t, err := p.tsPostfixType()
+	if err != nil {
+		return Type{}, err
+	}
+	for p.accept("&") {
+		if _, err := p.tsPostfixType(); err != nil {
+			return Type{}, err
+		}
+		t = Type{Kind: Any}
+	}
+	return t, nil
+}
+
+func (p *parser) tsPostfixType() (Type, error) {
+	t, err := p.tsPrimaryType()
+	if err != nil {
+		return Type{}, err
+	}
+	for p.is("[") && p.peek().line == p.tokens[p.pos-1].line {
+		if p.peekN(1).text == "]" {
+			p.next()
+			p.next()
+			elem := t
+			t = Type{Kind: Sequence, Elem: &elem}
+			continue
+		}
+		// Indexed access types.
+		if err := p.skipBalanced(); err != nil {
+			return Type{}, err
+		}
+		t = Type{Kind: Any}
+	}
+	return t, nil
+}
+
+func (p *parser) tsPrimaryType() (Type, error) {
+	switch t := p.peek(); {
+	case p.tsIsFunctionType():
+		if err := p.tsSkipTypeParams(); err != nil {
+			return Type{}, err
+		}
+		if _, err := p.tsParams(); err != nil {
+			return Type{}, err
+		}
+		if err := p.expect("=>"); err != nil {
+			return Type{}, err
+		}
+		_, err := p.tsType()
+		return Type{Kind: Any}, err
+	case p.accept("("):
+		inner, err := p.tsType()
+		if err != nil {
+			return Type{}, err
+		}
+		return inner, p.expect(")")
+	case p.is("{") || p.is("["):
+		// Object literal and tuple types.
+		return Type{Kind: Any}, p.skipBalanced()
+	case t.kind == tokString:
+		p.next()
+		return Type{Kind: String}, nil
+	case t.kind == tokNumber:
+		p.next()
+		return Type{Kind: Float}, nil
+	case p.accept("-"):
+		if p.peek().kind != tokNumber {
+			return Type{}, p.errorf("expected number, found %s", p.peek())
+		}
+		p.next()
+		return Type{Kind: Float}, nil
+	case p.accept("new") || p.accept("abstract"):
+		p.accept("new")
+		return p.tsPrimaryType()
+	case p.accept("typeof") || p.accept("keyof") || p.accept("unique") || p.accept("infer") || p.accept("readonly"):
+		if _, err := p.tsPostfixType(); err != nil {
+			return Type{}, err
+		}
+		return Type{Kind: Any}, nil
+	case t.kind != tokIdent:
+		return Type{}, p.errorf("expected type, found %s", t)
+	}
+
+	name := p.next().text
+	qualified := false
+	for p.accept(".") {
+		qualified = true
+		if _, err := p.ident(); err != nil {
+			return Type{}, err
+		}
+	}
+	if p.accept("is") {
+		// Type predicates are boolean results.
+		if _, err := p.tsType(); err != nil {
+			return Type{}, err
+		}
+		return Type{Kind: Bool}, nil
+	}
+	if p.is("<") {
+		if name == "Array" || name == "ReadonlyArray" {
+			p.next()
+			elem, err := p.tsType()
+			if err != nil {
+				return Type{}, err
+			}
+			return Type{Kind: Sequence, Elem: &elem}, p.expect(">")
+		}
+		// Instances of generic types can't be expressed in Go without
+		// generics support in the bindings.
+		return Type{Kind: Any}, p.skipBalanced()
+	}
+	if qualified {
+		return Type{Kind: Any}, nil
+	}
+	switch name {
+	case "number":
+		return Type{Kind: Float}, nil
+	case "string":
+		return Type{Kind: String}, nil
+	case "boolean", "true", "false":
+		return Type{Kind: Bool}, nil
+	case "void", "undefined", "null", "never":
+		return Type{Kind: Void}, nil
+	case "any", "unknown", "object", "symbol", "bigint", "Function", "Object", "this":
+		return Type{Kind: Any}, nil
+	}
+	return Type{Kind: Named, Name: name}, nil
+}
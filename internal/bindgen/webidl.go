@@ -0,0 +1,398 @@
+package bindgen
+
+// ParseWebIDL parses Web IDL definitions and adds them to defs. The name is
+// used in error messages.
+//
+// Partial interfaces and dictionaries are merged with the main definition,
+// extended attributes are ignored, and declarations without a Go counterpart,
+// such as iterable or maplike, are skipped.
+func ParseWebIDL(defs *Definitions, name string, src []byte) error {
+	tokens, err := tokenize(name, string(src))
+	if err != nil {
+		return err
+	}
+	p := &parser{name: name, tokens: tokens, defs: defs}
+	for p.peek().kind != tokEOF {
+		if err := p.idlDefinition(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *parser) skipExtendedAttributes() error {
+	for p.is("[") {
+		if err := p.skipBalanced(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *parser) idlDefinition() error {
+	if err := p.skipExtendedAttributes(); err != nil {
+		return err
+	}
+	if p.accept("callback") {
+		if p.accept("interface") {
+			// Callback interfaces are implemented by plain JS objects, which
+			// Go code would have to construct by hand anyway.
+			name, err := p.ident()
+			if err != nil {
+				return err
+			}
+			p.defs.Typedefs[name] = Type{Kind: Any}
+			return p.idlMembers(&Interface{Name: name})
+		}
+		return p.idlCallback()
+	}
+	p.accept("partial")
+	switch {
+	case p.accept("interface"):
+		mixin := p.accept("mixin")
+		iface, err := p.idlInterfaceHeader()
+		if err != nil {
+			return err
+		}
+		iface.Mixin = iface.Mixin || mixin
+		return p.idlMembers(iface)
+	case p.accept("namespace"):
+		iface, err := p.idlInterfaceHeader()
+		if err != nil {
+			return err
+		}
+		iface.Namespace = true
+		return p.idlMembers(iface)
+	case p.accept("dictionary"):
+		iface, err := p.idlInterfaceHeader()
+		if err != nil {
+			return err
+		}
+		iface.Dictionary = true
+		return p.idlDictionaryMembers(iface)
+	case p.accept("enum"):
+		return p.idlEnum()
+	case p.accept("typedef"):
+		t, err := p.idlType()
+		if err != nil {
+			return err
+		}
+		name, err := p.ident()
+		if err != nil {
+			return err
+		}
+		p.defs.Typedefs[name] = t
+		return p.expect(";")
+	case p.peek().kind == tokIdent && (p.peekN(1).text == "includes" || p.peekN(1).text == "implements"):
+		name := p.next().text
+		p.next()
+		mixin, err := p.ident()
+		if err != nil {
+			return err
+		}
+		iface := p.defs.Interface(name)
+		iface.Includes = append(iface.Includes, mixin)
+		return p.expect(";")
+	}
+	return p.errorf("unexpected %s", p.peek())
+}
+
+// idlInterfaceHeader parses the name and the optional parent of an interface,
+// namespace or dictionary.
+func (p *parser) idlInterfaceHeader() (*Interface, error) {
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	iface := p.defs.Interface(name)
+	if p.accept(":") {
+		if iface.Inherits, err = p.ident(); err != nil {
+			return nil, err
+		}
+	}
+	return iface, nil
+}
+
+func (p *parser) idlMembers(iface *Interface) error {
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for !p.accept("}") {
+		if err := p.idlMember(iface); err != nil {
+			return err
+		}
+	}
+	return p.expect(";")
+}
+
+func (p *parser) idlMember(iface *Interface) error {
+	if err := p.skipExtendedAttributes(); err != nil {
+		return err
+	}
+	switch {
+	case p.accept("const"):
+		return p.idlConst(iface)
+	case p.accept("constructor"):
+		params, err := p.idlParams()
+		if err != nil {
+			return err
+		}
+		iface.Constructors = append(iface.Constructors, &Operation{Params: params})
+		return p.expect(";")
+	case p.is("iterable") || p.is("async") || p.is("maplike") || p.is("setlike"):
+		return p.skipUntil(";")
+	case p.accept("stringifier"):
+		if p.accept(";") {
+			return nil
+		}
+	}
+
+	static := p.accept("static") || iface.Namespace
+	p.accept("inherit")
+	p.accept("readonly")
+	if p.accept("attribute") {
+		t, err := p.idlType()
+		if err != nil {
+			return err
+		}
+		name, err := p.ident()
+		if err != nil {
+			return err
+		}
+		iface.Attributes = append(iface.Attributes, &Attribute{Name: name, Type: t, Static: static})
+		return p.expect(";")
+	}
+
+	special := false
+	for p.is("getter") || p.is("setter") || p.is("deleter") {
+		p.next()
+		special = true
+	}
+	result, err := p.idlType()
+	if err != nil {
+		return err
+	}
+	name := ""
+	if p.peek().kind == tokIdent {
+		name = p.next().text
+	}
+	params, err := p.idlParams()
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		if !special {
+			return p.errorf("missing operation name")
+		}
+		// Anonymous getters and setters are property accesses, which are
+		// available through the embedded *js.Object.
+		return p.expect(";")
+	}
+	iface.Operations = append(iface.Operations, &Operation{Name: name, Params: params, Result: result, Static: static})
+	return p.expect(";")
+}
+
+func (p *parser) idlConst(iface *Interface) error {
+	if _, err := p.idlType(); err != nil {
+		return err
+	}
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if err := p.expect("="); err != nil {
+		return err
+	}
+	value := ""
+	if p.accept("-") {
+		value = "-"
+	}
+	t := p.next()
+	switch {
+	case t.kind == tokNumber:
+		value += t.text
+	case t.text == "true" || t.text == "false":
+		value = t.text
+	default:
+		// Infinity, NaN and null have no Go constant counterparts.
+		value = ""
+	}
+	if value != "" {
+		iface.Constants = append(iface.Constants, &Constant{Name: name, Value: value})
+	}
+	return p.expect(";")
+}
+
+func (p *parser) idlDictionaryMembers(iface *Interface) error {
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for !p.accept("}") {
+		if err := p.skipExtendedAttributes(); err != nil {
+			return err
+		}
+		p.accept("required")
+		t, err := p.idlType()
+		if err != nil {
+			return err
+		}
+		name, err := p.ident()
+		if err != nil {
+			return err
+		}
+		iface.Attributes = append(iface.Attributes, &Attribute{Name: name, Type: t})
+		if err := p.skipUntil(";"); err != nil { // Skip the default value.
+			return err
+		}
+	}
+	return p.expect(";")
+}
+
+func (p *parser) idlEnum() error {
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	enum := &Enum{Name: name}
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for !p.accept("}") {
+		t := p.next()
+		if t.kind != tokString {
+			return p.errorf("expected enum value, found %s", t)
+		}
+		enum.Values = append(enum.Values, EnumValue{Value: t.text})
+		if !p.accept(",") && !p.is("}") {
+			return p.errorf("expected \",\" or \"}\", found %s", p.peek())
+		}
+	}
+	p.defs.Enums = append(p.defs.Enums, enum)
+	return p.expect(";")
+}
+
+func (p *parser) idlCallback() error {
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	if err := p.expect("="); err != nil {
+		return err
+	}
+	result, err := p.idlType()
+	if err != nil {
+		return err
+	}
+	params, err := p.idlParams()
+	if err != nil {
+		return err
+	}
+	p.defs.Callbacks = append(p.defs.Callbacks, &Callback{Name: name, Params: params, Result: result})
+	return p.expect(";")
+}
+
+func (p *parser) idlParams() ([]*Param, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var params []*Param
+	for !p.accept(")") {
+		if err := p.skipExtendedAttributes(); err != nil {
+			return nil, err
+		}
+		param := &Param{Optional: p.accept("optional")}
+		var err error
+		if param.Type, err = p.idlType(); err != nil {
+			return nil, err
+		}
+		param.Variadic = p.accept("...")
+		if param.Name, err = p.ident(); err != nil {
+			return nil, err
+		}
+		if p.accept("=") {
+			for !p.is(",") && !p.is(")") {
+				if err := p.skipExpression(); err != nil {
+					return nil, err
+				}
+			}
+		}
+		params = append(params, param)
+		if !p.accept(",") && !p.is(")") {
+			return nil, p.errorf("expected \",\" or \")\", found %s", p.peek())
+		}
+	}
+	return params, nil
+}
+
+// skipExpression skips a single token or a bracketed group of tokens.
+func (p *parser) skipExpression() error {
+	if p.peek().kind == tokEOF {
+		return p.errorf("unexpected %s", p.peek())
+	}
+	if p.is("(") || p.is("[") || p.is("{") {
+		return p.skipBalanced()
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) idlType() (Type, error) {
+	if err := p.skipExtendedAttributes(); err != nil {
+		return Type{}, err
+	}
+	t, err := p.idlNonNullableType()
+	if err != nil {
+		return Type{}, err
+	}
+	p.accept("?") // Nullable types map onto the same Go types.
+	return t, nil
+}
+
+func (p *parser) idlNonNullableType() (Type, error) {
+	if p.is("(") {
+		// Union types don't have a Go counterpart.
+		return Type{Kind: Any}, p.skipBalanced()
+	}
+	name, err := p.ident()
+	if err != nil {
+		return Type{}, err
+	}
+	switch name {
+	case "unsigned":
+		return p.idlNonNullableType()
+	case "unrestricted", "float", "double":
+		p.accept("float")
+		p.accept("double")
+		return Type{Kind: Float}, nil
+	case "long":
+		if p.accept("long") {
+			return Type{Kind: Int64}, nil
+		}
+		return Type{Kind: Int}, nil
+	case "short", "byte", "octet":
+		return Type{Kind: Int}, nil
+	case "boolean":
+		return Type{Kind: Bool}, nil
+	case "DOMString", "USVString", "ByteString", "CSSOMString":
+		return Type{Kind: String}, nil
+	case "undefined", "void":
+		return Type{Kind: Void}, nil
+	case "any", "object", "symbol", "bigint":
+		return Type{Kind: Any}, nil
+	case "sequence", "FrozenArray", "ObservableArray":
+		if err := p.expect("<"); err != nil {
+			return Type{}, err
+		}
+		elem, err := p.idlType()
+		if err != nil {
+			return Type{}, err
+		}
+		return Type{Kind: Sequence, Elem: &elem}, p.expect(">")
+	case "Promise", "record":
+		if p.is("<") {
+			return Type{Kind: Any}, p.skipBalanced()
+		}
+		return Type{Kind: Any}, nil
+	}
+	return Type{Kind: Named, Name: name}, nil
+}
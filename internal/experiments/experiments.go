@@ -35,7 +35,27 @@ func init() {
 
 // Flags contains flags for currently supported experiments.
 type Flags struct {
-	// e.g. Generics bool `flag:"generics"`
+	// BigInt64 represents int64 and uint64 values as JavaScript BigInts instead
+	// of pairs of 32-bit numbers, and passes them to JavaScript as BigInts.
+	BigInt64 bool `flag:"bigint64"`
+	// MethodDCE eliminates exported methods that are never called by name,
+	// directly or through an interface, unless the program reflects on method
+	// sets. By default all exported methods of live types are kept.
+	MethodDCE bool `flag:"methoddce"`
+	// AsyncAwait compiles blocking functions into native JavaScript async
+	// functions, which await blocking calls, instead of resumable state
+	// machines. The output requires an ES2017-compatible engine.
+	AsyncAwait bool `flag:"asyncawait"`
+	// CallGraph resolves the functions that calls through interface methods
+	// and function values may call across the whole program, and only treats
+	// such calls as blocking if any of those functions may block. By default
+	// all such calls are assumed to be blocking.
+	CallGraph bool `flag:"callgraph"`
+	// MangleProps shortens the JavaScript property names of struct fields and
+	// unexported methods across the whole program. Reflection still sees the
+	// original names, but struct types created with reflect.StructOf use them
+	// as properties too.
+	MangleProps bool `flag:"mangleprops"`
 }
 
 // parseFlags parses the `raw` flags string and populates flag values in the
@@ -0,0 +1,267 @@
+// Package sizereport describes how the size of a compiled program breaks down
+// into its packages and declarations.
+//
+// The report is filled by the compiler while it writes the program and can be
+// saved either as JSON for further processing, or as a self-contained HTML page
+// with a treemap visualization.
+package sizereport
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Size is an amount of emitted code.
+type Size struct {
+	// Bytes is the number of bytes emitted into the output.
+	Bytes int `json:"bytes"`
+	// MinifiedBytes is the number of bytes the same code takes once minified.
+	// Equals Bytes if the output is minified already.
+	MinifiedBytes int `json:"minifiedBytes"`
+}
+
+// Add increases the size by o.
+func (s *Size) Add(o Size) {
+	s.Bytes += o.Bytes
+	s.MinifiedBytes += o.MinifiedBytes
+}
+
+// Report attributes the size of a compiled program to its parts.
+type Report struct {
+	// MainPackage is the import path of the program's main package.
+	MainPackage string `json:"mainPackage"`
+	// Minified is true if the output was minified.
+	Minified bool `json:"minified"`
+	// Total is the size of the whole program.
+	Total Size `json:"total"`
+	// Prelude is the size of the runtime prelude shared by all programs.
+	Prelude Size `json:"prelude"`
+	// Overhead is the size of the program code that doesn't belong to any
+	// package, such as the startup sequence and the position table.
+	Overhead Size `json:"overhead"`
+	// Packages in the order they appear in the output.
+	Packages []*Package `json:"packages"`
+}
+
+// Package attributes the size of a package's code to its declarations.
+type Package struct {
+	// ImportPath of the package.
+	ImportPath string `json:"importPath"`
+	// Total is the size of all code emitted for the package.
+	Total Size `json:"total"`
+	// JSFiles is the size of the package's hand-written .inc.js files.
+	JSFiles Size `json:"jsFiles"`
+	// Overhead is the size of the package's code that doesn't belong to any
+	// declaration, such as the package wrapper and the init function prologue.
+	Overhead Size `json:"overhead"`
+	// Decls are the declarations kept by dead-code elimination.
+	Decls []*Decl `json:"decls"`
+}
+
+// Decl attributes the size of a declaration's code to its parts.
+type Decl struct {
+	// Name is the declaration's fully qualified name.
+	Name string `json:"name"`
+	// Total is the size of all code emitted for the declaration.
+	Total Size `json:"total"`
+	// Parts maps the names of the compiler.Decl code fields, such as
+	// "FuncDeclCode" or "TypeInitCode", to the size of their code.
+	Parts map[string]Size `json:"parts"`
+	// Retention describes why dead-code elimination kept the declaration.
+	Retention Retention `json:"retention"`
+}
+
+// Add attributes the size of code emitted for the given part to the
+// declaration.
+func (d *Decl) Add(part string, size Size) {
+	if d.Parts == nil {
+		d.Parts = map[string]Size{}
+	}
+	p := d.Parts[part]
+	p.Add(size)
+	d.Parts[part] = p
+	d.Total.Add(size)
+}
+
+// Retention describes why dead-code elimination kept a declaration.
+type Retention struct {
+	// Root is set if the declaration is alive on its own: "alive" for entry
+	// points and other declarations with side effects, "unnamed" for
+	// declarations not subject to dead-code elimination, "go:linkname" for
+	// implementations of go:linkname directives and "package" for declarations
+	// of packages kept regardless of their use.
+	Root string `json:"root,omitempty"`
+	// KeptBy is the name of the live declaration that depends on this one.
+	KeptBy string `json:"keptBy,omitempty"`
+	// Dep is the dead-code elimination name through which KeptBy depends on
+	// this declaration, or "go:linkname" if KeptBy references this declaration
+	// with a go:linkname directive.
+	Dep string `json:"dep,omitempty"`
+}
+
+// Sort orders packages and declarations by decreasing emitted size.
+func (r *Report) Sort() {
+	sort.SliceStable(r.Packages, func(i, j int) bool {
+		return r.Packages[i].Total.Bytes > r.Packages[j].Total.Bytes
+	})
+	for _, p := range r.Packages {
+		sort.SliceStable(p.Decls, func(i, j int) bool {
+			return p.Decls[i].Total.Bytes > p.Decls[j].Total.Bytes
+		})
+	}
+}
+
+// WriteJSON writes the report as an indented JSON document.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteHTML writes the report as a self-contained HTML page, which presents it
+// as a treemap of packages and their declarations.
+func (r *Report) WriteHTML(w io.Writer) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return htmlTemplate.Execute(w, struct {
+		Title string
+		Data  template.JS
+	}{
+		Title: r.MainPackage,
+		Data:  template.JS(data),
+	})
+}
+
+// Write writes the report to w in the format implied by the file name: HTML
+// for the .html and .htm extensions and JSON otherwise.
+func (r *Report) Write(w io.Writer, fileName string) error {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".html", ".htm":
+		if err := r.WriteHTML(w); err != nil {
+			return fmt.Errorf("failed to write HTML size report: %w", err)
+		}
+	default:
+		if err := r.WriteJSON(w); err != nil {
+			return fmt.Errorf("failed to write JSON size report: %w", err)
+		}
+	}
+	return nil
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Size report: {{.Title}}</title>
+<style>
+body { font: 13px sans-serif; margin: 0; display: flex; flex-direction: column; height: 100vh; }
+header { padding: 8px; }
+#map { position: relative; flex: 1; margin: 0 8px 8px; }
+.node { position: absolute; box-sizing: border-box; overflow: hidden; border: 1px solid #fff; padding: 2px; cursor: pointer; }
+.node span { white-space: nowrap; pointer-events: none; }
+</style>
+</head>
+<body>
+<header>
+<strong id="title"></strong>
+<label><input type="checkbox" id="minified"> minified sizes</label>
+<a href="#" id="up">&#x2191; up</a>
+<span id="path"></span>
+</header>
+<div id="map"></div>
+<script>
+const report = {{.Data}};
+const mapEl = document.getElementById("map");
+const minifiedEl = document.getElementById("minified");
+let stack = [];
+
+const size = s => minifiedEl.checked ? s.minifiedBytes : s.bytes;
+const fmt = n => n >= 1024 ? (n / 1024).toFixed(1) + " KiB" : n + " B";
+
+function children(node) {
+  if (node.packages) {
+    return [{ name: "(prelude)", total: report.prelude }, { name: "(overhead)", total: report.overhead }]
+      .concat(node.packages.map(p => Object.assign({ name: p.importPath }, p)));
+  }
+  if (node.decls) {
+    return [{ name: "(js files)", total: node.jsFiles }, { name: "(overhead)", total: node.overhead }]
+      .concat(node.decls.map(d => Object.assign({ kids: d.parts }, d)));
+  }
+  if (node.kids) {
+    return Object.keys(node.kids).map(k => ({ name: k, total: node.kids[k] }));
+  }
+  return [];
+}
+
+function describe(node) {
+  let s = node.name + ": " + fmt(size(node.total));
+  const r = node.retention;
+  if (r) {
+    s += r.root ? "\nkept: " + r.root : "\nkept by: " + r.keptBy + " (" + r.dep + ")";
+  }
+  return s;
+}
+
+/* Lays the items out in rows of nearly square cells, see "Squarified Treemaps" by Bruls et al. */
+function squarify(items, x, y, w, h, out) {
+  let total = items.reduce((a, i) => a + i.value, 0);
+  while (items.length > 0) {
+    const short = Math.min(w, h), scale = (w * h) / total;
+    let row = [], rowSum = 0, worst = Infinity;
+    for (const item of items) {
+      const sum = rowSum + item.value;
+      const area = sum * scale, max = row.length > 0 ? row[0].value : item.value;
+      const ratio = Math.max(short * short * max * scale / (area * area), area * area / (short * short * item.value * scale));
+      if (ratio > worst) { break; }
+      worst = ratio; row.push(item); rowSum = sum;
+    }
+    const len = rowSum * scale / short;
+    let offset = 0;
+    for (const item of row) {
+      const cell = item.value * scale / len;
+      out.push(w >= h ? { item, x, y: y + offset, w: len, h: cell } : { item, x: x + offset, y, w: cell, h: len });
+      offset += cell;
+    }
+    if (w >= h) { x += len; w -= len; } else { y += len; h -= len; }
+    items = items.slice(row.length);
+    total -= rowSum;
+  }
+  return out;
+}
+
+function render() {
+  const node = stack[stack.length - 1];
+  document.getElementById("title").textContent = "Size report: " + report.mainPackage + " (" + fmt(size(report.total)) + ")";
+  document.getElementById("path").textContent = stack.slice(1).map(n => n.name).join(" / ");
+  mapEl.textContent = "";
+  const items = children(node).map(c => ({ node: c, value: size(c.total) })).filter(i => i.value > 0).sort((a, b) => b.value - a.value);
+  const cells = squarify(items, 0, 0, mapEl.clientWidth, mapEl.clientHeight, []);
+  cells.forEach((c, i) => {
+    const el = document.createElement("div");
+    el.className = "node";
+    Object.assign(el.style, { left: c.x + "px", top: c.y + "px", width: c.w + "px", height: c.h + "px", background: "hsl(" + (i * 47 % 360) + ",60%,75%)" });
+    el.title = describe(c.item.node);
+    const label = document.createElement("span");
+    label.textContent = c.item.node.name + " " + fmt(c.item.value);
+    el.appendChild(label);
+    el.onclick = () => { if (children(c.item.node).length > 0) { stack.push(c.item.node); render(); } };
+    mapEl.appendChild(el);
+  });
+}
+
+document.getElementById("up").onclick = e => { e.preventDefault(); if (stack.length > 1) { stack.pop(); render(); } };
+minifiedEl.onchange = render;
+window.onresize = render;
+stack = [Object.assign({ name: report.mainPackage }, report)];
+render();
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,81 @@
+package sizereport
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func testReport() *Report {
+	small := &Decl{Name: "example.com/small.F", Retention: Retention{KeptBy: "example.com/main.main", Dep: "example.com/small.F"}}
+	small.Add("FuncDeclCode", Size{Bytes: 10, MinifiedBytes: 8})
+	big := &Decl{Name: "example.com/main.main", Retention: Retention{Root: "alive"}}
+	big.Add("FuncDeclCode", Size{Bytes: 100, MinifiedBytes: 60})
+	big.Add("InitCode", Size{Bytes: 20, MinifiedBytes: 15})
+	big.Add("InitCode", Size{Bytes: 1, MinifiedBytes: 1})
+	return &Report{
+		MainPackage: "example.com/main",
+		Total:       Size{Bytes: 131, MinifiedBytes: 84},
+		Packages: []*Package{
+			{ImportPath: "example.com/small", Total: Size{Bytes: 10, MinifiedBytes: 8}, Decls: []*Decl{small}},
+			{ImportPath: "example.com/main", Total: Size{Bytes: 121, MinifiedBytes: 76}, Decls: []*Decl{big}},
+		},
+	}
+}
+
+func TestDeclAdd(t *testing.T) {
+	r := testReport()
+	d := r.Packages[1].Decls[0]
+	if want := (Size{Bytes: 121, MinifiedBytes: 76}); d.Total != want {
+		t.Errorf("Got: total %+v. Want: %+v.", d.Total, want)
+	}
+	if want := (Size{Bytes: 21, MinifiedBytes: 16}); d.Parts["InitCode"] != want {
+		t.Errorf("Got: InitCode %+v. Want: %+v.", d.Parts["InitCode"], want)
+	}
+}
+
+func TestSort(t *testing.T) {
+	r := testReport()
+	r.Sort()
+	got := []string{}
+	for _, p := range r.Packages {
+		got = append(got, p.ImportPath)
+	}
+	if diff := cmp.Diff([]string{"example.com/main", "example.com/small"}, got); diff != "" {
+		t.Errorf("Sort() produced unexpected package order (-want,+got):\n%s", diff)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	r := testReport()
+
+	t.Run("json", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		if err := r.Write(buf, "out.json"); err != nil {
+			t.Fatalf("Write() returned error: %s", err)
+		}
+		got := &Report{}
+		if err := json.Unmarshal(buf.Bytes(), got); err != nil {
+			t.Fatalf("Failed to parse JSON report: %s", err)
+		}
+		if diff := cmp.Diff(r, got); diff != "" {
+			t.Errorf("JSON report doesn't round-trip (-want,+got):\n%s", diff)
+		}
+	})
+
+	t.Run("html", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		if err := r.Write(buf, "out.HTML"); err != nil {
+			t.Fatalf("Write() returned error: %s", err)
+		}
+		html := buf.String()
+		for _, want := range []string{"<!DOCTYPE html>", "Size report: example.com/main", `"keptBy":"example.com/main.main"`} {
+			if !strings.Contains(html, want) {
+				t.Errorf("HTML report doesn't contain %q", want)
+			}
+		}
+	})
+}
@@ -19,12 +19,16 @@
 //     location in the generated code corresponds to.
 //   - Identifier maps a JS identifier to the original Go identifier it
 //     represents.
+//   - EndOfScope marks the end of the declaration, such as a function,
+//     started by an Identifier.
 //
 // More types may be added in future if necessary.
 //
 // Filter type is used to extract the hints from the written code stream and
 // pass them into source map generator. It also ensures that the encoded inline
 // hints don't make it into the final output, since they are not valid JS.
+// Optionally, the filter also collects a PositionTable, which is embedded into
+// the generated program for the runtime to report Go positions in call stacks.
 //
 // # Mapping JS source code
 //
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/evanw/esbuild/pkg/api"
+	"github.com/gopherjs/gopherjs/internal/experiments"
 	"github.com/neelance/sourcemap"
 	log "github.com/sirupsen/logrus"
 )
@@ -27,10 +28,11 @@ type Filter struct {
 	goMappingCallback goMappingCallbackHandle
 	jsMappingCallback jsMappingCallbackHandle
 
-	m        *sourcemap.Map
-	goroot   string
-	gopath   string
-	localMap bool
+	m         *sourcemap.Map
+	positions *PositionTable
+	goroot    string
+	gopath    string
+	localMap  bool
 
 	line   int
 	column int
@@ -45,8 +47,35 @@ func (f *Filter) EnableMapping(jsFileName, goroot, gopath string, localMap bool)
 	f.jsMappingCallback = f.defaultJSMappingCallback
 }
 
+// EnablePositionTable makes the filter collect a PositionTable from the
+// source map hints, which can be written into the output with
+// WritePositionTable.
+func (f *Filter) EnablePositionTable(goroot, gopath string, localMap bool) {
+	f.positions = &PositionTable{}
+	f.goroot = goroot
+	f.gopath = gopath
+	f.localMap = localMap
+}
+
+// IsMapping returns true if the filter uses the source map hints, either to
+// generate a source map or to collect a position table.
 func (f *Filter) IsMapping() bool {
-	return f.goMappingCallback != nil || f.jsMappingCallback != nil
+	return f.goMappingCallback != nil || f.jsMappingCallback != nil || f.positions != nil
+}
+
+// HasPositionTable returns true if the filter collects a position table.
+func (f *Filter) HasPositionTable() bool {
+	return f.positions != nil
+}
+
+// WritePositionTable writes the position table collected so far as a JS
+// expression. See PositionTable.Encode for the details of the format.
+func (f *Filter) WritePositionTable() (int, error) {
+	encoded, err := f.positions.Encode(f.line + 1)
+	if err != nil {
+		return 0, err
+	}
+	return f.Write([]byte(encoded))
 }
 
 func (f *Filter) WriteMappingTo(w io.Writer) error {
@@ -79,33 +108,69 @@ func (f *Filter) Write(p []byte) (n int, err error) {
 			return
 		}
 		h, length := ReadHint(p[i:])
-		if f.goMappingCallback != nil {
+		if f.goMappingCallback != nil || f.positions != nil {
 			value, err := h.Unpack()
 			if err != nil {
 				panic(fmt.Errorf("failed to unpack source map hint: %w", err))
 			}
-			switch value := value.(type) {
-			case token.Pos:
-				f.goMappingCallback(f.line+1, f.column, f.FileSet.Position(value), "")
-			case Identifier:
-				f.goMappingCallback(f.line+1, f.column, f.FileSet.Position(value.OriginalPos), value.OriginalName)
-			default:
-				panic(fmt.Errorf("unexpected source map hint type: %T", value))
-			}
+			f.handleHint(value)
 		}
 		p = p[i+length:]
 		n += length
 	}
 }
 
+// handleHint passes the unpacked hint value to the source map callback and the
+// position table.
+func (f *Filter) handleHint(value any) {
+	switch value := value.(type) {
+	case token.Pos:
+		pos := f.FileSet.Position(value)
+		if f.goMappingCallback != nil {
+			f.goMappingCallback(f.line+1, f.column, pos, "")
+		}
+		if f.positions != nil {
+			f.positions.Position(f.line+1, f.column, f.positionFile(pos), pos.Line)
+		}
+	case Identifier:
+		pos := f.FileSet.Position(value.OriginalPos)
+		if f.goMappingCallback != nil {
+			f.goMappingCallback(f.line+1, f.column, pos, value.OriginalName)
+		}
+		if f.positions != nil {
+			f.positions.EnterFunc(f.line+1, f.column, value.OriginalName, f.positionFile(pos), pos.Line)
+		}
+	case EndOfScope:
+		if f.positions != nil {
+			f.positions.ExitFunc(f.line+1, f.column)
+		}
+	default:
+		panic(fmt.Errorf("unexpected source map hint type: %T", value))
+	}
+}
+
+// positionFile returns the file name to be recorded in the position table,
+// or an empty string if the position is unknown.
+func (f *Filter) positionFile(pos token.Position) string {
+	if !pos.IsValid() {
+		return ""
+	}
+	return f.normalizePath(pos.Filename)
+}
+
 func (f *Filter) WriteJS(jsSource, jsFilePath string, minify bool) (n int, err error) {
 	if !minify && f.jsMappingCallback == nil {
 		// If not minimifying and not mapping, write source as-is.
 		return f.Write([]byte(jsSource))
 	}
 
+	target := api.ES2015
+	if experiments.Env.AsyncAwait {
+		// Keep async functions, instead of lowering them to generators.
+		target = api.ES2017
+	}
 	options := api.TransformOptions{
-		Target:         api.ES2015,
+		Target:         target,
 		Charset:        api.CharsetUTF8,
 		LegalComments:  api.LegalCommentsEndOfFile,
 		JSX:            api.JSXPreserve,
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"go/token"
 	"io"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -70,6 +71,74 @@ var x = function foo$1() {};
 	}
 }
 
+func TestFilterPositionTable(t *testing.T) {
+	code := &bytes.Buffer{}
+	filter := &Filter{
+		Writer:  code,
+		FileSet: token.NewFileSet(),
+	}
+	filter.EnablePositionTable("/goroot", "/gopath", false)
+
+	{
+		f := filter.FileSet.AddFile("/src/foo.go", filter.FileSet.Base(), 42)
+		f.AddLine(0)
+		f.AddLine(10)
+		f.AddLine(30)
+	}
+	outer := Identifier{Name: "foo$1", OriginalName: "main.Foo", OriginalPos: token.Pos(11)}
+	inner := Identifier{Name: "foo$2", OriginalName: "main.Foo.func1", OriginalPos: token.Pos(32)}
+
+	fmt.Fprintf(filter, "%sfunction %s() {\n", outer.EncodeHint(), outer)
+	writeHint(t, filter, token.Pos(16))
+	fmt.Fprintf(filter, "  f(%sfunction %s() {", inner.EncodeHint(), inner)
+	writeHint(t, filter, token.Pos(36))
+	fmt.Fprintf(filter, "g();}%s);\n", inner.EncodeEndHint())
+	fmt.Fprintf(filter, "}%s\n", outer.EncodeEndHint())
+	writeHint(t, filter, token.NoPos)
+	fmt.Fprintf(filter, "x();\n")
+
+	wantCode := "function foo$1() {\n  f(function foo$2() {g();});\n}\nx();\n"
+	if diff := cmp.Diff(wantCode, code.String()); diff != "" {
+		t.Errorf("Generated code differs from expected (-want,+got):\n%s", diff)
+	}
+
+	want := []positionEntry{
+		{genLine: 1, genCol: 0, file: 1, line: 2, fn: 1},  // function foo$1
+		{genLine: 2, genCol: 4, file: 1, line: 3, fn: 2},  // function foo$2
+		{genLine: 2, genCol: 27, file: 1, line: 2, fn: 1}, // back to the f() call
+		{genLine: 3, genCol: 1},                           // package level
+	}
+	if diff := cmp.Diff(want, filter.positions.entries, cmp.AllowUnexported(positionEntry{})); diff != "" {
+		t.Errorf("Position table entries differ from expected (-want,+got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"foo.go"}, filter.positions.fileSeq); diff != "" {
+		t.Errorf("Position table files differ from expected (-want,+got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"main.Foo", "main.Foo.func1"}, filter.positions.funcSeq); diff != "" {
+		t.Errorf("Position table functions differ from expected (-want,+got):\n%s", diff)
+	}
+
+	encoded, err := filter.positions.Encode(5)
+	if err != nil {
+		t.Fatalf("Got: Encode() returned error: %s. Want: no error.", err)
+	}
+	wantEncoded := `{line: 5, anchor: new Error(), files: ["foo.go"], funcs: ["main.Foo","main.Foo.func1"], mappings: "CACECCIACCAuBADDCCDFD"}`
+	if encoded != wantEncoded {
+		t.Errorf("Got: Encode() = %s. Want: %s.", encoded, wantEncoded)
+	}
+}
+
+func TestWriteVLQ(t *testing.T) {
+	tests := map[int]string{0: "A", 1: "C", -1: "D", 15: "e", 16: "gB", 123: "2H", -123: "3H"}
+	for v, want := range tests {
+		b := &strings.Builder{}
+		writeVLQ(b, v)
+		if got := b.String(); got != want {
+			t.Errorf("Got: writeVLQ(%d) = %q. Want: %q.", v, got, want)
+		}
+	}
+}
+
 func writeHint(t *testing.T, w io.Writer, value any) {
 	t.Helper()
 	hint := Hint{}
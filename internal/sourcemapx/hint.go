@@ -81,7 +81,7 @@ func (h *Hint) WriteTo(w io.Writer) (int64, error) {
 
 // Pack the given value into hint's payload.
 //
-// Supported types: go/token.Pos.
+// Supported types: go/token.Pos, Identifier, EndOfScope.
 //
 // The first byte of the payload will indicate the encoded type, and the rest
 // is an opaque, type-dependent binary representation of the type.
@@ -93,6 +93,8 @@ func (h *Hint) Pack(value any) error {
 		payload.WriteByte(1)
 	case Identifier:
 		payload.WriteByte(2)
+	case EndOfScope:
+		payload.WriteByte(3)
 	default:
 		return fmt.Errorf("unsupported hint payload type %T", value)
 	}
@@ -117,6 +119,8 @@ func (h *Hint) Unpack() (any, error) {
 		value = &v
 	case 2:
 		value = &Identifier{}
+	case 3:
+		value = &EndOfScope{}
 	default:
 		return nil, fmt.Errorf("unsupported hint payload type flag: %d", h.Payload[0])
 	}
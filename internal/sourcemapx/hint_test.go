@@ -162,6 +162,12 @@ func TestHintPack(t *testing.T) {
 			OriginalName: "foo",
 			OriginalPos:  token.Pos(42),
 		},
+	}, {
+		descr: "end of scope",
+		value: EndOfScope{OriginalPos: token.Pos(42)},
+	}, {
+		descr: "end of scope without position",
+		value: EndOfScope{},
 	}}
 
 	for _, test := range tests {
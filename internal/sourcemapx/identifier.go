@@ -26,10 +26,27 @@ func (i Identifier) String() string {
 // inserted into the generated code to be later extracted by the SourceMapFilter
 // to produce a source map.
 func (i Identifier) EncodeHint() string {
+	return encodeHint(i)
+}
+
+// EncodeEndHint returns a string with an encoded EndOfScope hint, which should
+// be inserted after the generated code of the declaration the identifier
+// refers to, such as a function.
+func (i Identifier) EncodeEndHint() string {
+	return encodeHint(EndOfScope{OriginalPos: i.OriginalPos})
+}
+
+// EndOfScope marks the end of the generated code for the declaration, which
+// beginning was marked by an Identifier hint.
+type EndOfScope struct {
+	OriginalPos token.Pos // Original identifier position.
+}
+
+func encodeHint(value any) string {
 	buf := &strings.Builder{}
 	h := Hint{}
-	if err := h.Pack(i); err != nil {
-		panic(fmt.Errorf("failed to pack identifier source map hint: %w", err))
+	if err := h.Pack(value); err != nil {
+		panic(fmt.Errorf("failed to pack %T source map hint: %w", value, err))
 	}
 	if _, err := h.WriteTo(buf); err != nil {
 		panic(fmt.Errorf("failed to write source map hint into a buffer: %w", err))
@@ -0,0 +1,186 @@
+package sourcemapx
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PositionTable is a compact mapping from generated code locations to the
+// original Go source positions and functions they belong to.
+//
+// Unlike a source map, the table is embedded into the generated program, so
+// that the GopherJS runtime can report Go positions in runtime.Callers() and
+// friends even if the host doesn't support source maps.
+//
+// The table is built from the same source map hints as the source map itself:
+// position hints update the current Go position, Identifier hints open a new
+// function scope and EndOfScope hints close it.
+type PositionTable struct {
+	files   map[string]int
+	fileSeq []string
+	funcs   map[string]int
+	funcSeq []string
+
+	entries []positionEntry
+	scopes  []positionEntry // Stack of the enclosing function scopes.
+}
+
+// positionEntry maps a generated code location onto the Go file, line and
+// function. File and function indices are 1-based, zero means unknown.
+type positionEntry struct {
+	genLine, genCol int
+	file, line, fn  int
+}
+
+func (t *PositionTable) fileIndex(name string) int {
+	if t.files == nil {
+		t.files = map[string]int{}
+	}
+	idx, ok := t.files[name]
+	if !ok {
+		t.fileSeq = append(t.fileSeq, name)
+		idx = len(t.fileSeq)
+		t.files[name] = idx
+	}
+	return idx
+}
+
+func (t *PositionTable) funcIndex(name string) int {
+	if t.funcs == nil {
+		t.funcs = map[string]int{}
+	}
+	idx, ok := t.funcs[name]
+	if !ok {
+		t.funcSeq = append(t.funcSeq, name)
+		idx = len(t.funcSeq)
+		t.funcs[name] = idx
+	}
+	return idx
+}
+
+// current returns the entry describing the innermost function scope, or an
+// unknown position outside of any function.
+func (t *PositionTable) current() positionEntry {
+	if len(t.scopes) == 0 {
+		return positionEntry{}
+	}
+	return t.scopes[len(t.scopes)-1]
+}
+
+// add records that generated code starting at the given location corresponds
+// to the entry's Go position.
+func (t *PositionTable) add(genLine, genCol int, e positionEntry) {
+	e.genLine, e.genCol = genLine, genCol
+	if len(t.scopes) > 0 {
+		t.scopes[len(t.scopes)-1] = e
+	}
+	if n := len(t.entries); n > 0 {
+		last := t.entries[n-1]
+		if last.file == e.file && last.line == e.line && last.fn == e.fn {
+			return // The previous entry already covers the location.
+		}
+	}
+	t.entries = append(t.entries, e)
+}
+
+// Position records that generated code starting at the given location
+// corresponds to the Go file and line. Empty file means that the Go position is
+// unknown.
+func (t *PositionTable) Position(genLine, genCol int, file string, line int) {
+	e := positionEntry{fn: t.current().fn}
+	if file != "" {
+		e.file = t.fileIndex(file)
+		e.line = line
+	}
+	t.add(genLine, genCol, e)
+}
+
+// EnterFunc records the beginning of the generated code for the Go function
+// with the given name, declared at the given file and line.
+func (t *PositionTable) EnterFunc(genLine, genCol int, name string, file string, line int) {
+	t.scopes = append(t.scopes, positionEntry{fn: t.funcIndex(name)})
+	t.Position(genLine, genCol, file, line)
+}
+
+// ExitFunc records the end of the generated code for the innermost function.
+// Generated code after it corresponds to the most recent position within the
+// enclosing function, such as a statement a function literal is a part of.
+func (t *PositionTable) ExitFunc(genLine, genCol int) {
+	if len(t.scopes) == 0 {
+		return
+	}
+	t.scopes = t.scopes[:len(t.scopes)-1]
+	t.add(genLine, genCol, t.current())
+}
+
+// Encode the table as a JavaScript object literal.
+//
+// The object has the following properties:
+//   - line: the generated line the object literal is written at, which
+//     allows to compute the offset of the program within the script;
+//   - anchor: an Error object, whose stack trace reveals the actual
+//     script name and line at runtime;
+//   - files and funcs: lists of Go file and function names;
+//   - mappings: entries encoded as groups of five base64 VLQ numbers: generated
+//     line delta, generated column (relative to the previous entry if on the
+//     same line), and deltas of the 1-based file index, Go line and 1-based
+//     function index.
+func (t *PositionTable) Encode(line int) (string, error) {
+	files, err := json.Marshal(nonNil(t.fileSeq))
+	if err != nil {
+		return "", fmt.Errorf("failed to encode position table files: %w", err)
+	}
+	funcs, err := json.Marshal(nonNil(t.funcSeq))
+	if err != nil {
+		return "", fmt.Errorf("failed to encode position table functions: %w", err)
+	}
+
+	mappings := &strings.Builder{}
+	prev := positionEntry{}
+	for _, e := range t.entries {
+		if e.genLine != prev.genLine {
+			writeVLQ(mappings, e.genLine-prev.genLine)
+			writeVLQ(mappings, e.genCol)
+		} else {
+			writeVLQ(mappings, 0)
+			writeVLQ(mappings, e.genCol-prev.genCol)
+		}
+		writeVLQ(mappings, e.file-prev.file)
+		writeVLQ(mappings, e.line-prev.line)
+		writeVLQ(mappings, e.fn-prev.fn)
+		prev = e
+	}
+
+	return fmt.Sprintf("{line: %d, anchor: new Error(), files: %s, funcs: %s, mappings: %q}", line, files, funcs, mappings.String()), nil
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+const vlqAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// writeVLQ writes a signed integer in the base64 VLQ encoding used by source
+// maps: the sign is stored in the least significant bit, and each base64 digit
+// carries five bits of the value and a continuation bit.
+func writeVLQ(b *strings.Builder, v int) {
+	u := v << 1
+	if v < 0 {
+		u = (-v << 1) | 1
+	}
+	for {
+		digit := u & 0x1f
+		u >>= 5
+		if u > 0 {
+			digit |= 0x20
+		}
+		b.WriteByte(vlqAlphabet[digit])
+		if u == 0 {
+			return
+		}
+	}
+}
@@ -20,9 +20,25 @@
 //	| functions             | Function              | func(...any) *js.Object |
 //	| time.Time             | Date                  | time.Time               |
 //	| -                     | instanceof Node       | *js.Object              |
-//	| maps, structs         | instanceof Object     | map[string]any          |
+//	| maps with string keys | instanceof Object     | map[string]any          |
+//	| other maps            | Map                   | map[any]any             |
+//	| structs               | instanceof Object     | map[string]any          |
+//	| channels              | see below             | channel                 |
+//
+// Maps can be internalized from both a plain object and a Map. A channel is passed as an object with the methods send(value), returning a promise resolved once the value was accepted, recv(), returning a promise of an object {value, ok}, and close(). Only the methods permitted by the channel's direction are present. Receivable channels are also async iterables over the received values. Passing such an object back to Go yields the original channel.
+//
+// With the bigint64 experiment enabled (GOPHERJS_EXPERIMENT=bigint64), int64 and uint64 are passed as BigInt instead of Number.
 //
 // Additionally, for a struct containing a *js.Object field, only the content of the field will be passed to JavaScript and vice versa.
+//
+// Other structs are converted to objects with a property for each exported field. The property name and conversion options can be set with a `js:"name,options"` field tag:
+//
+//	Name string `js:"name"`           // Property "name".
+//	Note string `js:"note,omitempty"`  // Property "note", omitted if the field is a zero value or empty.
+//	Size int    `js:",omitempty"`      // Property named after the field, omitted if zero.
+//	Temp int    `js:"-"`               // Not converted at all.
+//
+// Properties of untagged fields are named after the fields, unless the package declaring the struct type sets a naming strategy with the `//gopherjs:js-naming camelCase` or `//gopherjs:js-naming snake_case` directive in its package doc comment, in which case a field UserID maps to the property "userID" or "user_id" respectively.
 package js
 
 // Object is a container for a native JavaScript object. Calls to its methods are treated specially by GopherJS and translated directly to their JavaScript syntax. A nil pointer to Object is equal to JavaScript's "null". Object can not be used as a map key.
@@ -122,6 +138,93 @@ func MakeFunc(fn func(this *Object, arguments []*Object) any) *Object {
 	return Global.Call("$makeFunc", InternalObject(fn))
 }
 
+// MakeAsyncFunc wraps a function, which may block, into a JavaScript function
+// returning a Promise. Each call runs fn in a new goroutine. The promise is
+// fulfilled with the result of fn, or rejected if fn returns a non-nil error or
+// panics. This allows JavaScript code to await Go code which performs channel
+// operations, network requests, etc.
+func MakeAsyncFunc(fn func(this *Object, arguments []*Object) (any, error)) *Object {
+	return MakeFunc(func(this *Object, arguments []*Object) any {
+		return NewPromise(func() (any, error) {
+			return fn(this, arguments)
+		})
+	})
+}
+
+// NewPromise returns a JavaScript Promise, which is settled with the result of
+// fn, running in a new goroutine. The promise is rejected if fn returns a
+// non-nil error or panics.
+func NewPromise(fn func() (any, error)) *Object {
+	return Global.Get("Promise").New(InternalObject(func(resolve, reject *Object) {
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					reject.Invoke(errorObject(r))
+				}
+			}()
+			value, err := fn()
+			if err != nil {
+				reject.Invoke(errorObject(err))
+				return
+			}
+			resolve.Invoke(value)
+		}()
+	}))
+}
+
+// Await blocks the calling goroutine until the promise settles and returns its
+// fulfillment value. If the promise is rejected, Await returns an *Error
+// holding the rejection reason. Values other than promises or thenables are
+// returned as is.
+//
+// Similar to other blocking operations, Await may not be called from a
+// JavaScript callback. Wrap the code in a goroutine instead.
+func Await(promise *Object) (*Object, error) {
+	type result struct {
+		value *Object
+		err   error
+	}
+	c := make(chan result, 1)
+	Global.Call("$awaitPromise", promise, InternalObject(func(value *Object) {
+		c <- result{value: value}
+	}), InternalObject(func(reason *Object) {
+		c <- result{err: &Error{errorObject(reason)}}
+	}))
+	r := <-c
+	return r.value, r.err
+}
+
+// callAsync runs fn in a new goroutine and returns a Promise of its result. It
+// is used by the prelude to call Go functions, which may block, on behalf of
+// JavaScript code.
+func callAsync(fn func() *Object) *Object {
+	return NewPromise(func() (any, error) {
+		return fn(), nil
+	})
+}
+
+// errorObject converts a Go error, a panic value or a promise rejection reason
+// into a JavaScript Error object.
+func errorObject(v any) *Object {
+	switch v := v.(type) {
+	case *Error:
+		return v.Object
+	case *Object:
+		if Global.Call("$instanceOf", v, Global.Get("Error")).Bool() {
+			return v
+		}
+		err := Global.Get("Error").New(Global.Get("String").Invoke(v))
+		err.Set("cause", v)
+		return err
+	case error:
+		return Global.Get("Error").New(v.Error())
+	case string:
+		return Global.Get("Error").New(v)
+	default:
+		return Global.Get("Error").New(Global.Get("String").Invoke(v))
+	}
+}
+
 // Keys returns the keys of the given JavaScript object.
 func Keys(o *Object) []string {
 	if o == nil || o == Undefined {
@@ -137,6 +240,17 @@ func Keys(o *Object) []string {
 
 // MakeWrapper creates a JavaScript object which has wrappers for the exported methods of i. Use explicit getter and setter methods to expose struct fields to JavaScript.
 func MakeWrapper(i any) *Object {
+	return makeWrapper(i, false)
+}
+
+// MakeAsyncWrapper is like MakeWrapper, but the wrappers run the methods of i
+// in a new goroutine and return a Promise of their results, so that the
+// methods may block.
+func MakeAsyncWrapper(i any) *Object {
+	return makeWrapper(i, true)
+}
+
+func makeWrapper(i any, async bool) *Object {
 	v := InternalObject(i)
 	o := Global.Get("Object").New()
 	o.Set("__internal_object__", v)
@@ -147,20 +261,90 @@ func MakeWrapper(i any) *Object {
 			continue
 		}
 		o.Set(m.Get("name").String(), func(args ...*Object) *Object {
-			return Global.Call("$externalizeFunction", v.Get(m.Get("prop").String()), m.Get("typ"), true).Call("apply", v, args)
+			return Global.Call("$externalizeFunction", v.Get(m.Get("prop").String()), m.Get("typ"), true, Undefined, async).Call("apply", v, args)
 		})
 	}
 	return o
 }
 
+// AsyncFunc converts the Go function fn into a JavaScript function, which runs
+// fn in a new goroutine and returns a Promise of its results, so that fn may
+// block. Arguments and results are converted the same way as for Go functions
+// passed to JavaScript directly.
+func AsyncFunc(fn any) *Object {
+	v := InternalObject(fn)
+	if v.Get("constructor").Get("kind") != Global.Get("$kindFunc") {
+		panic("js.AsyncFunc: argument is not a function")
+	}
+	return Global.Call("$externalizeFunction", v.Get("$val"), v.Get("constructor"), false, Undefined, true)
+}
+
 // MakeFullWrapper creates a JavaScript object which has wrappers for the exported
 // methods of i, and, where i is a (pointer to a) struct value, wrapped getters
 // and setters
 // (https://developer.mozilla.org/en-US/docs/Web/JavaScript/Reference/Global_Objects/Object/defineProperty)
 // for the non-embedded exported fields of i. Values accessed via these methods
 // and getters are themselves wrapped when accessed, but an important point to
-// note is that a new wrapped value is created on each access.
+// note is that a new wrapped value is created on each access. Use
+// MakeCachedFullWrapper if the identity of wrappers matters.
+// Properties are named the same way as when the struct is converted to a
+// JavaScript object, see the package documentation, except that fields with
+// the omitempty option are always present.
 func MakeFullWrapper(i any) *Object {
+	return makeFullWrapper(i, InternalObject(MakeFullWrapper))
+}
+
+// MakeCachedWrapper is like MakeWrapper, but returns the same JavaScript
+// object each time it is called with the same Go pointer. See
+// MakeCachedFullWrapper for details.
+func MakeCachedWrapper(i any) *Object {
+	return cachedWrapper(wrapperCache, i, MakeWrapper)
+}
+
+// MakeCachedFullWrapper is like MakeFullWrapper, but returns the same
+// JavaScript object each time it is called with the same Go pointer, which
+// also applies to the pointers accessed via the methods and getters of the
+// wrapper. This makes Go values usable where JavaScript code relies on object
+// identity, e.g. as props of UI frameworks or as keys of a Map. Since a
+// wrapper converts back to the Go pointer it was created for, a pointer
+// passed to JavaScript and back keeps its wrapper.
+//
+// Wrappers are held in a WeakMap keyed by the pointer, so caching doesn't
+// keep either of them alive. Use ReleaseWrapper to drop a wrapper explicitly.
+// Values other than pointers aren't cached, a new wrapper is created for them
+// each time, as by MakeFullWrapper.
+func MakeCachedFullWrapper(i any) *Object {
+	return cachedWrapper(fullWrapperCache, i, func(i any) *Object {
+		return makeFullWrapper(i, InternalObject(MakeCachedFullWrapper))
+	})
+}
+
+// ReleaseWrapper removes the wrappers created for the pointer i by
+// MakeCachedWrapper and MakeCachedFullWrapper from their caches, so that the
+// next call creates a new wrapper. Wrappers that were already handed out keep
+// working.
+func ReleaseWrapper(i any) {
+	v := InternalObject(i)
+	wrapperCache.Call("delete", v)
+	fullWrapperCache.Call("delete", v)
+}
+
+var wrapperCache, fullWrapperCache *Object
+
+func cachedWrapper(cache *Object, i any, wrap func(any) *Object) *Object {
+	v := InternalObject(i)
+	if v == nil || v == Undefined || v.Get("constructor").Get("kind") != Global.Get("$kindPtr") {
+		return wrap(i)
+	}
+	if w := cache.Call("get", v); w != Undefined {
+		return w
+	}
+	w := wrap(i)
+	cache.Call("set", v, w)
+	return w
+}
+
+func makeFullWrapper(i any, makeWrapper *Object) *Object {
 	internalObj := InternalObject(i)
 	constructor := internalObj.Get("constructor")
 
@@ -201,7 +385,7 @@ func MakeFullWrapper(i any) *Object {
 		})
 	}
 
-	var fields *Object
+	structType := constructor
 	methods := Global.Get("Array").New()
 	if ms := constructor.Get("methods"); ms != Undefined {
 		methods = methods.Call("concat", ms)
@@ -209,10 +393,8 @@ func MakeFullWrapper(i any) *Object {
 	// If we are a pointer value then add fields from element,
 	// else the constructor itself will have them.
 	if e := constructor.Get("elem"); e != Undefined {
-		fields = e.Get("fields")
+		structType = e
 		methods = methods.Call("concat", e.Get("methods"))
-	} else {
-		fields = constructor.Get("fields")
 	}
 	for i := 0; i < methods.Length(); i++ {
 		m := methods.Index(i)
@@ -221,23 +403,21 @@ func MakeFullWrapper(i any) *Object {
 		}
 		defineProperty(m.Get("prop").String(), M{
 			"value": func(args ...*Object) *Object {
-				return Global.Call("$externalizeFunction", internalObj.Get(m.Get("prop").String()), m.Get("typ"), true, InternalObject(MakeFullWrapper)).Call("apply", internalObj, args)
+				return Global.Call("$externalizeFunction", internalObj.Get(m.Get("prop").String()), m.Get("typ"), true, makeWrapper).Call("apply", internalObj, args)
 			},
 		})
 	}
-	if fields != Undefined {
+	if structType.Get("fields") != Undefined {
+		fields := Global.Call("$jsFields", structType)
 		for i := 0; i < fields.Length(); i++ {
-			f := fields.Index(i)
-			if !f.Get("exported").Bool() {
-				continue
-			}
-			defineProperty(f.Get("prop").String(), M{
+			f := fields.Index(i).Get("field")
+			defineProperty(fields.Index(i).Get("name").String(), M{
 				"get": func() *Object {
 					vc := Global.Call("$copyIfRequired", internalObj.Get("$val").Get(f.Get("prop").String()), f.Get("typ"))
-					return Global.Call("$externalize", vc, f.Get("typ"), InternalObject(MakeFullWrapper))
+					return Global.Call("$externalize", vc, f.Get("typ"), makeWrapper)
 				},
 				"set": func(jv *Object) {
-					gv := Global.Call("$internalize", jv, f.Get("typ"), InternalObject(MakeFullWrapper))
+					gv := Global.Call("$internalize", jv, f.Get("typ"), makeWrapper)
 					internalObj.Get("$val").Set(f.Get("prop").String(), gv)
 				},
 			})
@@ -254,6 +434,56 @@ func NewArrayBuffer(b []byte) *Object {
 	return slice.Get("$array").Get("buffer").Call("slice", offset, offset+length)
 }
 
+// MarshalObject converts v to plain JavaScript values the way encoding/json
+// converts it to JSON: structs become objects with a property for each exported
+// field, named according to the json struct tags, maps become objects, and
+// slices and arrays become arrays. Unlike with encoding/json, a []byte becomes
+// a Uint8Array with a copy of the bytes, and a *Object is passed through as is.
+// Marshaler implementations are ignored.
+//
+// The conversion doesn't go through JSON text. The compiler generates a
+// converter for each named struct type, so MarshalObject is considerably
+// faster than JSON.parse(json.Marshal(v)) or Externalize for large values.
+// It returns an error for values that can't be converted, such as channels and
+// functions.
+func MarshalObject(v any) (o *Object, err error) {
+	defer catchConversionError(&err)
+	return Global.Call("$jsonMarshal", InternalObject(v)), nil
+}
+
+// UnmarshalObject converts the plain JavaScript value o to the Go value pointed
+// to by v, the way encoding/json would decode o converted to JSON, except that
+// a Uint8Array may be converted to a []byte. Unmarshaler implementations are
+// ignored. UnmarshalObject returns an error if v is not a non-nil pointer or
+// o has a different shape than v, in which case v may have been partially
+// modified.
+func UnmarshalObject(o *Object, v any) (err error) {
+	defer catchConversionError(&err)
+	Global.Call("$jsonUnmarshal", o, InternalObject(v))
+	return nil
+}
+
+// conversionError is returned by MarshalObject and UnmarshalObject.
+type conversionError string
+
+func (err conversionError) Error() string {
+	return string(err)
+}
+
+// catchConversionError recovers from the exceptions thrown by the prelude's
+// JSON-style conversion helpers and stores them in *err.
+func catchConversionError(err *error) {
+	e := recover()
+	if e == nil {
+		return
+	}
+	if jsErr, ok := e.(*Error); ok && jsErr.Get("$jsonError") != Undefined {
+		*err = conversionError(jsErr.Get("message").String())
+		return
+	}
+	panic(e)
+}
+
 // M is a simple map type. It is intended as a shorthand for JavaScript objects (before conversion).
 type M map[string]any
 
@@ -261,6 +491,10 @@ type M map[string]any
 type S []any
 
 func init() {
+	Global.Set("$callAsync", InternalObject(callAsync))
+	wrapperCache = Global.Get("WeakMap").New()
+	fullWrapperCache = Global.Get("WeakMap").New()
+
 	// Avoid dead code elimination.
 	e := Error{}
 	_ = e
@@ -0,0 +1,64 @@
+package tests_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// experimentBuilds are the ways to build testdata/experiments, with each of
+// the GOPHERJS_EXPERIMENT flags changing the generated code and with -O.
+var experimentBuilds = []struct {
+	name       string
+	experiment string
+	args       []string
+}{
+	{name: `default`},
+	{name: `asyncawait`, experiment: `asyncawait`},
+	{name: `callgraph`, experiment: `callgraph`},
+	{name: `methoddce`, experiment: `methoddce`},
+	{name: `mangleprops`, experiment: `mangleprops`},
+	{name: `optimize`, args: []string{`-O`}},
+}
+
+// Test_Experiments uses testdata/experiments/main.go to test that the program
+// behaves the same with each experiment enabled.
+func Test_Experiments(t *testing.T) {
+	for _, build := range experimentBuilds {
+		t.Run(build.name, func(t *testing.T) {
+			t.Setenv(`GOPHERJS_EXPERIMENT`, build.experiment)
+			runOutputTest(t, `testdata`, `experiments`, build.args...)
+		})
+	}
+}
+
+// BenchmarkBuildSize builds testdata/experiments/main.go minified with each
+// experiment enabled, and reports the size of the generated code along with
+// the build time.
+func BenchmarkBuildSize(b *testing.B) {
+	if runtime.GOOS == `js` {
+		b.Skip(`benchmark meant to be run using normal Go compiler (needs os/exec)`)
+	}
+
+	mainPath := filepath.Join(`testdata`, `experiments`, `main.go`)
+	for _, build := range experimentBuilds {
+		b.Run(build.name, func(b *testing.B) {
+			b.Setenv(`GOPHERJS_EXPERIMENT`, build.experiment)
+			outPath := filepath.Join(b.TempDir(), `main.js`)
+			args := append([]string{`build`, `-m`, `-o`, outPath, mainPath}, build.args...)
+			for i := 0; i < b.N; i++ {
+				if out, err := exec.Command(`gopherjs`, args...).CombinedOutput(); err != nil {
+					b.Fatalf("gopherjs build failed: %v:\n%s", err, out)
+				}
+			}
+
+			info, err := os.Stat(outPath)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportMetric(float64(info.Size()), `bytes`)
+		})
+	}
+}
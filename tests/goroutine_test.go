@@ -239,6 +239,104 @@ func BenchmarkGoroutineSwitching(b *testing.B) {
 	}
 }
 
+func maybeBlocking(c chan bool, n int) int {
+	if c != nil {
+		<-c
+	}
+	return n + 1
+}
+
+func BenchmarkBlockingCall(b *testing.B) {
+	// This benchmark measures the overhead of calling a function that may block,
+	// but doesn't. Such calls pay for the machinery that would allow them to
+	// resume later, e.g. state machine dispatch or awaiting a promise, which
+	// makes it useful for comparing the ways blocking functions are compiled.
+	n := 0
+	for i := 0; i < b.N; i++ {
+		n = maybeBlocking(nil, n)
+	}
+}
+
+func blockingDeepStack(c chan bool, depth int) {
+	if depth == 0 {
+		<-c
+		return
+	}
+	blockingDeepStack(c, depth-1)
+}
+
+func BenchmarkBlockingDeepStack(b *testing.B) {
+	// This benchmark measures the cost of blocking and resuming a goroutine
+	// with several blocking functions on its call stack, all of which have to
+	// be suspended and resumed along with the innermost one.
+	c := make(chan bool)
+	go func() {
+		for i := 0; i < b.N; i++ {
+			c <- true
+		}
+	}()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		blockingDeepStack(c, 10)
+	}
+}
+
+type incrementer interface{ increment(n int) int }
+
+type step int
+
+func (s step) increment(n int) int { return n + int(s) }
+
+func BenchmarkInterfaceCall(b *testing.B) {
+	// This benchmark measures the cost of calling a method through an interface
+	// none of whose implementations block. Such calls are assumed to be blocking
+	// unless the callgraph experiment resolves their targets, which makes it
+	// useful for comparing the blocking analysis of dynamic calls.
+	var inc incrementer = step(1)
+	n := 0
+	for i := 0; i < b.N; i++ {
+		n = inc.increment(n)
+	}
+}
+
+type promiser interface{ promise(v int) *js.Object }
+
+type resolver struct{}
+
+func (resolver) promise(v int) *js.Object {
+	return js.Global.Get("Promise").Call("resolve", v)
+}
+
+func TestBlockingCallReturningPromise(t *testing.T) {
+	// Calls of interface methods and function values may block, but the
+	// JavaScript promises returned by callees which don't block must reach
+	// the caller as they are, also when blocking functions are async.
+	var p promiser = resolver{}
+	calls := map[string]func(int) *js.Object{
+		"interface method": func(v int) *js.Object { return p.promise(v) },
+		"method value":     p.promise,
+		"method expression": func(v int) *js.Object {
+			return promiser.promise(p, v)
+		},
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			promise := call(42)
+			if promise.Get("then") == js.Undefined {
+				t.Fatalf("Got: %v. Want: a promise.", promise)
+			}
+			got, err := js.Await(promise)
+			if err != nil {
+				t.Fatalf("Got: js.Await() returned error: %v. Want: no error.", err)
+			}
+			if got.Int() != 42 {
+				t.Errorf("Got: js.Await() = %v. Want: 42.", got)
+			}
+		})
+	}
+}
+
 func TestEventLoopStarvation(t *testing.T) {
 	// See: https://github.com/gopherjs/gopherjs/issues/1078.
 	c := make(chan bool)
@@ -13,6 +13,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 
 	"github.com/gopherjs/gopherjs/js"
+	"github.com/gopherjs/gopherjs/tests/jsnaming"
 )
 
 var dummys = js.Global.Call("eval", `({
@@ -710,6 +711,43 @@ func TestMakeFullWrapperGettersAndSetters(t *testing.T) {
 	}
 }
 
+func TestMakeCachedFullWrapper(t *testing.T) {
+	f := &F{Field: 50}
+	m := &M{Name: "Gopher", Pointer: f}
+	same := func(a, b *js.Object) bool {
+		return js.Global.Call("eval", `(function(a, b) { return a === b; })`).Invoke(a, b).Bool()
+	}
+
+	w := js.MakeCachedFullWrapper(m)
+	if !same(w, js.MakeCachedFullWrapper(m)) {
+		t.Errorf("Got: MakeCachedFullWrapper() returned different objects for the same pointer. Want: the same object.")
+	}
+	if same(w, js.MakeCachedFullWrapper(&M{})) {
+		t.Errorf("Got: MakeCachedFullWrapper() returned the same object for different pointers. Want: different objects.")
+	}
+	if !same(w.Get("Pointer"), w.Get("Pointer")) || !same(w.Get("Pointer"), js.MakeCachedFullWrapper(f)) {
+		t.Errorf("Got: the wrapper of a pointer field changes between accesses. Want: the same object.")
+	}
+
+	var got *M
+	back := func(m *M) *js.Object {
+		got = m
+		return js.MakeCachedFullWrapper(m)
+	}
+	if r := js.Global.Call("eval", `(function(f, w) { return f(w); })`).Invoke(back, w); got != m || !same(r, w) {
+		t.Errorf("Got: round trip through JavaScript returned %p and a different wrapper: %v. Want: %p and the same wrapper.", got, !same(r, w), m)
+	}
+
+	if !same(js.MakeCachedWrapper(m), js.MakeCachedWrapper(m)) {
+		t.Errorf("Got: MakeCachedWrapper() returned different objects for the same pointer. Want: the same object.")
+	}
+
+	js.ReleaseWrapper(m)
+	if same(w, js.MakeCachedFullWrapper(m)) {
+		t.Errorf("Got: MakeCachedFullWrapper() returned the released wrapper. Want: a new wrapper.")
+	}
+}
+
 func TestCallWithNull(t *testing.T) {
 	c := make(chan int, 1)
 	js.Global.Set("test", func() {
@@ -830,6 +868,84 @@ func TestExternalize(t *testing.T) {
 	}
 }
 
+func TestExternalizeMap(t *testing.T) {
+	type key struct{ A, B int }
+	m := map[key]string{{1, 2}: "x"}
+
+	if got := js.Global.Call("eval", "(function(m) { return m instanceof Map && m.size; })").Invoke(m).Int(); got != 1 {
+		t.Fatalf("Got: externalized Map of size %d. Want: 1.", got)
+	}
+
+	var back map[key]string
+	js.Global.Call("eval", "(function(m, f) { f(m); })").Invoke(m, func(v map[key]string) { back = v })
+	if !reflect.DeepEqual(back, m) {
+		t.Errorf("Got: round trip %v. Want: %v.", back, m)
+	}
+
+	var ints map[int]string
+	js.Global.Call("eval", `(function(f) { f(new Map([[1, "a"], [2, "b"]])); })`).Invoke(func(v map[int]string) { ints = v })
+	if want := map[int]string{1: "a", 2: "b"}; !reflect.DeepEqual(ints, want) {
+		t.Errorf("Got: internalized %v. Want: %v.", ints, want)
+	}
+
+	var anyMap any
+	js.Global.Call("eval", `(function(f) { f(new Map([[1, "a"]])); })`).Invoke(func(v any) { anyMap = v })
+	if want := map[any]any{1.0: "a"}; !reflect.DeepEqual(anyMap, want) {
+		t.Errorf("Got: internalized %#v. Want: %#v.", anyMap, want)
+	}
+}
+
+func TestExternalizeChan(t *testing.T) {
+	c := make(chan int, 1)
+	o := js.Global.Get("Object").New()
+	o.Set("c", c)
+	if o.Get("c") != o.Get("c") {
+		t.Errorf("Got: different objects for the same channel. Want: the same object.")
+	}
+
+	if _, err := js.Await(o.Get("c").Call("send", 1)); err != nil {
+		t.Fatalf("Got: send() failed: %v. Want: no error.", err)
+	}
+	if got := <-c; got != 1 {
+		t.Errorf("Got: received %d. Want: 1.", got)
+	}
+
+	go func() {
+		c <- 2
+		c <- 3
+		close(c)
+	}()
+	r, err := js.Await(o.Get("c").Call("recv"))
+	if err != nil {
+		t.Fatalf("Got: recv() failed: %v. Want: no error.", err)
+	}
+	if r.Get("value").Int() != 2 || !r.Get("ok").Bool() {
+		t.Errorf("Got: recv() = {value: %v, ok: %v}. Want: {value: 2, ok: true}.", r.Get("value"), r.Get("ok"))
+	}
+
+	collect := js.Global.Call("eval", `(async function(c) { const r = []; for await (const v of c) { r.push(v); } return r.join(","); })`)
+	all, err := js.Await(collect.Invoke(o.Get("c")))
+	if err != nil {
+		t.Fatalf("Got: iteration failed: %v. Want: no error.", err)
+	}
+	if got := all.String(); got != "3" {
+		t.Errorf("Got: iterated %q. Want: %q.", got, "3")
+	}
+
+	if _, err := js.Await(o.Get("c").Call("send", 4)); err == nil {
+		t.Errorf("Got: no error sending on a closed channel. Want: an error.")
+	}
+
+	var back <-chan int
+	js.Global.Call("eval", "(function(c, f) { f(c); })").Invoke(o.Get("c"), func(v <-chan int) { back = v })
+	if back != c {
+		t.Errorf("Got: a different channel after round trip. Want: the same channel.")
+	}
+	if got := js.Global.Call("eval", "(function(c) { return typeof c.send; })").Invoke((<-chan int)(c)).String(); got != "undefined" {
+		t.Errorf("Got: typeof send = %q on a receive-only channel. Want: %q.", got, "undefined")
+	}
+}
+
 func TestInternalizeSlice(t *testing.T) {
 	tests := []struct {
 		name string
@@ -977,6 +1093,250 @@ func TestStructWithNonIdentifierJSTag(t *testing.T) {
 	}
 }
 
+func TestStructTagOptions(t *testing.T) {
+	type Tagged struct {
+		Name  string `js:"name"`
+		Note  string `js:"note,omitempty"`
+		Count int    `js:",omitempty"`
+		Items []int  `js:"items,omitempty"`
+		Temp  int    `js:"-"`
+		Plain bool
+	}
+	stringify := js.Global.Call("eval", "(function(x) { return JSON.stringify(x); })")
+
+	t.Run("Externalize", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			input Tagged
+			want  string
+		}{{
+			name:  "empty",
+			input: Tagged{Temp: 1},
+			want:  `{"name":"","Plain":false}`,
+		}, {
+			name:  "full",
+			input: Tagged{Name: "a", Note: "b", Count: 2, Items: []int{3}, Temp: 4, Plain: true},
+			want:  `{"name":"a","note":"b","Count":2,"items":[3],"Plain":true}`,
+		}, {
+			name:  "empty slice",
+			input: Tagged{Items: []int{}},
+			want:  `{"name":"","Plain":false}`,
+		}}
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				if got := stringify.Invoke(test.input).String(); got != test.want {
+					t.Errorf("Got: %s. Want: %s.", got, test.want)
+				}
+			})
+		}
+	})
+
+	t.Run("Internalize", func(t *testing.T) {
+		var got Tagged
+		js.Global.Call("eval", `(function(f) { f({name: "a", note: "b", Count: 2, items: [3], Temp: 4, "-": 5, Plain: true}); })`).Invoke(func(v Tagged) { got = v })
+		want := Tagged{Name: "a", Note: "b", Count: 2, Items: []int{3}, Plain: true}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Internalized struct differs from expected (-want,+got):\n%s", diff)
+		}
+	})
+
+	t.Run("MakeFullWrapper", func(t *testing.T) {
+		v := &Tagged{Name: "a", Temp: 4}
+		w := js.MakeFullWrapper(v)
+		keys := js.Global.Call("eval", `(function(o) { return ["name", "note", "Count", "items", "Temp", "Plain"].filter(k => k in o).join(","); })`).Invoke(w).String()
+		if want := "name,note,Count,items,Plain"; keys != want {
+			t.Errorf("Got: wrapper properties %q. Want: %q.", keys, want)
+		}
+		w.Set("name", "b")
+		if v.Name != "b" {
+			t.Errorf("Got: v.Name = %q after setting the wrapper property. Want: %q.", v.Name, "b")
+		}
+	})
+}
+
+func TestStructJSNaming(t *testing.T) {
+	u := jsnaming.User{UserID: 1, FullName: "Gopher", HTTPHost: "golang.org", Password: "secret"}
+	got := js.Global.Get("JSON").Call("stringify", u).String()
+	want := `{"userID":1,"fullName":"Gopher","host":"golang.org"}`
+	if got != want {
+		t.Errorf("Got: %s. Want: %s.", got, want)
+	}
+
+	var back jsnaming.User
+	js.Global.Call("eval", `(function(f) { f({userID: 2, fullName: "JS", host: "example.com", Password: "x"}); })`).Invoke(func(v jsnaming.User) { back = v })
+	if diff := cmp.Diff(jsnaming.User{UserID: 2, FullName: "JS", HTTPHost: "example.com"}, back); diff != "" {
+		t.Errorf("Internalized struct differs from expected (-want,+got):\n%s", diff)
+	}
+}
+
+type jsonBase struct {
+	ID int `json:"id"`
+}
+
+type jsonRecord struct {
+	jsonBase
+	Name   string         `json:"name,omitempty"`
+	Tags   []string       `json:"tags"`
+	Scores map[string]int `json:"scores"`
+	Big    int64          `json:"big"`
+	Next   *jsonRecord    `json:"next,omitempty"`
+	Data   []byte         `json:"data"`
+	Extra  any            `json:"extra"`
+	Skip   int            `json:"-"`
+	hidden int
+	Anon   struct{ X int }   `json:"anon"`
+	Nested map[string][]bool `json:"nested,omitempty"`
+}
+
+func TestMarshalObject(t *testing.T) {
+	r := &jsonRecord{
+		jsonBase: jsonBase{ID: 1},
+		Name:     "Gopher ☺",
+		Tags:     []string{"a", "b"},
+		Scores:   map[string]int{"x": 2},
+		Big:      1 << 40,
+		Next:     &jsonRecord{jsonBase: jsonBase{ID: 2}},
+		Data:     []byte{1, 2},
+		Extra:    []any{"s", 1.5, true, nil},
+		Skip:     3,
+		hidden:   4,
+	}
+	r.Anon.X = 5
+	o, err := js.MarshalObject(r)
+	if err != nil {
+		t.Fatalf("Got: MarshalObject() returned error %q. Want: no error.", err)
+	}
+	if got, want := o.Get("data").Get("constructor").Get("name").String(), "Uint8Array"; got != want {
+		t.Errorf("Got: data converted to %s. Want: %s.", got, want)
+	}
+	o.Set("data", nil)
+	got := js.Global.Get("JSON").Call("stringify", o).String()
+	want := `{"id":1,"name":"Gopher ☺","tags":["a","b"],"scores":{"x":2},"big":1099511627776,` +
+		`"next":{"id":2,"tags":null,"scores":null,"big":0,"data":null,"extra":null,"anon":{"X":0}},` +
+		`"data":null,"extra":["s",1.5,true,null],"anon":{"X":5}}`
+	if got != want {
+		t.Errorf("Got: %s. Want: %s.", got, want)
+	}
+
+	if _, err := js.MarshalObject(map[string]any{"c": make(chan int)}); err == nil || err.Error() != "js: unsupported type: chan int" {
+		t.Errorf("Got: MarshalObject() of a channel returned error %v. Want: js: unsupported type: chan int.", err)
+	}
+}
+
+func TestUnmarshalObject(t *testing.T) {
+	o := js.Global.Call("eval", `({
+		id: 7, name: "JS ☺", tags: ["q"], scores: {y: 3}, big: 1099511627776,
+		next: {id: 8}, data: new Uint8Array([3, 4]), extra: {k: [1]}, Skip: 9,
+		anon: {X: 6}, nested: {b: [true, false]}
+	})`)
+	r := jsonRecord{Name: "old", Skip: 1}
+	if err := js.UnmarshalObject(o, &r); err != nil {
+		t.Fatalf("Got: UnmarshalObject() returned error %q. Want: no error.", err)
+	}
+	want := jsonRecord{
+		jsonBase: jsonBase{ID: 7},
+		Name:     "JS ☺",
+		Tags:     []string{"q"},
+		Scores:   map[string]int{"y": 3},
+		Big:      1 << 40,
+		Next:     &jsonRecord{jsonBase: jsonBase{ID: 8}},
+		Data:     []byte{3, 4},
+		Extra:    map[string]any{"k": []any{1.0}},
+		Skip:     1,
+		Nested:   map[string][]bool{"b": {true, false}},
+	}
+	want.Anon.X = 6
+	if diff := cmp.Diff(want, r, cmp.AllowUnexported(jsonRecord{})); diff != "" {
+		t.Errorf("UnmarshalObject() result differs from expected (-want,+got):\n%s", diff)
+	}
+
+	tests := []struct {
+		descr string
+		o     *js.Object
+		v     any
+		want  string
+	}{{
+		descr: "type mismatch",
+		o:     js.Global.Call("eval", `({id: "1"})`),
+		v:     &jsonRecord{},
+		want:  "js: cannot unmarshal string into Go value of type int",
+	}, {
+		descr: "fraction into int",
+		o:     js.Global.Call("eval", `({id: 1.5})`),
+		v:     &jsonRecord{},
+		want:  "js: cannot unmarshal number into Go value of type int",
+	}, {
+		descr: "non-pointer",
+		o:     js.Global.Call("eval", `({})`),
+		v:     jsonRecord{},
+		want:  "js: UnmarshalObject(non-pointer tests_test.jsonRecord)",
+	}, {
+		descr: "nil pointer",
+		o:     js.Global.Call("eval", `({})`),
+		v:     (*jsonRecord)(nil),
+		want:  "js: UnmarshalObject(nil *tests_test.jsonRecord)",
+	}}
+	for _, test := range tests {
+		t.Run(test.descr, func(t *testing.T) {
+			err := js.UnmarshalObject(test.o, test.v)
+			if err == nil || err.Error() != test.want {
+				t.Errorf("Got: error %v. Want: %q.", err, test.want)
+			}
+		})
+	}
+}
+
+type jsonShadowBase struct {
+	Name string
+	ID   int
+}
+
+type jsonShadowed struct {
+	Name string
+	jsonShadowBase
+}
+
+func TestObjectConvertersShadowing(t *testing.T) {
+	// The fields of the outer struct take precedence over the promoted ones, and
+	// the compiler-generated converters agree with the reflection fallback.
+	typ := js.InternalObject(&jsonShadowed{}).Get("constructor").Get("elem")
+	convert := func() (string, jsonShadowed) {
+		v := &jsonShadowed{Name: "outer", jsonShadowBase: jsonShadowBase{Name: "inner", ID: 1}}
+		o, err := js.MarshalObject(v)
+		if err != nil {
+			t.Fatalf("Got: MarshalObject() returned error %q. Want: no error.", err)
+		}
+		var back jsonShadowed
+		if err := js.UnmarshalObject(js.Global.Call("eval", `({Name: "js", ID: 2})`), &back); err != nil {
+			t.Fatalf("Got: UnmarshalObject() returned error %q. Want: no error.", err)
+		}
+		return js.Global.Get("JSON").Call("stringify", o).String(), back
+	}
+
+	if typ.Get("jsonEncode") == js.Undefined {
+		t.Fatal("Got: no generated converters for jsonShadowed. Want: generated converters.")
+	}
+	generated, generatedBack := convert()
+	if want := `{"Name":"outer","ID":1}`; generated != want {
+		t.Errorf("Got: %s. Want: %s.", generated, want)
+	}
+
+	encode, decode := typ.Get("jsonEncode"), typ.Get("jsonDecode")
+	typ.Delete("jsonEncode")
+	typ.Delete("jsonDecode")
+	defer func() {
+		typ.Set("jsonEncode", encode)
+		typ.Set("jsonDecode", decode)
+	}()
+	fallback, fallbackBack := convert()
+	if generated != fallback {
+		t.Errorf("Got: %s from the generated converter. Want: %s, as from reflection.", generated, fallback)
+	}
+	if diff := cmp.Diff(fallbackBack, generatedBack); diff != "" {
+		t.Errorf("Generated converter decodes differently from reflection (-want,+got):\n%s", diff)
+	}
+}
+
 func TestSliceData(t *testing.T) {
 	var (
 		s0 = []int(nil)
@@ -1014,3 +1374,147 @@ func TestSliceData(t *testing.T) {
 		t.Errorf("slice data for different slices were the same")
 	}
 }
+
+func TestAwait(t *testing.T) {
+	promise := js.Global.Get("Promise")
+
+	t.Run("Fulfilled", func(t *testing.T) {
+		v, err := js.Await(promise.Call("resolve", 42))
+		if err != nil {
+			t.Fatalf("Got: js.Await() returned error: %v. Want: no error.", err)
+		}
+		if got := v.Int(); got != 42 {
+			t.Errorf("Got: js.Await() = %d. Want: 42.", got)
+		}
+	})
+
+	t.Run("Delayed", func(t *testing.T) {
+		p := js.Global.Call("eval", `new Promise(resolve => setTimeout(() => resolve("done"), 10))`)
+		v, err := js.Await(p)
+		if err != nil {
+			t.Fatalf("Got: js.Await() returned error: %v. Want: no error.", err)
+		}
+		if got := v.String(); got != "done" {
+			t.Errorf("Got: js.Await() = %q. Want: %q.", got, "done")
+		}
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		_, err := js.Await(promise.Call("reject", js.Global.Get("Error").New("boom")))
+		jsErr, ok := err.(*js.Error)
+		if !ok {
+			t.Fatalf("Got: js.Await() returned error %#v. Want: *js.Error.", err)
+		}
+		if got, want := jsErr.Error(), "JavaScript error: boom"; got != want {
+			t.Errorf("Got: error %q. Want: %q.", got, want)
+		}
+	})
+
+	t.Run("RejectedWithNonError", func(t *testing.T) {
+		_, err := js.Await(promise.Call("reject", "boom"))
+		jsErr, ok := err.(*js.Error)
+		if !ok {
+			t.Fatalf("Got: js.Await() returned error %#v. Want: *js.Error.", err)
+		}
+		if got, want := jsErr.Error(), "JavaScript error: boom"; got != want {
+			t.Errorf("Got: error %q. Want: %q.", got, want)
+		}
+		if got := jsErr.Get("cause").String(); got != "boom" {
+			t.Errorf("Got: error cause %q. Want: %q.", got, "boom")
+		}
+	})
+
+	t.Run("NotPromise", func(t *testing.T) {
+		o := js.Global.Get("Object").New()
+		v, err := js.Await(o)
+		if err != nil {
+			t.Fatalf("Got: js.Await() returned error: %v. Want: no error.", err)
+		}
+		if v != o {
+			t.Errorf("Got: js.Await() returned a different object. Want: the same object.")
+		}
+	})
+}
+
+func TestMakeAsyncFunc(t *testing.T) {
+	t.Run("Blocking", func(t *testing.T) {
+		c := make(chan int)
+		fn := js.MakeAsyncFunc(func(this *js.Object, arguments []*js.Object) (any, error) {
+			return <-c + arguments[0].Int(), nil
+		})
+		p := fn.Invoke(1)
+		go func() { c <- 41 }()
+
+		v, err := js.Await(p)
+		if err != nil {
+			t.Fatalf("Got: awaiting the async function returned error: %v. Want: no error.", err)
+		}
+		if got := v.Int(); got != 42 {
+			t.Errorf("Got: async function result %d. Want: 42.", got)
+		}
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		fn := js.MakeAsyncFunc(func(this *js.Object, arguments []*js.Object) (any, error) {
+			return nil, fmt.Errorf("boom")
+		})
+		_, err := js.Await(fn.Invoke())
+		if got, want := fmt.Sprint(err), "JavaScript error: boom"; got != want {
+			t.Errorf("Got: error %q. Want: %q.", got, want)
+		}
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		fn := js.MakeAsyncFunc(func(this *js.Object, arguments []*js.Object) (any, error) {
+			panic("oops")
+		})
+		_, err := js.Await(fn.Invoke())
+		if got, want := fmt.Sprint(err), "JavaScript error: oops"; got != want {
+			t.Errorf("Got: error %q. Want: %q.", got, want)
+		}
+	})
+}
+
+type asyncCounter struct{ c chan int }
+
+func (a *asyncCounter) Next(delta int) int { return <-a.c + delta }
+
+func TestMakeAsyncWrapper(t *testing.T) {
+	a := &asyncCounter{c: make(chan int)}
+	w := js.MakeAsyncWrapper(a)
+
+	p := w.Call("Next", 1)
+	if p.Get("then") == js.Undefined {
+		t.Fatalf("Got: %v returned by the wrapper. Want: a Promise.", p)
+	}
+	go func() { a.c <- 41 }()
+
+	v, err := js.Await(p)
+	if err != nil {
+		t.Fatalf("Got: awaiting the wrapped method returned error: %v. Want: no error.", err)
+	}
+	if got := v.Int(); got != 42 {
+		t.Errorf("Got: wrapped method result %d. Want: 42.", got)
+	}
+}
+
+func TestAsyncFunc(t *testing.T) {
+	c := make(chan string)
+	fn := js.AsyncFunc(func(prefix string) (string, int) {
+		return prefix + <-c, 2
+	})
+
+	p := fn.Invoke("foo")
+	go func() { c <- "bar" }()
+
+	v, err := js.Await(p)
+	if err != nil {
+		t.Fatalf("Got: awaiting the async function returned error: %v. Want: no error.", err)
+	}
+	if got := v.Index(0).String(); got != "foobar" {
+		t.Errorf("Got: first result %q. Want: %q.", got, "foobar")
+	}
+	if got := v.Index(1).Int(); got != 2 {
+		t.Errorf("Got: second result %d. Want: 2.", got)
+	}
+}
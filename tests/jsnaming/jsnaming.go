@@ -0,0 +1,13 @@
+// Package jsnaming declares struct types with JavaScript property names
+// derived by the gopherjs:js-naming directive, for use in tests.
+//
+//gopherjs:js-naming camelCase
+package jsnaming
+
+// User is converted to a JavaScript object with camelCase property names.
+type User struct {
+	UserID   int
+	FullName string
+	HTTPHost string `js:"host"`
+	Password string `js:"-"`
+}
@@ -49,3 +49,36 @@ func TestDeferBuiltin(t *testing.T) {
 		t.Fatalf("%v:\n%s", err, got)
 	}
 }
+
+func TestPanicTrace(t *testing.T) {
+	if runtime.GOOS == "js" {
+		t.Skip("test meant to be run using normal Go compiler (needs os/exec)")
+	}
+
+	for _, sourceMap := range []string{"--source_map=true", "--source_map=false"} {
+		t.Run(sourceMap, func(t *testing.T) {
+			cmd := exec.Command("gopherjs", "run", sourceMap, filepath.Join("testdata", "panic_trace.go"))
+			out, err := cmd.CombinedOutput()
+			got := strings.ReplaceAll(string(out), "\r\n", "\n")
+			exitErr, ok := err.(*exec.ExitError)
+			if !ok {
+				t.Fatalf("Got: gopherjs run error %v. Want: exit error.\n%s", err, got)
+			}
+			if code := exitErr.ExitCode(); code != 2 {
+				t.Errorf("Got: exit code %d. Want: 2.", code)
+			}
+
+			for _, want := range []string{
+				"deferred call\npanic: boom\n\ngoroutine 1 [running]:\n",
+				"main.failure.fail(...)\n\t",
+				"panic_trace.go:8\n",
+				"main.main(...)\n\t",
+				"panic_trace.go:13\n",
+			} {
+				if !strings.Contains(got, want) {
+					t.Errorf("Got output:\n%s\nWant it to contain: %q.", got, want)
+				}
+			}
+		})
+	}
+}
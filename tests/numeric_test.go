@@ -98,6 +98,65 @@ func BenchmarkMul64(b *testing.B) {
 	})
 }
 
+// BenchmarkInt64Arithmetic measures common 64-bit integer operations. Run it
+// with and without GOPHERJS_EXPERIMENT=bigint64 to compare the default
+// two-word representation with the native BigInt one.
+func BenchmarkInt64Arithmetic(b *testing.B) {
+	r := rand.New(rand.NewSource(0x5EED))
+	const size = 1024
+	x := [size]int64{}
+	y := [size]int64{}
+	for i := 0; i < size; i++ {
+		x[i] = r.Int63() | (r.Int63n(2) << 63)
+		y[i] = r.Int63() | 1
+	}
+
+	b.Run("add", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			runtime.KeepAlive(x[i%size] + y[i%size])
+		}
+	})
+	b.Run("div", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			runtime.KeepAlive(x[i%size] / y[i%size])
+		}
+	})
+	b.Run("shift", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			runtime.KeepAlive(x[i%size] >> (uint(i) % 64))
+		}
+	})
+	b.Run("compare", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			runtime.KeepAlive(x[i%size] < y[i%size])
+		}
+	})
+	b.Run("convert", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			runtime.KeepAlive(float64(x[i%size]) + float64(int32(y[i%size])))
+		}
+	})
+}
+
+func TestInt64Externalize(t *testing.T) {
+	if runtime.GOOS != "js" {
+		t.Skip("test uses GopherJS-specific features")
+	}
+
+	want := "number"
+	if js.Global.Get("$bigInt64").Bool() {
+		want = "bigint"
+	}
+	o := js.Global.Get("Object").New()
+	o.Set("v", int64(-1)<<40)
+	if got := js.Global.Call("eval", "(function(o) { return typeof o.v; })").Invoke(o).String(); got != want {
+		t.Errorf("Got: typeof int64 = %q. Want: %q.", got, want)
+	}
+	if got := o.Get("v").Int64(); got != int64(-1)<<40 {
+		t.Errorf("Got: round trip = %d. Want: %d.", got, int64(-1)<<40)
+	}
+}
+
 func TestIssue733(t *testing.T) {
 	if runtime.GOOS != "js" {
 		t.Skip("test uses GopherJS-specific features")
@@ -5,9 +5,11 @@ package tests
 import (
 	"fmt"
 	"runtime"
+	"runtime/metrics"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 	_ "unsafe"
 
 	"github.com/google/go-cmp/cmp"
@@ -56,6 +58,11 @@ func Test_parseCallFrame(t *testing.T) {
 			input: "at REPLServer.runBound [as eval] (domain.js:440:12)",
 			want:  "eval domain.js 440 12",
 		},
+		{
+			name:  "Node.js v20, async function",
+			input: "    at async Object.$init (/tmp/main.js:3539:4)",
+			want:  "Object.$init /tmp/main.js 3539 4",
+		},
 		{
 			name:  "Firefox 78.15.0esr Linux",
 			input: "getEvalResult@resource://devtools/server/actors/webconsole/eval-with-debugger.js:231:24",
@@ -177,6 +184,29 @@ func TestCallers(t *testing.T) {
 	})
 }
 
+func TestCallerPosition(t *testing.T) {
+	if js.Global.Get("$goPositions") == nil {
+		t.Skip("Test requires the position table, which minified builds omit by default.")
+	}
+	_, file, line, ok := runtime.Caller(0) // Keep callerLine in sync.
+	const callerLine = 191
+	if !ok {
+		t.Fatalf("Got: runtime.Caller(0) failed. Want: success.")
+	}
+	if !strings.HasSuffix(file, "runtime_test.go") {
+		t.Errorf("Got: runtime.Caller(0) file %q. Want: runtime_test.go.", file)
+	}
+	if line != callerLine {
+		t.Errorf("Got: runtime.Caller(0) line %d. Want: %d.", line, callerLine)
+	}
+
+	pc := [1]uintptr{}
+	runtime.Callers(1, pc[:])
+	if got, want := runtime.FuncForPC(pc[0]).Name(), "github.com/gopherjs/gopherjs/tests.TestCallerPosition"; got != want {
+		t.Errorf("Got: runtime.FuncForPC().Name() = %q. Want: %q.", got, want)
+	}
+}
+
 // Need this to tunnel into `internal/godebug` and run a test
 // without causing a dependency cycle with the `testing` package.
 //
@@ -215,3 +245,111 @@ func Test_GoDebugInjection(t *testing.T) {
 	t.Setenv(`NOT_GODEBUG`, `gopherJSTest=bob`)
 	check(`"gopherJSTest=tom", "gopherJSTest=sam"`)
 }
+
+func TestSetFinalizer(t *testing.T) {
+	type resource struct{ handle int }
+
+	t.Run("FieldAccess", func(t *testing.T) {
+		r := &resource{handle: 1}
+		runtime.SetFinalizer(r, func(*resource) {})
+		r.handle = 42
+		if r.handle != 42 {
+			t.Errorf("Got r.handle=%d after SetFinalizer(). Want: 42.", r.handle)
+		}
+		runtime.SetFinalizer(r, nil)
+		runtime.KeepAlive(r)
+	})
+
+	t.Run("NotPointer", func(t *testing.T) {
+		defer func() {
+			err := recover()
+			if err == nil {
+				t.Fatalf("SetFinalizer() with a non-pointer argument didn't panic.")
+			}
+			want := "runtime error: runtime.SetFinalizer: first argument is int, not pointer"
+			if got := fmt.Sprint(err); got != want {
+				t.Errorf("Got panic: %q. Want: %q.", got, want)
+			}
+		}()
+		runtime.SetFinalizer(42, func(int) {})
+	})
+
+	t.Run("Collected", func(t *testing.T) {
+		if js.Global.Get("gc") == js.Undefined {
+			t.Skip("Garbage collection can't be triggered, run Node.js with --expose-gc.")
+		}
+		finalized := make(chan int, 1)
+		func() {
+			r := &resource{handle: 1}
+			runtime.SetFinalizer(r, func(r *resource) { finalized <- r.handle })
+			r.handle = 42
+		}()
+		runtime.GC()
+
+		select {
+		case got := <-finalized:
+			if got != 42 {
+				t.Errorf("Finalizer got handle=%d. Want: 42.", got)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("Finalizer didn't run after runtime.GC().")
+		}
+	})
+}
+
+func TestReadMemStats(t *testing.T) {
+	if js.Global.Get("process") == js.Undefined {
+		t.Skip("Memory usage is only guaranteed to be reported under Node.js.")
+	}
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	if m.HeapAlloc == 0 || m.Sys == 0 {
+		t.Errorf("Got HeapAlloc=%d, Sys=%d. Want: non-zero values.", m.HeapAlloc, m.Sys)
+	}
+	if m.HeapSys < m.HeapInuse {
+		t.Errorf("Got HeapSys=%d < HeapInuse=%d. Want: HeapSys >= HeapInuse.", m.HeapSys, m.HeapInuse)
+	}
+
+	before := m.TotalAlloc
+	garbage := make([][]int, 0, 1000)
+	for i := 0; i < cap(garbage); i++ {
+		garbage = append(garbage, make([]int, 100))
+	}
+	runtime.ReadMemStats(&m)
+	if m.TotalAlloc < before || m.TotalAlloc < m.Alloc {
+		t.Errorf("Got TotalAlloc=%d (was %d), Alloc=%d. Want: TotalAlloc monotonic and not less than Alloc.", m.TotalAlloc, before, m.Alloc)
+	}
+	runtime.KeepAlive(garbage)
+}
+
+func TestMetrics(t *testing.T) {
+	descs := metrics.All()
+	samples := make([]metrics.Sample, len(descs))
+	names := map[string]bool{}
+	for i, d := range descs {
+		samples[i].Name = d.Name
+		names[d.Name] = true
+	}
+	for _, name := range []string{"/sched/goroutines:goroutines", "/gopherjs/sched/goroutines-created:goroutines"} {
+		if !names[name] {
+			t.Errorf("Metric %q is not listed by metrics.All().", name)
+		}
+	}
+
+	metrics.Read(samples)
+	for _, s := range samples {
+		if s.Value.Kind() != metrics.KindUint64 {
+			t.Errorf("Got metric %q of kind %v. Want: %v.", s.Name, s.Value.Kind(), metrics.KindUint64)
+			continue
+		}
+		if s.Name == "/sched/goroutines:goroutines" && s.Value.Uint64() == 0 {
+			t.Errorf("Got zero value of %q. Want: at least one goroutine.", s.Name)
+		}
+	}
+
+	bad := []metrics.Sample{{Name: "/gc/heap/allocs-by-size:bytes"}}
+	metrics.Read(bad)
+	if bad[0].Value.Kind() != metrics.KindBad {
+		t.Errorf("Got unsupported metric of kind %v. Want: %v.", bad[0].Value.Kind(), metrics.KindBad)
+	}
+}
@@ -0,0 +1,71 @@
+// This program is built with each of the GOPHERJS_EXPERIMENT flags and -O by
+// BenchmarkBuildSize, and exercises the code they change: blocking functions,
+// calls through interfaces and function values, small functions and struct
+// fields.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type shape interface {
+	area() float64
+	name() string
+}
+
+type rect struct{ width, height float64 }
+
+func (r rect) area() float64 { return r.width * r.height }
+func (r rect) name() string  { return "rect" }
+
+type circle struct{ radius float64 }
+
+func (c circle) area() float64 { return 3 * c.radius * c.radius }
+func (c circle) name() string  { return "circle" }
+
+func produce(shapes []shape, c chan<- shape) {
+	defer close(c)
+	for _, s := range shapes {
+		c <- s
+	}
+}
+
+func total(c <-chan shape, scale func(float64) float64) (sum float64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("recovered: %v", r)
+		}
+	}()
+	for s := range c {
+		if s.area() < 0 {
+			panic(errors.New("negative area"))
+		}
+		sum += scale(s.area())
+	}
+	return sum, nil
+}
+
+func double(x float64) float64 { return 2 * x }
+
+func main() {
+	shapes := []shape{rect{2, 3}, circle{1}, rect{1, 1}}
+	c := make(chan shape)
+	go produce(shapes, c)
+	sum, err := total(c, double)
+	fmt.Println(sum, err)
+
+	c = make(chan shape)
+	go produce([]shape{rect{-1, 1}}, c)
+	_, err = total(c, double)
+	fmt.Println(err)
+
+	names := make([]string, 0, len(shapes))
+	for _, s := range shapes {
+		names = append(names, s.name())
+	}
+	sort.Strings(names)
+	fmt.Println(strings.Join(names, ","))
+}
@@ -0,0 +1,14 @@
+package main
+
+import "fmt"
+
+type failure struct{}
+
+func (failure) fail() {
+	panic(fmt.Errorf("boom"))
+}
+
+func main() {
+	defer fmt.Println("deferred call")
+	failure{}.fail()
+}
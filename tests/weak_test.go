@@ -0,0 +1,53 @@
+//go:build js && gopherjs
+
+package tests
+
+import (
+	"testing"
+	"unique"
+	"weak"
+)
+
+func TestWeakPointer(t *testing.T) {
+	type payload struct{ n int }
+
+	p := &payload{n: 42}
+	wp1 := weak.Make(p)
+	wp2 := weak.Make(p)
+	if wp1 != wp2 {
+		t.Errorf("Got weak.Make(p) != weak.Make(p). Want: equal weak pointers.")
+	}
+	if got := wp1.Value(); got != p {
+		t.Errorf("Got wp.Value() = %p. Want: %p.", got, p)
+	}
+	if other := weak.Make(&payload{n: 42}); other == wp1 {
+		t.Errorf("Got weak pointers to different objects comparing equal.")
+	}
+
+	var zero weak.Pointer[payload]
+	if zero != weak.Make[payload](nil) {
+		t.Errorf("Got zero weak.Pointer != weak.Make(nil). Want: equal weak pointers.")
+	}
+	if got := zero.Value(); got != nil {
+		t.Errorf("Got zero.Value() = %p. Want: nil.", got)
+	}
+}
+
+func TestUniqueHandle(t *testing.T) {
+	type point struct{ x, y int }
+
+	if unique.Make("foo") != unique.Make("f"+"oo") {
+		t.Errorf("Got different handles for equal strings.")
+	}
+	if unique.Make("foo") == unique.Make("bar") {
+		t.Errorf("Got equal handles for different strings.")
+	}
+
+	h := unique.Make(point{1, 2})
+	if h != unique.Make(point{1, 2}) {
+		t.Errorf("Got different handles for equal structs.")
+	}
+	if got := h.Value(); got != (point{1, 2}) {
+		t.Errorf("Got h.Value() = %v. Want: %v.", got, point{1, 2})
+	}
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/gopherjs/gopherjs/compiler"
 	"github.com/gopherjs/gopherjs/compiler/errlist"
 	"github.com/gopherjs/gopherjs/compiler/incjs"
+	"github.com/gopherjs/gopherjs/internal/bindgen"
 	"github.com/gopherjs/gopherjs/internal/sourcemapx"
 	"github.com/gopherjs/gopherjs/internal/sysutil"
 )
@@ -78,11 +79,13 @@ func main() {
 
 	compilerFlags := pflag.NewFlagSet("", 0)
 	compilerFlags.BoolVarP(&options.Minify, "minify", "m", false, "minify generated code")
+	compilerFlags.BoolVarP(&options.Optimize, "optimize", "O", false, "inline small functions and fold constant branches")
 	compilerFlags.BoolVar(&options.Color, "color", term.IsTerminal(int(os.Stderr.Fd())) && os.Getenv("TERM") != "dumb", "colored output")
 	compilerFlags.StringVar(&tags, "tags", "", "a list of build tags to consider satisfied during the build")
 	compilerFlags.BoolVar(&options.MapToLocalDisk, "localmap", false, "use local paths for sourcemap")
 	compilerFlags.BoolVarP(&options.NoCache, "no_cache", "a", false, "rebuild all packages from scratch")
 	compilerFlags.BoolVarP(&options.CreateMapFile, "source_map", "s", true, "enable generation of source maps")
+	compilerFlags.BoolVar(&options.PositionTable, "position_table", false, "embed Go source positions reported by runtime.Callers into the generated code (default true unless minifying)")
 
 	flagWatch := pflag.NewFlagSet("", 0)
 	flagWatch.BoolVarP(&options.Watch, "watch", "w", false, "watch for changes to the source files")
@@ -92,6 +95,7 @@ func main() {
 		Short: "compile packages and dependencies",
 	}
 	cmdBuild.Flags().StringVarP(&pkgObj, "output", "o", "", "output file")
+	cmdBuild.Flags().StringVar(&options.SizeReport, "size-report", "", "write a report of the output size by package and declaration to the given file, as HTML if it has the .html extension and as JSON otherwise")
 	cmdBuild.Flags().AddFlagSet(flagVerbose)
 	cmdBuild.Flags().AddFlagSet(flagQuiet)
 	cmdBuild.Flags().AddFlagSet(compilerFlags)
@@ -155,7 +159,7 @@ func main() {
 						if pkgObj == "" {
 							pkgObj = filepath.Base(pkg.Dir) + ".js"
 						}
-						if pkg.IsCommand() && !pkg.UpToDate {
+						if pkg.IsCommand() && (!pkg.UpToDate || options.SizeReport != "") {
 							if err := s.WriteCommandPackage(archive, pkgObj); err != nil {
 								return err
 							}
@@ -253,6 +257,98 @@ func main() {
 		return goDoc.Run()
 	}
 
+	cmdBindgen := &cobra.Command{
+		Use:   "bindgen [files]",
+		Short: "generate Go bindings from Web IDL or TypeScript declaration files",
+		Long: "Bindgen generates a Go package wrapping the JavaScript APIs described by the given Web IDL (.idl, .webidl) or TypeScript declaration (.d.ts, .ts) files. " +
+			"Interfaces and dictionaries become structs embedding *js.Object with js:\"name\" field tags, operations become methods. " +
+			"Functions and variables of namespaces, modules and the global scope become package-level functions. " +
+			"Declarations which can't be bound are reported as warnings.",
+		Args: cobra.MinimumNArgs(1),
+	}
+	bindgenPackage := cmdBindgen.Flags().StringP("package", "p", "bindings", "package name of the generated bindings")
+	bindgenOutput := cmdBindgen.Flags().StringP("output", "o", "", "output file, defaults to stdout")
+	cmdBindgen.RunE = func(cmd *cobra.Command, args []string) error {
+		defs := bindgen.NewDefinitions()
+		for _, name := range args {
+			src, err := os.ReadFile(name)
+			if err != nil {
+				return err
+			}
+			switch ext := filepath.Ext(name); ext {
+			case ".idl", ".webidl":
+				err = bindgen.ParseWebIDL(defs, name, src)
+			case ".ts":
+				err = bindgen.ParseTypeScript(defs, name, src)
+			default:
+				err = fmt.Errorf("%s: unsupported file extension %q, want .idl, .webidl, .d.ts or .ts", name, ext)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		for _, warning := range defs.Warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+		}
+		out, err := bindgen.Generate(*bindgenPackage, defs)
+		if err != nil {
+			return err
+		}
+		if *bindgenOutput == "" {
+			_, err := os.Stdout.Write(out)
+			return err
+		}
+		return os.WriteFile(*bindgenOutput, out, 0o666)
+	}
+
+	cmdWhy := &cobra.Command{
+		Use:   "why <package> <symbol>",
+		Short: "explain why dead-code elimination keeps a symbol in the output",
+		Long: "Why prints the shortest chain of live declarations through which a program keeps the given symbol of a package, starting with an entry point, " +
+			"such as the main or an init function, a variable with a side-effecting initializer or a go:linkname implementation. " +
+			"Symbols are named as in the package, for example Sprintf, pp or (*pp).doPrintf.",
+		Args: cobra.ExactArgs(2),
+	}
+	whyMain := cmdWhy.Flags().String("main", ".", "main package of the program to analyze")
+	cmdWhy.Flags().AddFlagSet(flagVerbose)
+	cmdWhy.Flags().AddFlagSet(flagQuiet)
+	cmdWhy.Flags().AddFlagSet(compilerFlags)
+	cmdWhy.RunE = func(cmd *cobra.Command, args []string) error {
+		options.BuildTags = strings.Fields(tags)
+		s, err := gbuild.NewSession(options)
+		if err != nil {
+			return err
+		}
+		xctx := gbuild.NewBuildContext(s.InstallSuffix(), options.BuildTags)
+		pkg, err := xctx.Import(*whyMain, currentDirectory, 0)
+		if err != nil {
+			return err
+		}
+		if !pkg.IsCommand() {
+			return fmt.Errorf("package %s is not a main package", pkg.ImportPath)
+		}
+		archive, err := s.BuildProject(pkg)
+		if err != nil {
+			return err
+		}
+		deps, err := compiler.ImportDependencies(archive, s.ImportResolverFor(""))
+		if err != nil {
+			return err
+		}
+		steps, err := compiler.ExplainLiveness(deps, args[0], args[1])
+		if err != nil {
+			return err
+		}
+		for i, step := range steps {
+			if i == 0 {
+				fmt.Printf("%s (%s)\n", step.Decl.FullName, step.Root)
+				continue
+			}
+			fmt.Printf("%s-> %s (via %s)\n", strings.Repeat("  ", i-1), step.Decl.FullName, step.Dep)
+		}
+		return nil
+	}
+
 	cmdGet := &cobra.Command{
 		Use:   "get [packages]",
 		Short: "download and install packages and dependencies",
@@ -538,7 +634,7 @@ func main() {
 		SilenceUsage:  true,
 		SilenceErrors: true,
 	}
-	rootCmd.AddCommand(cmdBuild, cmdGet, cmdInstall, cmdRun, cmdTest, cmdServe, cmdVersion, cmdDoc, cmdClean)
+	rootCmd.AddCommand(cmdBuild, cmdGet, cmdInstall, cmdRun, cmdTest, cmdServe, cmdVersion, cmdDoc, cmdClean, cmdBindgen, cmdWhy)
 
 	{
 		var logLevel string
@@ -555,6 +651,12 @@ func main() {
 			}
 			log.SetLevel(lvl)
 
+			// Minified builds are meant to be small, so they only embed the position
+			// table if asked to explicitly.
+			if !compilerFlags.Lookup("position_table").Changed {
+				options.PositionTable = !options.Minify
+			}
+
 			if cpuProfile != "" {
 				f, err := os.Create(cpuProfile)
 				if err != nil {
@@ -708,6 +810,7 @@ func (fs serveCommandFileSystem) Open(requestName string) (http.File, error) {
 
 				sourceMapFilter := &sourcemapx.Filter{Writer: buf}
 				s.EnableMapping(sourceMapFilter, base+`.js`)
+				s.EnablePositionTable(sourceMapFilter)
 
 				deps, err := compiler.ImportDependencies(archive, s.ImportResolverFor(""))
 				if err != nil {